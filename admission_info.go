@@ -0,0 +1,79 @@
+package admissioncontrol
+
+import (
+	"context"
+	"net/http"
+
+	admission "k8s.io/api/admission/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// AdmissionInfo holds the metadata of a decoded AdmissionRequest, so that
+// middleware (e.g. tracing or metrics) can label requests without decoding the
+// AdmissionReview themselves.
+type AdmissionInfo struct {
+	UID       types.UID
+	Kind      meta.GroupVersionKind
+	Namespace string
+}
+
+// admissionInfoKey is the context key for the *AdmissionInfo of a request.
+type admissionInfoKey struct{}
+
+// WithAdmissionInfo returns a copy of ctx that an AdmissionHandler will populate
+// with the AdmissionInfo of the request it evaluates. Pass the returned context
+// to AdmissionHandler.Evaluate, and read it back with AdmissionInfoFromContext
+// once Evaluate returns.
+//
+// HTTP servers should use AdmissionInfoMiddleware instead.
+func WithAdmissionInfo(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(admissionInfoKey{}).(*AdmissionInfo); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, admissionInfoKey{}, &AdmissionInfo{})
+}
+
+// AdmissionInfoFromContext returns the AdmissionInfo populated by an
+// AdmissionHandler, and false if the context was not prepared with
+// WithAdmissionInfo (or AdmissionInfoMiddleware), or the request could not be
+// decoded.
+func AdmissionInfoFromContext(ctx context.Context) (AdmissionInfo, bool) {
+	info, ok := ctx.Value(admissionInfoKey{}).(*AdmissionInfo)
+	if !ok || info.UID == "" {
+		return AdmissionInfo{}, false
+	}
+
+	return *info, true
+}
+
+// AdmissionInfoMiddleware prepares the context of each request so that the
+// AdmissionInfo decoded by the wrapped AdmissionHandler is available to the
+// middleware around it, via AdmissionInfoFromContext(r.Context()), after the
+// next handler has returned.
+//
+// It should be the outermost middleware that needs to read the AdmissionInfo.
+func AdmissionInfoMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithAdmissionInfo(r.Context())))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// setAdmissionInfo populates the AdmissionInfo in ctx, if any, from request.
+func setAdmissionInfo(ctx context.Context, request *admission.AdmissionRequest) {
+	info, ok := ctx.Value(admissionInfoKey{}).(*AdmissionInfo)
+	if !ok {
+		return
+	}
+
+	*info = AdmissionInfo{
+		UID:       request.UID,
+		Kind:      request.Kind,
+		Namespace: request.Namespace,
+	}
+}
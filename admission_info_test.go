@@ -0,0 +1,86 @@
+package admissioncontrol
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdmissionInfoMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := &AdmissionHandler{
+		AdmitFunc: newTestAdmitFunc(true, false),
+		Logger:    &noopLogger{},
+	}
+
+	var (
+		info AdmissionInfo
+		ok   bool
+	)
+
+	// A downstream middleware, such as one that records traces, reads the
+	// AdmissionInfo once the AdmissionHandler has evaluated the request.
+	tracingMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			info, ok = AdmissionInfoFromContext(r.Context())
+		})
+	}
+
+	srv := AdmissionInfoMiddleware()(tracingMiddleware(handler))
+
+	body := []byte(`{"request":{"uid":"abc-123","namespace":"default","kind":{"group":"","version":"v1","kind":"Pod"}}}`)
+	req := httptest.NewRequest(http.MethodPost, "/admit", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+
+	if !ok {
+		t.Fatalf("the AdmissionInfo was not available to the middleware")
+	}
+
+	if info.UID != "abc-123" {
+		t.Fatalf("UIDs do not match: got %q - expected %q", info.UID, "abc-123")
+	}
+
+	if info.Namespace != "default" || info.Kind.Kind != "Pod" {
+		t.Fatalf("the AdmissionInfo does not match the request: got %+v", info)
+	}
+}
+
+func TestAdmissionInfoFromContext(t *testing.T) {
+	t.Parallel()
+
+	handler := &AdmissionHandler{
+		AdmitFunc: newTestAdmitFunc(true, false),
+		Logger:    &noopLogger{},
+	}
+
+	if _, ok := AdmissionInfoFromContext(context.Background()); ok {
+		t.Fatalf("a context without an AdmissionInfo should not return one")
+	}
+
+	ctx := WithAdmissionInfo(context.Background())
+	if _, ok := AdmissionInfoFromContext(ctx); ok {
+		t.Fatalf("an AdmissionInfo should not be returned before a request is evaluated")
+	}
+
+	if _, err := handler.Evaluate(ctx, []byte(`{`)); err == nil {
+		t.Fatalf("expected an error for a malformed request")
+	}
+
+	if _, ok := AdmissionInfoFromContext(ctx); ok {
+		t.Fatalf("an AdmissionInfo should not be returned for a malformed request")
+	}
+
+	if _, err := handler.Evaluate(ctx, []byte(`{"request":{"uid":"abc-123"}}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, ok := AdmissionInfoFromContext(ctx)
+	if !ok || info.UID != "abc-123" {
+		t.Fatalf("the AdmissionInfo was not populated by Evaluate: got %+v", info)
+	}
+}
@@ -1,18 +1,50 @@
 package admissioncontrol
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"golang.org/x/xerrors"
+	"math"
+	"net"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"golang.org/x/xerrors"
 	admission "k8s.io/api/admission/v1beta1"
 	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
 	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networking "k8s.io/api/networking/v1"
+	policy "k8s.io/api/policy/v1"
+	rbac "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// clusterAdminRole is the name of the built-in superuser ClusterRole.
+	clusterAdminRole = "cluster-admin"
+	// defaultServiceAccount is the name of the ServiceAccount created in every
+	// namespace, and used by Pods that do not specify one.
+	defaultServiceAccount = "default"
+	// privilegedPortThreshold is the first non-privileged port number.
+	privilegedPortThreshold = 1024
+	// registryTimeout bounds the time spent querying a registry for a single
+	// admission request; the apiserver's default webhook timeout is 10s.
+	registryTimeout = time.Second * 5
 )
 
 var (
@@ -63,9 +95,18 @@ func newDefaultDenyResponse() *admission.AdmissionResponse {
 //
 // Kinds other than Ingress will be allowed.
 func DenyIngresses(ignoredNamespaces []string) AdmitFunc {
+	return DenyIngressesScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyIngressesScoped is DenyIngresses, enforced within the namespaces of the
+// given scope.
+func DenyIngressesScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
 	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
 		kind := admissionReview.Request.Kind.Kind // Base Kind - e.g. "Service" as opposed to "v1/Service"
-		resp := newDefaultDenyResponse()
 
 		switch kind {
 		case "Ingress":
@@ -76,18 +117,14 @@ func DenyIngresses(ignoredNamespaces []string) AdmitFunc {
 			}
 
 			// Ignore objects in whitelisted namespaces.
-			for _, ns := range ignoredNamespaces {
-				if ingress.Namespace == ns {
-					resp.Allowed = true
-					resp.Result.Message = fmt.Sprintf("allowing admission: %s namespace is whitelisted", ingress.Namespace)
-					return resp, nil
-				}
+			namespace := requestNamespace(admissionReview, ingress.Namespace)
+			if resp, ok := scope.allow(namespace); ok {
+				return resp, nil
 			}
 
-			return nil, xerrors.Errorf("%s objects cannot be deployed to this cluster", kind)
+			return nil, denyf(ReasonIngressForbidden, "%s objects cannot be deployed to this cluster", kind)
 		default:
-			resp.Allowed = true
-			return resp, nil
+			return PassThroughUnsupportedKind(admissionReview), nil
 		}
 	}
 }
@@ -103,20 +140,38 @@ func DenyIngresses(ignoredNamespaces []string) AdmitFunc {
 // Services with a .spec.type other than LoadBalancer will NOT be rejected by
 // this handler.
 //
+// Multiple providers can be configured - e.g. for multi-cloud clusters, or
+// during a migration - and a Service is treated as internal if it has the
+// internal-only annotations of any of them. At least one provider is required.
+//
 // Providing an empty/nil list of ignoredNamespaces will reject LoadBalancers
 // across all namespaces.
-func DenyPublicLoadBalancers(ignoredNamespaces []string, provider CloudProvider) AdmitFunc {
+func DenyPublicLoadBalancers(ignoredNamespaces []string, providers ...CloudProvider) AdmitFunc {
+	return DenyPublicLoadBalancersScoped(NamespaceScope{Ignored: ignoredNamespaces}, providers...)
+}
+
+// DenyPublicLoadBalancersScoped is DenyPublicLoadBalancers, enforced within the
+// namespaces of the given scope.
+func DenyPublicLoadBalancersScoped(scope NamespaceScope, providers ...CloudProvider) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
 	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
 		kind := admissionReview.Request.Kind.Kind
 		resp := newDefaultDenyResponse()
 
+		if kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
 		service := core.Service{}
 		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
 		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
 			return nil, err
 		}
 
-		if kind != "Service" || service.Spec.Type != "LoadBalancer" {
+		if service.Spec.Type != "LoadBalancer" {
 			resp.Allowed = true
 			resp.Result.Message = fmt.Sprintf(
 				"DenyPublicLoadBalancers received a non-LoadBalancer type (%s)",
@@ -126,134 +181,408 @@ func DenyPublicLoadBalancers(ignoredNamespaces []string, provider CloudProvider)
 		}
 
 		// Ignore objects in whitelisted namespaces.
-		for _, ns := range ignoredNamespaces {
-			if service.Namespace == ns {
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if len(providers) == 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "at least one provider must be configured to validate internal load balancer annotations")
+		}
+
+		for _, provider := range providers {
+			if _, ok := ilbAnnotations[provider]; !ok {
+				return resp, denyf(ReasonInvalidConfiguration, "internal load balancer annotations for the given provider (%q) are not supported", provider)
+			}
+		}
+
+		// TODO(matt): If we're missing any annotations, provide them in the AdmissionResponse so
+		// the user can correct them.
+		for _, provider := range providers {
+			if _, ok := ensureHasAnnotations(ilbAnnotations[provider], service.ObjectMeta.Annotations); ok {
+				// No missing or invalid annotations for this provider; allow admission
 				resp.Allowed = true
-				resp.Result.Message = fmt.Sprintf("allowing admission: %s namespace is whitelisted", service.Namespace)
 				return resp, nil
 			}
 		}
 
-		expectedAnnotations, ok := ilbAnnotations[provider]
-		if !ok {
-			return resp, xerrors.Errorf("internal load balancer annotations for the given provider (%q) are not supported", provider)
+		return resp, denyf(ReasonPublicLoadBalancer, "%s objects of type: LoadBalancer without an internal-only annotation cannot be deployed to this cluster", kind)
+	}
+}
+
+// ValidateLocalTrafficPolicy validates that Services of type: LoadBalancer with
+// an externalTrafficPolicy of "Local" are internally consistent. Cloud load
+// balancers rely on the Service's healthCheckNodePort to find nodes with local
+// endpoints; a misconfiguration will silently drop traffic.
+//
+// Services are rejected when:
+//
+// 1. A healthCheckNodePort is set, but the externalTrafficPolicy is not Local.
+//
+// 2. The healthCheckNodePort conflicts with one of the Service's nodePorts.
+//
+// A warning is returned (but admission allowed) for Local Services without a
+// selector, as their health checks then depend on manually managed Endpoints.
+//
+// Kinds other than Service, and Services of other types, will be allowed.
+func ValidateLocalTrafficPolicy(ignoredNamespaces []string) AdmitFunc {
+	return ValidateLocalTrafficPolicyScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// ValidateLocalTrafficPolicyScoped is ValidateLocalTrafficPolicy, enforced
+// within the namespaces of the given scope.
+func ValidateLocalTrafficPolicyScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
 		}
 
-		// TODO(matt): If we're missing any annotations, provide them in the AdmissionResponse so
-		// the user can correct them.
-		if _, ok := ensureHasAnnotations(expectedAnnotations, service.ObjectMeta.Annotations); !ok {
-			return resp, xerrors.Errorf("%s objects of type: LoadBalancer without an internal-only annotation cannot be deployed to this cluster", kind)
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		if service.Spec.Type != core.ServiceTypeLoadBalancer {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		healthCheckNodePort := service.Spec.HealthCheckNodePort
+		if service.Spec.ExternalTrafficPolicy != core.ServiceExternalTrafficPolicyTypeLocal {
+			if healthCheckNodePort != 0 {
+				return resp, denyf(
+					ReasonInvalidTrafficPolicy,
+					"%s objects may only set a healthCheckNodePort (%d) with externalTrafficPolicy: Local",
+					kind,
+					healthCheckNodePort,
+				)
+			}
+
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		for _, port := range service.Spec.Ports {
+			if healthCheckNodePort != 0 && port.NodePort == healthCheckNodePort {
+				return resp, denyf(
+					ReasonInvalidTrafficPolicy,
+					"%s objects with externalTrafficPolicy: Local cannot use the healthCheckNodePort (%d) as the nodePort of port %q",
+					kind,
+					healthCheckNodePort,
+					port.Name,
+				)
+			}
+		}
+
+		if len(service.Spec.Selector) == 0 {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+				"Service %q has externalTrafficPolicy: Local but no selector: load balancer health checks will fail unless its Endpoints are managed manually",
+				service.Name,
+			))
 		}
 
-		// No missing or invalid annotations; allow admission
 		resp.Allowed = true
 		return resp, nil
 	}
 }
 
-// EnforcePodAnnotations ensures that Pods have the required annotations by
-// looking for a strict (case-sensitive) key-match, and then running the
-// matchFunc (a func(string) bool) over the value.
-//
-// This allows the caller to perform flexible matching - checking for valid DNS
-// names or a list of accepted values - rather than having to iterate over all
-// possible values, which may not be possible.
+// DenyCrossNamespaceSelectorOverlap denies any Service whose selector matches
+// one of the protectedLabels - e.g. "k8s-app: kube-dns" - used by control-plane
+// and other system components. This prevents a Service in a tenant namespace
+// from selecting (and receiving traffic for) system Pods.
 //
-// EnforcePodAnnotations can inspect Pods, Deployments, StatefulSets, DaemonSets &
-// Jobs.
+// A selector overlaps when any of its key/value pairs is also a protected
+// label. Services in the kube-system namespace are not rejected, as they
+// legitimately select system Pods.
 //
-// Unknown object kinds are rejected. You can create multiple versions of
-// this AdmitFunc for a given ValidatingAdmissionWebhook configuration if you
-// wish to apply different configurations per kind or namespace.
-func EnforcePodAnnotations(ignoredNamespaces []string, requiredAnnotations map[string]func(string) bool) AdmitFunc {
+// Kinds other than Service will be allowed.
+func DenyCrossNamespaceSelectorOverlap(protectedLabels map[string]string) AdmitFunc {
 	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
 		kind := admissionReview.Request.Kind.Kind
 		resp := newDefaultDenyResponse()
 
+		if kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
 		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
 
-		// We handle all built-in Kinds that include a PodTemplateSpec, as described here:
-		// https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.15/#pod-v1-core
-		var namespace string
-		annotations := make(map[string]string)
-		// Extract the necessary metadata from our known Kinds
-		switch kind {
-		case "Pod":
-			pod := core.Pod{}
-			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &pod); err != nil {
-				return nil, err
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := allowIgnoredNamespace([]string{metav1.NamespaceSystem}, namespace); ok {
+			return resp, nil
+		}
+
+		overlapping := make(map[string]string)
+		for key, val := range service.Spec.Selector {
+			if protectedVal, ok := protectedLabels[key]; ok && protectedVal == val {
+				overlapping[key] = val
 			}
+		}
 
-			namespace = pod.GetNamespace()
-			annotations = pod.GetAnnotations()
-		case "Deployment":
-			deployment := apps.Deployment{}
-			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &deployment); err != nil {
-				return nil, err
+		if len(overlapping) > 0 {
+			return resp, denyf(
+				ReasonSelectorOverlap,
+				"%s objects with a selector matching protected system labels cannot be deployed to this cluster: %v",
+				kind,
+				overlapping,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyNonFQDNExternalNames denies any Service of type: ExternalName whose
+// externalName is not a fully-qualified domain name. Short names (e.g. "db")
+// are resolved against the search domains of each Pod, and can resolve
+// unexpectedly.
+//
+// An externalName must contain at least one dot, be a valid DNS name, and not
+// be an IP address.
+//
+// Kinds other than Service, and Services of other types, will be allowed.
+func DenyNonFQDNExternalNames(ignoredNamespaces []string) AdmitFunc {
+	return DenyNonFQDNExternalNamesScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyNonFQDNExternalNamesScoped is DenyNonFQDNExternalNames, enforced within
+// the namespaces of the given scope.
+func DenyNonFQDNExternalNamesScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		if service.Spec.Type != core.ServiceTypeExternalName {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if !isFQDN(service.Spec.ExternalName) {
+			return resp, denyf(
+				ReasonNonFQDNExternalName,
+				"%s objects must set an externalName that is a fully-qualified domain name: %q",
+				kind,
+				service.Spec.ExternalName,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyExternalEndpoints denies any kind: Endpoints or EndpointSlice with an
+// address outside of the given clusterCIDRs (e.g. the cluster's Pod CIDRs).
+// Manually created Endpoints that point at external or node IPs can redirect
+// the traffic of a Service off-cluster.
+//
+// Endpoints managed by the endpoints controllers are also inspected: Services
+// backed by hostNetwork Pods resolve to node IPs, and their namespaces should
+// be ignored, or the node CIDRs included in clusterCIDRs. Addresses that are
+// not IPs (e.g. in an EndpointSlice with an addressType of FQDN) are denied.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than Endpoints and EndpointSlice will be allowed.
+func DenyExternalEndpoints(ignoredNamespaces []string, clusterCIDRs []string) AdmitFunc {
+	return DenyExternalEndpointsScoped(NamespaceScope{Ignored: ignoredNamespaces}, clusterCIDRs)
+}
+
+// DenyExternalEndpointsScoped is DenyExternalEndpoints, enforced within the
+// namespaces of the given scope.
+func DenyExternalEndpointsScoped(scope NamespaceScope, clusterCIDRs []string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		kind := admissionReview.Request.Kind
+		if kind.Kind != "Endpoints" && !(kind.Group == discovery.GroupName && kind.Kind == "EndpointSlice") {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if len(clusterCIDRs) == 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "at least one cluster CIDR must be provided")
+		}
+
+		networks := make([]*net.IPNet, 0, len(clusterCIDRs))
+		for _, cidr := range clusterCIDRs {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return resp, denyf(ReasonInvalidConfiguration, "the cluster CIDR %q is invalid: %v", cidr, err)
 			}
 
-			deployment.GetNamespace()
-			annotations = deployment.Spec.Template.GetAnnotations()
-		case "StatefulSet":
-			statefulset := apps.StatefulSet{}
-			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &statefulset); err != nil {
+			networks = append(networks, network)
+		}
+
+		var (
+			objectNamespace string
+			addresses       []string
+		)
+
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if kind.Kind == "Endpoints" {
+			endpoints := core.Endpoints{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &endpoints); err != nil {
 				return nil, err
 			}
 
-			namespace = statefulset.GetNamespace()
-			annotations = statefulset.Spec.Template.GetAnnotations()
-		case "DaemonSet":
-			daemonset := apps.DaemonSet{}
-			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &daemonset); err != nil {
+			objectNamespace = endpoints.Namespace
+			for _, subset := range endpoints.Subsets {
+				for _, address := range append(subset.Addresses, subset.NotReadyAddresses...) {
+					addresses = append(addresses, address.IP)
+				}
+			}
+		} else {
+			// The v1beta1 and v1 EndpointSlice APIs share the same address fields.
+			slice := discovery.EndpointSlice{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &slice); err != nil {
 				return nil, err
 			}
 
-			namespace = daemonset.GetNamespace()
-			annotations = daemonset.Spec.Template.GetAnnotations()
-		case "Job":
-			job := batch.Job{}
-			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &job); err != nil {
-				return nil, err
+			objectNamespace = slice.Namespace
+			for _, endpoint := range slice.Endpoints {
+				addresses = append(addresses, endpoint.Addresses...)
 			}
+		}
 
-			namespace = job.Spec.Template.GetNamespace()
-			annotations = job.Spec.Template.GetAnnotations()
-		default:
-			// TODO(matt): except for whitelisted namespaces
-			return nil, xerrors.Errorf("the submitted Kind is not supported by this admission handler: %s", kind)
+		namespace := requestNamespace(admissionReview, objectNamespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
 		}
 
-		// Ignore objects in whitelisted namespaces.
-		for _, ns := range ignoredNamespaces {
-			if namespace == ns {
-				resp.Allowed = true
-				resp.Result.Message = fmt.Sprintf("allowing admission: %s namespace is whitelisted", namespace)
-				return resp, nil
+		var external []string
+		for _, address := range addresses {
+			if !containsIP(networks, net.ParseIP(address)) {
+				external = append(external, address)
 			}
 		}
 
-		missing := make(map[string]string)
-		// We check whether the (strictly matched) annotation key exists, and then run
-		// our user-provided matchFunc against it. If we're missing any keys, or the
-		// value for a key does not match, admission is rejected.
-		for requiredKey, matchFunc := range requiredAnnotations {
-			if matchFunc == nil {
-				return resp, xerrors.Errorf("cannot validate annotations (%s) with a nil matchFunc", requiredKey)
-			}
+		if len(external) > 0 {
+			return resp, denyf(
+				ReasonExternalEndpoint,
+				"the submitted %s has addresses outside of the cluster CIDRs %v: %v",
+				kind.Kind,
+				clusterCIDRs,
+				external,
+			)
+		}
 
-			if existingVal, ok := annotations[requiredKey]; !ok {
-				// Key does not exist; add it to the missing annotations list
-				missing[requiredKey] = "key was not found"
-			} else {
-				if matched := matchFunc(existingVal); !matched {
-					missing[requiredKey] = "value did not match"
-				}
-				// Key exists & matchFunc returned OK.
-			}
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// containsIP returns true if ip is within any of the given networks. A nil ip
+// is not contained by any network.
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFQDN returns true if name is a (multi-label) DNS name, and not an IP
+// address. A trailing dot is permitted, and matching is case-insensitive, as
+// DNS names are.
+func isFQDN(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if net.ParseIP(name) != nil {
+		return false
+	}
+
+	return strings.Contains(name, ".") && isDNSName(name)
+}
+
+// EnforcePodAnnotations ensures that Pods have the required annotations by
+// looking for a strict (case-sensitive) key-match, and then running the
+// matchFunc (a func(string) bool) over the value.
+//
+// This allows the caller to perform flexible matching - checking for valid DNS
+// names or a list of accepted values - rather than having to iterate over all
+// possible values, which may not be possible.
+//
+// EnforcePodAnnotations can inspect Pods, Deployments, StatefulSets, DaemonSets &
+// Jobs.
+//
+// Other object kinds are allowed. You can create multiple versions of
+// this AdmitFunc for a given ValidatingAdmissionWebhook configuration if you
+// wish to apply different configurations per kind or namespace.
+func EnforcePodAnnotations(ignoredNamespaces []string, requiredAnnotations map[string]func(string) bool) AdmitFunc {
+	return EnforcePodAnnotationsScoped(NamespaceScope{Ignored: ignoredNamespaces}, requiredAnnotations)
+}
+
+// EnforcePodAnnotationsScoped is EnforcePodAnnotations, enforced within the
+// namespaces of the given scope.
+func EnforcePodAnnotationsScoped(scope NamespaceScope, requiredAnnotations map[string]func(string) bool) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		missing, err := missingAnnotations(pod.annotations, requiredAnnotations)
+		if err != nil {
+			return resp, err
 		}
 
 		if len(missing) > 0 {
-			return resp, xerrors.Errorf("%s %v", podDeniedError, missing)
+			return resp, denyf(ReasonMissingAnnotation, "%s %v", podDeniedError, missing)
 		}
 
 		// No missing or invalid annotations; allow admission
@@ -262,6 +591,5034 @@ func EnforcePodAnnotations(ignoredNamespaces []string, requiredAnnotations map[s
 	}
 }
 
+// EnforcePodAnnotationsAnyOf ensures that Pods satisfy at least one of the
+// provided sets of required annotations. Each set is checked as per
+// EnforcePodAnnotations: every annotation in the set must exist, and its value
+// must match the provided matchFunc.
+//
+// When no set is satisfied, the rejection reports the missing annotations of
+// every set. Providing an empty list of sets is a configuration error, and
+// will reject all Pods.
+//
+// EnforcePodAnnotationsAnyOf can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func EnforcePodAnnotationsAnyOf(ignoredNamespaces []string, sets []map[string]func(string) bool) AdmitFunc {
+	return EnforcePodAnnotationsAnyOfScoped(NamespaceScope{Ignored: ignoredNamespaces}, sets)
+}
+
+// EnforcePodAnnotationsAnyOfScoped is EnforcePodAnnotationsAnyOf, enforced
+// within the namespaces of the given scope.
+func EnforcePodAnnotationsAnyOfScoped(scope NamespaceScope, sets []map[string]func(string) bool) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		if len(sets) == 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "cannot validate annotations without any required annotation sets")
+		}
+
+		misses := make([]string, 0, len(sets))
+		for i, requiredAnnotations := range sets {
+			missing, err := missingAnnotations(pod.annotations, requiredAnnotations)
+			if err != nil {
+				return resp, err
+			}
+
+			if len(missing) == 0 {
+				resp.Allowed = true
+				return resp, nil
+			}
+
+			misses = append(misses, fmt.Sprintf("set %d: %v", i, missing))
+		}
+
+		return resp, denyf(
+			ReasonMissingAnnotation,
+			"the submitted Pods do not satisfy any of the required annotation sets: %s",
+			strings.Join(misses, "; "),
+		)
+	}
+}
+
+// missingAnnotations returns the required annotations that are missing from (or
+// do not match) the given annotations, mapped to the reason they failed.
+func missingAnnotations(annotations map[string]string, requiredAnnotations map[string]func(string) bool) (map[string]string, error) {
+	missing := make(map[string]string)
+	// We check whether the (strictly matched) annotation key exists, and then run
+	// our user-provided matchFunc against it. If we're missing any keys, or the
+	// value for a key does not match, admission is rejected.
+	for requiredKey, matchFunc := range requiredAnnotations {
+		if matchFunc == nil {
+			return nil, denyf(ReasonInvalidConfiguration, "cannot validate annotations (%s) with a nil matchFunc", requiredKey)
+		}
+
+		if existingVal, ok := annotations[requiredKey]; !ok {
+			// Key does not exist; add it to the missing annotations list
+			missing[requiredKey] = "key was not found"
+		} else {
+			if matched := matchFunc(existingVal); !matched {
+				missing[requiredKey] = "value did not match"
+			}
+			// Key exists & matchFunc returned OK.
+		}
+	}
+
+	return missing, nil
+}
+
+// EnforceServiceAccountAnnotations ensures that ServiceAccounts have the
+// required annotations by matching the values of those annotations to the
+// provided matchFunc. This is useful for workload identity, where a
+// ServiceAccount must be annotated with the cloud identity it maps to: e.g.
+// iam.gke.io/gcp-service-account or eks.amazonaws.com/role-arn.
+//
+// Kinds other than ServiceAccount will be allowed.
+func EnforceServiceAccountAnnotations(ignoredNamespaces []string, requiredAnnotations map[string]func(string) bool) AdmitFunc {
+	return EnforceServiceAccountAnnotationsScoped(NamespaceScope{Ignored: ignoredNamespaces}, requiredAnnotations)
+}
+
+// EnforceServiceAccountAnnotationsScoped is EnforceServiceAccountAnnotations,
+// enforced within the namespaces of the given scope.
+func EnforceServiceAccountAnnotationsScoped(scope NamespaceScope, requiredAnnotations map[string]func(string) bool) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "ServiceAccount" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		serviceAccount := core.ServiceAccount{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &serviceAccount); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, serviceAccount.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		missing, err := missingAnnotations(serviceAccount.GetAnnotations(), requiredAnnotations)
+		if err != nil {
+			return resp, err
+		}
+
+		if len(missing) > 0 {
+			return resp, denyf(
+				ReasonMissingAnnotation,
+				"the submitted ServiceAccount %q is missing required annotations: %v",
+				serviceAccount.Name,
+				missing,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireChartVersionAnnotation denies any Deployment, StatefulSet or DaemonSet
+// that does not carry a non-empty annotation with the given key - e.g. the
+// version of the Helm chart that deployed it - so that deployed chart versions
+// can be tracked.
+//
+// Unlike EnforcePodAnnotations, the annotations of the workload itself, and not
+// those of its Pod template, are inspected. Pods and Jobs are not inspected, as
+// they are typically created by another controller. Providing an empty/nil
+// list of ignoredNamespaces will enforce this across all namespaces.
+func RequireChartVersionAnnotation(ignoredNamespaces []string, key string) AdmitFunc {
+	return RequireChartVersionAnnotationScoped(NamespaceScope{Ignored: ignoredNamespaces}, key)
+}
+
+// RequireChartVersionAnnotationScoped is RequireChartVersionAnnotation,
+// enforced within the namespaces of the given scope.
+func RequireChartVersionAnnotationScoped(scope NamespaceScope, key string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		switch normalizeGroupKind(admissionReview.Request.Kind) {
+		case apps.SchemeGroupVersion.WithKind("Deployment").GroupKind(),
+			apps.SchemeGroupVersion.WithKind("StatefulSet").GroupKind(),
+			apps.SchemeGroupVersion.WithKind("DaemonSet").GroupKind():
+		default:
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if key == "" {
+			return resp, denyf(ReasonInvalidConfiguration, "a chart version annotation key must be provided")
+		}
+
+		object := metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(admissionReview.Request.Object.Raw, &object); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, object.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if object.Annotations[key] == "" {
+			return resp, denyf(
+				ReasonMissingAnnotation,
+				"the submitted %s %q is missing the chart version annotation %q",
+				admissionReview.Request.Kind.Kind,
+				object.Name,
+				key,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyClusterAdminBindings denies any RoleBinding or ClusterRoleBinding that
+// grants the built-in "cluster-admin" ClusterRole to its subjects.
+//
+// Bindings that carry the provided allowAnnotation (any value) are allowed, so
+// that exceptions can be made explicitly and are visible on the object itself.
+// Providing an empty allowAnnotation will reject all cluster-admin bindings.
+//
+// Kinds other than RoleBinding and ClusterRoleBinding will be allowed.
+func DenyClusterAdminBindings(allowAnnotation string) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		var (
+			annotations map[string]string
+			roleRef     rbac.RoleRef
+		)
+
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		switch kind {
+		case "ClusterRoleBinding":
+			binding := rbac.ClusterRoleBinding{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &binding); err != nil {
+				return nil, err
+			}
+
+			annotations = binding.GetAnnotations()
+			roleRef = binding.RoleRef
+		case "RoleBinding":
+			binding := rbac.RoleBinding{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &binding); err != nil {
+				return nil, err
+			}
+
+			annotations = binding.GetAnnotations()
+			roleRef = binding.RoleRef
+		default:
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if roleRef.Kind != "ClusterRole" || roleRef.Name != clusterAdminRole {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		if allowAnnotation != "" {
+			if _, ok := annotations[allowAnnotation]; ok {
+				resp.Allowed = true
+				resp.Result.Message = fmt.Sprintf("allowing admission: %s binding is annotated with %q", clusterAdminRole, allowAnnotation)
+				return resp, nil
+			}
+		}
+
+		return resp, denyf(ReasonClusterAdminBinding, "%s objects that bind the %s ClusterRole cannot be deployed to this cluster", kind, clusterAdminRole)
+	}
+}
+
+// aggregationControllerUsers are the identities that the ClusterRole
+// aggregation controller may run as, depending on whether the controller
+// manager uses per-controller service account credentials.
+var aggregationControllerUsers = []string{
+	"system:serviceaccount:kube-system:clusterrole-aggregation-controller",
+	"system:kube-controller-manager",
+}
+
+// DenyAggregatedClusterRoleEdits denies UPDATEs to any ClusterRole that carries
+// an aggregationRule (before or after the update). Editing an aggregated
+// ClusterRole - e.g. changing its selectors - can unexpectedly grant the
+// permissions of other ClusterRoles to its subjects.
+//
+// Updates that carry the provided allowAnnotation (any value) are allowed, so
+// that exceptions can be made explicitly and are visible on the object itself.
+// Updates by the aggregation controller, which maintains the rules of
+// aggregated ClusterRoles, are always allowed.
+//
+// Operations other than UPDATE, and Kinds other than ClusterRole, will be
+// allowed.
+func DenyAggregatedClusterRoleEdits(allowAnnotation string) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		request := admissionReview.Request
+		resp := newDefaultDenyResponse()
+
+		if request.Kind.Kind != "ClusterRole" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if request.Operation != admission.Update {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		if containsString(aggregationControllerUsers, request.UserInfo.Username) {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		clusterRole := rbac.ClusterRole{}
+		if _, _, err := deserializer.Decode(request.Object.Raw, nil, &clusterRole); err != nil {
+			return nil, err
+		}
+
+		aggregated := clusterRole.AggregationRule != nil
+		if !aggregated && len(request.OldObject.Raw) > 0 {
+			oldClusterRole := rbac.ClusterRole{}
+			if _, _, err := deserializer.Decode(request.OldObject.Raw, nil, &oldClusterRole); err != nil {
+				return nil, err
+			}
+
+			aggregated = oldClusterRole.AggregationRule != nil
+		}
+
+		if !aggregated {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		if allowAnnotation != "" {
+			if _, ok := clusterRole.Annotations[allowAnnotation]; ok {
+				resp.Allowed = true
+				resp.Result.Message = fmt.Sprintf("allowing admission: aggregated ClusterRole is annotated with %q", allowAnnotation)
+				return resp, nil
+			}
+		}
+
+		return resp, denyf(
+			ReasonAggregatedClusterRoleEdit,
+			"ClusterRole %q has an aggregationRule, and cannot be edited without the %q annotation",
+			clusterRole.Name,
+			allowAnnotation,
+		)
+	}
+}
+
+// RequireLivenessInitialDelay ensures that containers with a livenessProbe set
+// an initialDelaySeconds of at least min seconds. Probes that fire before a
+// slow-starting application is ready will cause the container to be restarted
+// in a loop.
+//
+// Containers without a livenessProbe are not rejected. Init containers do not
+// support probes, and are not inspected.
+//
+// RequireLivenessInitialDelay can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func RequireLivenessInitialDelay(ignoredNamespaces []string, min int32) AdmitFunc {
+	return RequireLivenessInitialDelayScoped(NamespaceScope{Ignored: ignoredNamespaces}, min)
+}
+
+// RequireLivenessInitialDelayScoped is RequireLivenessInitialDelay, enforced
+// within the namespaces of the given scope.
+func RequireLivenessInitialDelayScoped(scope NamespaceScope, min int32) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		invalid := make(map[string]int32)
+		for _, container := range pod.spec.Containers {
+			probe := container.LivenessProbe
+			if probe == nil {
+				continue
+			}
+
+			if probe.InitialDelaySeconds < min {
+				invalid[container.Name] = probe.InitialDelaySeconds
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(invalid) > 0 {
+			return resp, denyf(
+				ReasonLivenessInitialDelay,
+				"the submitted Pods have liveness probes with an initialDelaySeconds below %d: %v",
+				min,
+				invalid,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyPrivilegedContainerPorts denies any Pod (or workload) with containers that
+// declare a privileged containerPort (below 1024). Binding to privileged ports
+// may require the container to run as root, or with the NET_BIND_SERVICE
+// capability.
+//
+// Both containers and initContainers are inspected. Providing an empty/nil list
+// of ignoredNamespaces will reject privileged ports across all namespaces.
+func DenyPrivilegedContainerPorts(ignoredNamespaces []string) AdmitFunc {
+	return DenyPrivilegedContainerPortsScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyPrivilegedContainerPortsScoped is DenyPrivilegedContainerPorts, enforced
+// within the namespaces of the given scope.
+func DenyPrivilegedContainerPortsScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		privileged := make(map[string][]int32)
+		for _, container := range allContainers(pod.spec) {
+			for _, port := range container.Ports {
+				if port.ContainerPort < privilegedPortThreshold {
+					privileged[container.Name] = append(privileged[container.Name], port.ContainerPort)
+				}
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(privileged) > 0 {
+			return resp, denyf(
+				ReasonPrivilegedContainerPort,
+				"the submitted Pods declare privileged container ports (below %d): %v",
+				privilegedPortThreshold,
+				privileged,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyDuplicateMountPaths denies any Pod (or workload) with a container that
+// mounts more than one volume at the same mountPath. Only one of the volumes
+// will be visible to the container, which is almost always a misconfiguration.
+//
+// Both containers and initContainers are inspected. Providing an empty/nil list
+// of ignoredNamespaces will reject duplicate mounts across all namespaces.
+func DenyDuplicateMountPaths(ignoredNamespaces []string) AdmitFunc {
+	return DenyDuplicateMountPathsScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyDuplicateMountPathsScoped is DenyDuplicateMountPaths, enforced within the
+// namespaces of the given scope.
+func DenyDuplicateMountPathsScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		duplicates := make(map[string][]string)
+		for _, container := range allContainers(pod.spec) {
+			seen := make(map[string]int)
+			for _, mount := range container.VolumeMounts {
+				seen[mount.MountPath]++
+				// Only report each duplicated path once.
+				if seen[mount.MountPath] == 2 {
+					duplicates[container.Name] = append(duplicates[container.Name], mount.MountPath)
+				}
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(duplicates) > 0 {
+			return resp, denyf(ReasonDuplicateMountPath, "the submitted Pods have containers with duplicate volumeMount paths: %v", duplicates)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// EnforceContainerNames ensures that the names of all containers in a Pod (or
+// workload) match the provided pattern - e.g. for compatibility with downstream
+// tooling. The pattern is not implicitly anchored: use ^ and $ to match against
+// the full name.
+//
+// Both containers and initContainers are inspected. Providing an empty/nil list
+// of ignoredNamespaces will enforce the pattern across all namespaces.
+func EnforceContainerNames(ignoredNamespaces []string, pattern *regexp.Regexp) AdmitFunc {
+	return EnforceContainerNamesScoped(NamespaceScope{Ignored: ignoredNamespaces}, pattern)
+}
+
+// EnforceContainerNamesScoped is EnforceContainerNames, enforced within the
+// namespaces of the given scope.
+func EnforceContainerNamesScoped(scope NamespaceScope, pattern *regexp.Regexp) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+		if pattern == nil {
+			return resp, denyf(ReasonInvalidConfiguration, "cannot validate container names with a nil pattern")
+		}
+
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var invalid []string
+		for _, container := range allContainers(pod.spec) {
+			if !pattern.MatchString(container.Name) {
+				invalid = append(invalid, container.Name)
+			}
+		}
+
+		if len(invalid) > 0 {
+			return resp, denyf(
+				ReasonInvalidContainerName,
+				"the submitted Pods have containers with names that do not match %q: %v",
+				pattern.String(),
+				invalid,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RestrictSecretReferences ensures that the names of all Secrets referenced by
+// a Pod (or workload) match the provided pattern, so that Pods can only consume
+// Secrets that follow an agreed naming convention.
+//
+// Secrets referenced by envFrom, env.valueFrom.secretKeyRef & secret volumes
+// are inspected, for both containers and initContainers. Providing an empty/nil
+// list of ignoredNamespaces will enforce this across all namespaces.
+func RestrictSecretReferences(ignoredNamespaces []string, allowedPattern *regexp.Regexp) AdmitFunc {
+	return RestrictSecretReferencesScoped(NamespaceScope{Ignored: ignoredNamespaces}, allowedPattern)
+}
+
+// RestrictSecretReferencesScoped is RestrictSecretReferences, enforced within
+// the namespaces of the given scope.
+func RestrictSecretReferencesScoped(scope NamespaceScope, allowedPattern *regexp.Regexp) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+		if allowedPattern == nil {
+			return resp, denyf(ReasonInvalidConfiguration, "cannot validate Secret references with a nil pattern")
+		}
+
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var secrets []string
+		for _, container := range allContainers(pod.spec) {
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.SecretRef != nil {
+					secrets = append(secrets, envFrom.SecretRef.Name)
+				}
+			}
+
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					secrets = append(secrets, env.ValueFrom.SecretKeyRef.Name)
+				}
+			}
+		}
+
+		for _, volume := range pod.spec.Volumes {
+			if volume.Secret != nil {
+				secrets = append(secrets, volume.Secret.SecretName)
+			}
+		}
+
+		var disallowed []string
+		for _, secret := range secrets {
+			if !allowedPattern.MatchString(secret) && !containsString(disallowed, secret) {
+				disallowed = append(disallowed, secret)
+			}
+		}
+
+		if len(disallowed) > 0 {
+			return resp, denyf(
+				ReasonForbiddenSecretReference,
+				"the submitted Pods reference Secrets with names that do not match %q: %v",
+				allowedPattern.String(),
+				disallowed,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyAllowAllNetworkPolicies denies any NetworkPolicy that selects all Pods in
+// a namespace (an empty podSelector) and then permits all ingress or egress
+// traffic to them. Such policies silently disable the isolation provided by any
+// other NetworkPolicy in the namespace.
+//
+// A rule permits all traffic when it does not restrict ports, and either has
+// no peers, or has a peer matching everything: an empty namespaceSelector, or
+// an ipBlock of 0.0.0.0/0 or ::/0 without exceptions.
+//
+// Kinds other than NetworkPolicy will be allowed.
+func DenyAllowAllNetworkPolicies(ignoredNamespaces []string) AdmitFunc {
+	return DenyAllowAllNetworkPoliciesScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyAllowAllNetworkPoliciesScoped is DenyAllowAllNetworkPolicies, enforced
+// within the namespaces of the given scope.
+func DenyAllowAllNetworkPoliciesScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "NetworkPolicy" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		policy := networking.NetworkPolicy{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &policy); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, policy.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		// Policies scoped to a subset of Pods cannot disable isolation for the
+		// whole namespace.
+		if !isEmptyLabelSelector(&policy.Spec.PodSelector) {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		var allowAll []string
+		for _, rule := range policy.Spec.Ingress {
+			if allowsAllPeers(rule.From, rule.Ports) {
+				allowAll = append(allowAll, string(networking.PolicyTypeIngress))
+				break
+			}
+		}
+
+		for _, rule := range policy.Spec.Egress {
+			if allowsAllPeers(rule.To, rule.Ports) {
+				allowAll = append(allowAll, string(networking.PolicyTypeEgress))
+				break
+			}
+		}
+
+		if len(allowAll) > 0 {
+			return resp, denyf(
+				ReasonAllowAllNetworkPolicy,
+				"%s objects that allow all %v traffic to all Pods in a namespace cannot be deployed to this cluster",
+				kind,
+				allowAll,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// allowsAllPeers returns true if a NetworkPolicy rule with the given peers and
+// ports permits traffic from (or to) anywhere, on any port.
+func allowsAllPeers(peers []networking.NetworkPolicyPeer, ports []networking.NetworkPolicyPort) bool {
+	if len(ports) > 0 {
+		return false
+	}
+
+	if len(peers) == 0 {
+		return true
+	}
+
+	for _, peer := range peers {
+		if peer.NamespaceSelector != nil && isEmptyLabelSelector(peer.NamespaceSelector) &&
+			(peer.PodSelector == nil || isEmptyLabelSelector(peer.PodSelector)) {
+			return true
+		}
+
+		if peer.IPBlock != nil && len(peer.IPBlock.Except) == 0 &&
+			(peer.IPBlock.CIDR == "0.0.0.0/0" || peer.IPBlock.CIDR == "::/0") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isEmptyLabelSelector returns true if the given LabelSelector matches all
+// objects.
+func isEmptyLabelSelector(selector *metav1.LabelSelector) bool {
+	return len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0
+}
+
+// WarnInteractiveContainers warns when a container in a Pod (or workload) sets
+// only one of tty or stdin. These mismatches are usually left over from debug
+// manifests, and are rarely intended in production.
+//
+// WarnInteractiveContainers never rejects admission: it returns warnings to the
+// client instead.
+func WarnInteractiveContainers(ignoredNamespaces []string) AdmitFunc {
+	return WarnInteractiveContainersScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// WarnInteractiveContainersScoped is WarnInteractiveContainers, enforced within
+// the namespaces of the given scope.
+func WarnInteractiveContainersScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		for _, container := range allContainers(pod.spec) {
+			switch {
+			case container.TTY && !container.Stdin:
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"container %q sets tty: true without stdin: true", container.Name,
+				))
+			case container.Stdin && !container.TTY:
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"container %q sets stdin: true without tty: true", container.Name,
+				))
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// sleepRegexp matches a sleep command and its duration (in seconds, minutes or
+// hours) within an exec or shell command.
+var sleepRegexp = regexp.MustCompile(`(?:^|[\s;&|/])sleep\s+(\d+)([smh]?)\b`)
+
+// WarnLongPreStopHooks warns when a container in a Pod (or workload) has a
+// preStop exec hook that sleeps for longer than maxSeconds. Long sleeps (e.g.
+// "sleep 3600") hold Pods in Terminating until their grace period expires.
+//
+// Commands are parsed on a best-effort basis: both direct ("sleep", "60") and
+// shell ("sh", "-c", "sleep 60 && nginx -s quit") forms are inspected.
+//
+// WarnLongPreStopHooks never rejects admission: it returns warnings to the
+// client instead.
+func WarnLongPreStopHooks(ignoredNamespaces []string, maxSeconds int) AdmitFunc {
+	return WarnLongPreStopHooksScoped(NamespaceScope{Ignored: ignoredNamespaces}, maxSeconds)
+}
+
+// WarnLongPreStopHooksScoped is WarnLongPreStopHooks, enforced within the
+// namespaces of the given scope.
+func WarnLongPreStopHooksScoped(scope NamespaceScope, maxSeconds int) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		// initContainers do not support lifecycle hooks.
+		for _, container := range pod.spec.Containers {
+			lifecycle := container.Lifecycle
+			if lifecycle == nil || lifecycle.PreStop == nil || lifecycle.PreStop.Exec == nil {
+				continue
+			}
+
+			seconds := sleepSeconds(strings.Join(lifecycle.PreStop.Exec.Command, " "))
+			if seconds > maxSeconds {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"container %q has a preStop hook that sleeps for %ds, which exceeds the maximum of %ds",
+					container.Name,
+					seconds,
+					maxSeconds,
+				))
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// sleepSeconds returns the total number of seconds slept for by the sleep
+// commands in the given command line.
+func sleepSeconds(command string) int {
+	var total int
+	for _, match := range sleepRegexp.FindAllStringSubmatch(command, -1) {
+		seconds, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+
+		switch match[2] {
+		case "m":
+			seconds *= 60
+		case "h":
+			seconds *= 60 * 60
+		}
+
+		total += seconds
+	}
+
+	return total
+}
+
+// WarnZeroReplicas warns when a Deployment or StatefulSet is scaled to zero
+// replicas, which takes the service it backs offline. This is often
+// unintentional, such as a copy-pasted manifest.
+//
+// WarnZeroReplicas never rejects admission: it returns warnings to the client
+// instead. Kinds other than Deployment and StatefulSet will be allowed.
+func WarnZeroReplicas(ignoredNamespaces []string) AdmitFunc {
+	return WarnZeroReplicasScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// WarnZeroReplicasScoped is WarnZeroReplicas, enforced within the namespaces of
+// the given scope.
+func WarnZeroReplicasScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+
+		var (
+			object   metav1.Object
+			replicas *int32
+		)
+
+		switch normalizeGroupKind(admissionReview.Request.Kind) {
+		case apps.SchemeGroupVersion.WithKind("Deployment").GroupKind():
+			deployment := apps.Deployment{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &deployment); err != nil {
+				return nil, err
+			}
+
+			object = &deployment
+			replicas = deployment.Spec.Replicas
+		case apps.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+			statefulset := apps.StatefulSet{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &statefulset); err != nil {
+				return nil, err
+			}
+
+			object = &statefulset
+			replicas = statefulset.Spec.Replicas
+		default:
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		namespace := requestNamespace(admissionReview, object.GetNamespace())
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		// An unset replica count defaults to 1.
+		if replicas != nil && *replicas == 0 {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+				"%s %q is scaled to 0 replicas, and will not serve any traffic",
+				admissionReview.Request.Kind.Kind,
+				object.GetName(),
+			))
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireProgressDeadline denies any kind: Deployment without a
+// .spec.progressDeadlineSeconds of at most max seconds. Without a progress
+// deadline, a broken rollout is never marked as failed, and can hang
+// indefinitely.
+//
+// The apps/v1 API defaults the deadline to 600 seconds before admission
+// webhooks are invoked, and so Deployments that omit it are allowed when max is
+// at least 600. The legacy extensions/v1beta1 API instead defaults it to
+// math.MaxInt32 - no deadline - which is denied.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than Deployment will be allowed.
+func RequireProgressDeadline(ignoredNamespaces []string, max int32) AdmitFunc {
+	return RequireProgressDeadlineScoped(NamespaceScope{Ignored: ignoredNamespaces}, max)
+}
+
+// RequireProgressDeadlineScoped is RequireProgressDeadline, enforced within the
+// namespaces of the given scope.
+func RequireProgressDeadlineScoped(scope NamespaceScope, max int32) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if normalizeGroupKind(admissionReview.Request.Kind) != apps.SchemeGroupVersion.WithKind("Deployment").GroupKind() {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if max <= 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "the maximum progress deadline must be greater than zero (got %d)", max)
+		}
+
+		deployment := apps.Deployment{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &deployment); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, deployment.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		deadline := deployment.Spec.ProgressDeadlineSeconds
+		if deadline == nil || *deadline == math.MaxInt32 {
+			return resp, denyf(
+				ReasonInvalidProgressDeadline,
+				"Deployment %q does not set a spec.progressDeadlineSeconds",
+				deployment.Name,
+			)
+		}
+
+		if *deadline > max {
+			return resp, denyf(
+				ReasonInvalidProgressDeadline,
+				"Deployment %q has a spec.progressDeadlineSeconds of %d, which exceeds the maximum of %d",
+				deployment.Name,
+				*deadline,
+				max,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireRevisionHistoryLimit denies any kind: Deployment without a
+// .spec.revisionHistoryLimit of at most max. Each retained revision is an old
+// ReplicaSet, and an unbounded history piles them up in etcd.
+//
+// The apps/v1 API defaults the limit to 10 before admission webhooks are
+// invoked, and so Deployments that omit it are allowed when max is at least 10.
+// The legacy extensions/v1beta1 API instead defaults it to math.MaxInt32 -
+// retaining every revision - which is denied.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than Deployment will be allowed.
+func RequireRevisionHistoryLimit(ignoredNamespaces []string, max int32) AdmitFunc {
+	return RequireRevisionHistoryLimitScoped(NamespaceScope{Ignored: ignoredNamespaces}, max)
+}
+
+// RequireRevisionHistoryLimitScoped is RequireRevisionHistoryLimit, enforced
+// within the namespaces of the given scope.
+func RequireRevisionHistoryLimitScoped(scope NamespaceScope, max int32) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if normalizeGroupKind(admissionReview.Request.Kind) != apps.SchemeGroupVersion.WithKind("Deployment").GroupKind() {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if max < 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "the maximum revision history limit must not be negative (got %d)", max)
+		}
+
+		deployment := apps.Deployment{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &deployment); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, deployment.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		limit := deployment.Spec.RevisionHistoryLimit
+		if limit == nil || *limit == math.MaxInt32 {
+			return resp, denyf(
+				ReasonInvalidRevisionHistoryLimit,
+				"Deployment %q does not set a spec.revisionHistoryLimit",
+				deployment.Name,
+			)
+		}
+
+		if *limit > max {
+			return resp, denyf(
+				ReasonInvalidRevisionHistoryLimit,
+				"Deployment %q has a spec.revisionHistoryLimit of %d, which exceeds the maximum of %d",
+				deployment.Name,
+				*limit,
+				max,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// zoneTopologyKeys are the well-known node labels that identify the zone of a
+// node, including the deprecated beta label.
+var zoneTopologyKeys = []string{
+	core.LabelTopologyZone,
+	core.LabelFailureDomainBetaZone,
+}
+
+// RequireZoneSpread denies any Deployment or StatefulSet with more than one
+// replica that does not spread its Pods across zones with a
+// topologySpreadConstraint on the "topology.kubernetes.io/zone" label. Without
+// one, every replica may be scheduled into a single zone, and the workload is
+// not resilient to the failure of that zone.
+//
+// An unset replica count defaults to 1, and is allowed. Providing an empty/nil
+// list of ignoredNamespaces will enforce this across all namespaces. Kinds
+// other than Deployment and StatefulSet will be allowed.
+func RequireZoneSpread(ignoredNamespaces []string) AdmitFunc {
+	return RequireZoneSpreadScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// RequireZoneSpreadScoped is RequireZoneSpread, enforced within the namespaces
+// of the given scope.
+func RequireZoneSpreadScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+
+		var (
+			object   metav1.Object
+			replicas *int32
+			template core.PodTemplateSpec
+		)
+
+		switch normalizeGroupKind(admissionReview.Request.Kind) {
+		case apps.SchemeGroupVersion.WithKind("Deployment").GroupKind():
+			deployment := apps.Deployment{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &deployment); err != nil {
+				return nil, err
+			}
+
+			object = &deployment
+			replicas = deployment.Spec.Replicas
+			template = deployment.Spec.Template
+		case apps.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+			statefulset := apps.StatefulSet{}
+			if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &statefulset); err != nil {
+				return nil, err
+			}
+
+			object = &statefulset
+			replicas = statefulset.Spec.Replicas
+			template = statefulset.Spec.Template
+		default:
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		namespace := requestNamespace(admissionReview, object.GetNamespace())
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if replicas == nil || *replicas <= 1 {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		for _, constraint := range template.Spec.TopologySpreadConstraints {
+			if containsString(zoneTopologyKeys, constraint.TopologyKey) {
+				resp.Allowed = true
+				return resp, nil
+			}
+		}
+
+		return resp, denyf(
+			ReasonMissingZoneSpread,
+			"%s %q has %d replicas, but no topologySpreadConstraint with a topologyKey of %q",
+			admissionReview.Request.Kind.Kind,
+			object.GetName(),
+			*replicas,
+			core.LabelTopologyZone,
+		)
+	}
+}
+
+// topologyHintsAnnotation enables Topology Aware Hints for a Service.
+const topologyHintsAnnotation = "service.kubernetes.io/topology-aware-hints"
+
+// topologyHintsValues are the values of the topologyHintsAnnotation recognized
+// by the EndpointSlice controller.
+var topologyHintsValues = []string{"Auto", "auto", "Disabled", "disabled"}
+
+// WarnTopologyHintsMisuse warns when a Service sets the
+// service.kubernetes.io/topology-aware-hints annotation to a value that is not
+// recognized by the EndpointSlice controller. Unrecognized values (e.g. "true"
+// or "enabled") silently leave Topology Aware Hints disabled.
+//
+// WarnTopologyHintsMisuse never rejects admission: it returns warnings to the
+// client instead. Kinds other than Service will be allowed.
+func WarnTopologyHintsMisuse(ignoredNamespaces []string) AdmitFunc {
+	return WarnTopologyHintsMisuseScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// WarnTopologyHintsMisuseScoped is WarnTopologyHintsMisuse, enforced within the
+// namespaces of the given scope.
+func WarnTopologyHintsMisuseScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if value, ok := service.Annotations[topologyHintsAnnotation]; ok && value != "" && !containsString(topologyHintsValues, value) {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+				"Service %q sets %s to an unrecognized value %q, and Topology Aware Hints will be disabled: use one of %v",
+				service.Name,
+				topologyHintsAnnotation,
+				value,
+				topologyHintsValues,
+			))
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// WarnDeploymentsLackingRequestsForHPA warns when the containers of a
+// Deployment do not set CPU & memory requests. A HorizontalPodAutoscaler that
+// scales the Deployment on CPU or memory utilization cannot compute the
+// utilization of such Pods, and will fail to scale.
+//
+// Requests that are unset, but defaulted from a limit, are treated as set. Init
+// containers are not inspected.
+//
+// WarnDeploymentsLackingRequestsForHPA never rejects admission: it returns
+// warnings to the client instead. Kinds other than Deployment will be allowed.
+func WarnDeploymentsLackingRequestsForHPA(ignoredNamespaces []string) AdmitFunc {
+	return WarnDeploymentsLackingRequestsForHPAScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// WarnDeploymentsLackingRequestsForHPAScoped is
+// WarnDeploymentsLackingRequestsForHPA, enforced within the namespaces of the
+// given scope.
+func WarnDeploymentsLackingRequestsForHPAScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if normalizeGroupKind(admissionReview.Request.Kind) != apps.SchemeGroupVersion.WithKind("Deployment").GroupKind() {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		deployment := apps.Deployment{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &deployment); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, deployment.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			var missing []string
+			for _, name := range []core.ResourceName{core.ResourceCPU, core.ResourceMemory} {
+				_, hasRequest := container.Resources.Requests[name]
+				_, hasLimit := container.Resources.Limits[name]
+				if !hasRequest && !hasLimit {
+					missing = append(missing, string(name))
+				}
+			}
+
+			if len(missing) > 0 {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"container %q of Deployment %q does not request %s: a HorizontalPodAutoscaler cannot scale it on resource utilization",
+					container.Name,
+					deployment.Name,
+					strings.Join(missing, " or "),
+				))
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// WarnSensitivePortsExposed warns when a Service of type LoadBalancer or
+// NodePort exposes one of the provided sensitivePorts - e.g. metrics (9090) or
+// admin (6443) ports - outside of the cluster. A port is exposed when either
+// its port, or its (numeric) targetPort, is sensitive.
+//
+// WarnSensitivePortsExposed never rejects admission: it returns warnings to the
+// client instead. Kinds other than Service, and Services of other types, will
+// be allowed.
+func WarnSensitivePortsExposed(ignoredNamespaces []string, sensitivePorts []int32) AdmitFunc {
+	return WarnSensitivePortsExposedScoped(NamespaceScope{Ignored: ignoredNamespaces}, sensitivePorts)
+}
+
+// WarnSensitivePortsExposedScoped is WarnSensitivePortsExposed, enforced within
+// the namespaces of the given scope.
+func WarnSensitivePortsExposedScoped(scope NamespaceScope, sensitivePorts []int32) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if service.Spec.Type != core.ServiceTypeLoadBalancer && service.Spec.Type != core.ServiceTypeNodePort {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		for _, port := range service.Spec.Ports {
+			exposed := port.Port
+			if !containsPort(sensitivePorts, exposed) {
+				if port.TargetPort.Type != intstr.Int || !containsPort(sensitivePorts, port.TargetPort.IntVal) {
+					continue
+				}
+
+				exposed = port.TargetPort.IntVal
+			}
+
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+				"%s Service %q exposes the sensitive port %d outside of the cluster",
+				service.Spec.Type,
+				service.Name,
+				exposed,
+			))
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// containsPort returns true if port is in the given list.
+func containsPort(ports []int32, port int32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WarnClusterTrafficPolicyOnLB warns when a Service of type LoadBalancer uses
+// an externalTrafficPolicy of Cluster (the default). Traffic is then forwarded
+// between nodes and source NAT'd, and so the Pods behind the Service do not see
+// the source IP of their clients. An externalTrafficPolicy of Local preserves
+// it.
+//
+// WarnClusterTrafficPolicyOnLB never rejects admission: it returns warnings to
+// the client instead. Kinds other than Service, and Services of other types,
+// will be allowed.
+func WarnClusterTrafficPolicyOnLB(ignoredNamespaces []string) AdmitFunc {
+	return WarnClusterTrafficPolicyOnLBScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// WarnClusterTrafficPolicyOnLBScoped is WarnClusterTrafficPolicyOnLB, enforced
+// within the namespaces of the given scope.
+func WarnClusterTrafficPolicyOnLBScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		// An unset externalTrafficPolicy defaults to Cluster.
+		policy := service.Spec.ExternalTrafficPolicy
+		if service.Spec.Type == core.ServiceTypeLoadBalancer &&
+			(policy == "" || policy == core.ServiceExternalTrafficPolicyTypeCluster) {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+				"LoadBalancer Service %q uses externalTrafficPolicy: Cluster, which does not preserve client source IPs: use externalTrafficPolicy: Local instead",
+				service.Name,
+			))
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyPublishNotReadyAddresses denies any kind: Service that sets
+// .spec.publishNotReadyAddresses, which routes traffic to Pods that are not
+// ready. This is rarely intended outside of the headless Services of stateful
+// workloads (e.g. for peer discovery), whose namespaces can be ignored.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than Service will be allowed.
+func DenyPublishNotReadyAddresses(ignoredNamespaces []string) AdmitFunc {
+	return DenyPublishNotReadyAddressesScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyPublishNotReadyAddressesScoped is DenyPublishNotReadyAddresses, enforced
+// within the namespaces of the given scope.
+func DenyPublishNotReadyAddressesScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if service.Spec.PublishNotReadyAddresses {
+			return resp, denyf(
+				ReasonPublishNotReadyAddresses,
+				"Service %q sets publishNotReadyAddresses, which routes traffic to Pods that are not ready",
+				service.Name,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// defaultSessionAffinityTimeout is the timeoutSeconds the apiserver defaults
+// the sessionAffinityConfig of a ClientIP-affinity Service to (three hours).
+const defaultSessionAffinityTimeout int32 = 10800
+
+// RequireSessionAffinityTimeout denies any kind: Service with a sessionAffinity
+// of ClientIP that does not explicitly set
+// .spec.sessionAffinityConfig.clientIP.timeoutSeconds. The default timeout of
+// three hours is rarely what the Service owner expects.
+//
+// The apiserver sets the default timeout before admission webhooks are invoked,
+// and so a timeout equal to the default is treated as implicit: Service owners
+// must choose another value.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than Service will be allowed.
+func RequireSessionAffinityTimeout(ignoredNamespaces []string) AdmitFunc {
+	return RequireSessionAffinityTimeoutScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// RequireSessionAffinityTimeoutScoped is RequireSessionAffinityTimeout,
+// enforced within the namespaces of the given scope.
+func RequireSessionAffinityTimeoutScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if service.Spec.SessionAffinity != core.ServiceAffinityClientIP {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		config := service.Spec.SessionAffinityConfig
+		if config == nil || config.ClientIP == nil || config.ClientIP.TimeoutSeconds == nil ||
+			*config.ClientIP.TimeoutSeconds == defaultSessionAffinityTimeout {
+			return resp, denyf(
+				ReasonImplicitSessionAffinityTimeout,
+				"Service %q uses ClientIP session affinity without an explicit sessionAffinityConfig.clientIP.timeoutSeconds (other than the default of %d)",
+				service.Name,
+				defaultSessionAffinityTimeout,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireServiceOwnerLabel denies any kind: Service that does not carry a
+// non-empty label with the given labelKey - e.g. "owner" or "cost-center" - so
+// that the cost of the load balancers and IP addresses it provisions can be
+// attributed.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than Service will be allowed.
+func RequireServiceOwnerLabel(ignoredNamespaces []string, labelKey string) AdmitFunc {
+	return RequireServiceOwnerLabelScoped(NamespaceScope{Ignored: ignoredNamespaces}, labelKey)
+}
+
+// RequireServiceOwnerLabelScoped is RequireServiceOwnerLabel, enforced within
+// the namespaces of the given scope.
+func RequireServiceOwnerLabelScoped(scope NamespaceScope, labelKey string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "Service" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if labelKey == "" {
+			return resp, denyf(ReasonInvalidConfiguration, "an owner label key must be provided")
+		}
+
+		service := core.Service{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &service); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, service.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if service.Labels[labelKey] == "" {
+			return resp, denyf(
+				ReasonMissingOwnerLabel,
+				"Service %q is missing the owner label %q",
+				service.Name,
+				labelKey,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// WarnInlineSecretEnv warns when a container in a Pod (or workload) sets an
+// environment variable whose name matches any of secretNamePatterns (e.g.
+// PASSWORD or TOKEN) to an inline value, rather than referencing a Secret via
+// valueFrom.secretKeyRef. Inline values are stored in plaintext in manifests
+// and etcd.
+//
+// Both containers and initContainers are inspected. WarnInlineSecretEnv never
+// rejects admission: it returns warnings to the client instead.
+func WarnInlineSecretEnv(ignoredNamespaces []string, secretNamePatterns []*regexp.Regexp) AdmitFunc {
+	return WarnInlineSecretEnvScoped(NamespaceScope{Ignored: ignoredNamespaces}, secretNamePatterns)
+}
+
+// WarnInlineSecretEnvScoped is WarnInlineSecretEnv, enforced within the
+// namespaces of the given scope.
+func WarnInlineSecretEnvScoped(scope NamespaceScope, secretNamePatterns []*regexp.Regexp) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		for _, container := range allContainers(pod.spec) {
+			for _, env := range container.Env {
+				if env.Value == "" || !matchesAny(secretNamePatterns, env.Name) {
+					continue
+				}
+
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"container %q sets %s to an inline value: reference a Secret via valueFrom.secretKeyRef instead",
+					container.Name,
+					env.Name,
+				))
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// matchesAny returns true if s matches any of the given (non-nil) patterns.
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern != nil && pattern.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnforceRestrictedProfile enforces the "restricted" Pod Security Standard on
+// Pods (and workloads), as described at
+// https://kubernetes.io/docs/concepts/security/pod-security-standards/
+//
+// Pods must not share the host's network, PID or IPC namespaces, and every
+// container (including initContainers) must:
+//
+// 1. Not be privileged, and set allowPrivilegeEscalation: false.
+//
+// 2. Run as a non-root user (runAsNonRoot: true), set on the Pod or container.
+//
+// 3. Drop ALL capabilities.
+//
+// 4. Use a RuntimeDefault or Localhost seccompProfile, set on the Pod or
+// container.
+//
+// All violations are reported together in a single rejection, rather than
+// failing on the first. A read-only root filesystem is not required.
+func EnforceRestrictedProfile(ignoredNamespaces []string) AdmitFunc {
+	return EnforceRestrictedProfileScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// EnforceRestrictedProfileScoped is EnforceRestrictedProfile, enforced within
+// the namespaces of the given scope.
+func EnforceRestrictedProfileScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var violations []string
+		if pod.spec.HostNetwork {
+			violations = append(violations, "hostNetwork must not be set")
+		}
+
+		if pod.spec.HostPID {
+			violations = append(violations, "hostPID must not be set")
+		}
+
+		if pod.spec.HostIPC {
+			violations = append(violations, "hostIPC must not be set")
+		}
+
+		podContext := pod.spec.SecurityContext
+		if podContext == nil {
+			podContext = &core.PodSecurityContext{}
+		}
+
+		for _, container := range allContainers(pod.spec) {
+			sc := container.SecurityContext
+			if sc == nil {
+				sc = &core.SecurityContext{}
+			}
+
+			if sc.Privileged != nil && *sc.Privileged {
+				violations = append(violations, fmt.Sprintf("container %q must not be privileged", container.Name))
+			}
+
+			if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+				violations = append(violations, fmt.Sprintf("container %q must set allowPrivilegeEscalation: false", container.Name))
+			}
+
+			// Container-level settings take precedence over those on the Pod.
+			runAsNonRoot := podContext.RunAsNonRoot
+			if sc.RunAsNonRoot != nil {
+				runAsNonRoot = sc.RunAsNonRoot
+			}
+
+			if runAsNonRoot == nil || !*runAsNonRoot {
+				violations = append(violations, fmt.Sprintf("container %q must set runAsNonRoot: true", container.Name))
+			}
+
+			if sc.Capabilities == nil || !containsCapability(sc.Capabilities.Drop, "ALL") {
+				violations = append(violations, fmt.Sprintf("container %q must drop ALL capabilities", container.Name))
+			}
+
+			seccompProfile := podContext.SeccompProfile
+			if sc.SeccompProfile != nil {
+				seccompProfile = sc.SeccompProfile
+			}
+
+			if seccompProfile == nil ||
+				(seccompProfile.Type != core.SeccompProfileTypeRuntimeDefault && seccompProfile.Type != core.SeccompProfileTypeLocalhost) {
+				violations = append(violations, fmt.Sprintf("container %q must use a RuntimeDefault or Localhost seccompProfile", container.Name))
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(violations) > 0 {
+			return resp, denyf(
+				ReasonRestrictedProfile,
+				"the submitted Pods violate the restricted Pod Security Standard: %s",
+				strings.Join(violations, "; "),
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// containsCapability returns true if the given capability is in the list.
+func containsCapability(capabilities []core.Capability, capability core.Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+
+	return false
+}
+
+// debugCapabilities are the Linux capabilities denied by DenyDebugCapabilities:
+// they allow a container to inspect (or modify) other processes and the
+// kernel, and are typically only added to debug a running container.
+var debugCapabilities = []core.Capability{"ALL", "SYS_PTRACE", "SYS_ADMIN", "PERFMON", "BPF"}
+
+// DenyDebugCapabilities denies any Pod (or workload) with a container that adds
+// one of a curated set of debugging capabilities - SYS_PTRACE, SYS_ADMIN,
+// PERFMON, BPF, or ALL - to its securityContext. Other capabilities (e.g.
+// NET_BIND_SERVICE) are allowed.
+//
+// Capabilities are matched case-insensitively, with or without a "CAP_"
+// prefix. Providing an empty/nil list of ignoredNamespaces will enforce this
+// across all namespaces.
+func DenyDebugCapabilities(ignoredNamespaces []string) AdmitFunc {
+	return DenyDebugCapabilitiesScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyDebugCapabilitiesScoped is DenyDebugCapabilities, enforced within the
+// namespaces of the given scope.
+func DenyDebugCapabilitiesScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		added := make(map[string][]core.Capability)
+		for _, container := range allContainers(pod.spec) {
+			sc := container.SecurityContext
+			if sc == nil || sc.Capabilities == nil {
+				continue
+			}
+
+			for _, capability := range sc.Capabilities.Add {
+				normalized := core.Capability(strings.TrimPrefix(strings.ToUpper(string(capability)), "CAP_"))
+				if containsCapability(debugCapabilities, normalized) {
+					added[container.Name] = append(added[container.Name], capability)
+				}
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(added) > 0 {
+			return resp, denyf(
+				ReasonDebugCapability,
+				"the submitted Pods have containers that add debugging capabilities: %v",
+				added,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyBroadPDB denies any PodDisruptionBudget with an empty or nil selector.
+// These match every Pod in the namespace, and often unintentionally block node
+// drains.
+//
+// Providing an empty/nil list of ignoredNamespaces will reject broad
+// PodDisruptionBudgets across all namespaces.
+//
+// Kinds other than PodDisruptionBudget will be allowed.
+func DenyBroadPDB(ignoredNamespaces []string) AdmitFunc {
+	return DenyBroadPDBScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyBroadPDBScoped is DenyBroadPDB, enforced within the namespaces of the
+// given scope.
+func DenyBroadPDBScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "PodDisruptionBudget" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		pdb := policy.PodDisruptionBudget{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &pdb); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, pdb.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		if pdb.Spec.Selector == nil || isEmptyLabelSelector(pdb.Spec.Selector) {
+			return resp, denyf(
+				ReasonBroadPodDisruptionBudget,
+				"%s objects with an empty selector match all Pods in a namespace, and cannot be deployed to this cluster",
+				kind,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyDefaultServiceAccountToken denies any Pod (or workload) that runs as the
+// default ServiceAccount of its namespace and mounts its token, either by
+// automounting it, or by projecting it with a serviceAccountToken volume
+// source. Workloads that need to talk to the apiserver should use a dedicated
+// ServiceAccount.
+//
+// Pods must explicitly set automountServiceAccountToken: false to avoid
+// automounting the token. Pods using a named ServiceAccount are allowed.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces.
+func DenyDefaultServiceAccountToken(ignoredNamespaces []string) AdmitFunc {
+	return DenyDefaultServiceAccountTokenScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyDefaultServiceAccountTokenScoped is DenyDefaultServiceAccountToken,
+// enforced within the namespaces of the given scope.
+func DenyDefaultServiceAccountTokenScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		serviceAccount := pod.spec.ServiceAccountName
+		if serviceAccount == "" {
+			serviceAccount = pod.spec.DeprecatedServiceAccount
+		}
+
+		if serviceAccount != "" && serviceAccount != defaultServiceAccount {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		var mounts []string
+		if automount := pod.spec.AutomountServiceAccountToken; automount == nil || *automount {
+			mounts = append(mounts, "automountServiceAccountToken")
+		}
+
+		for _, volume := range pod.spec.Volumes {
+			if volume.Projected == nil {
+				continue
+			}
+
+			for _, source := range volume.Projected.Sources {
+				if source.ServiceAccountToken != nil {
+					mounts = append(mounts, fmt.Sprintf("projected volume %q", volume.Name))
+					break
+				}
+			}
+		}
+
+		if len(mounts) > 0 {
+			return resp, denyf(
+				ReasonDefaultServiceAccountToken,
+				"the submitted Pods mount the token of the default ServiceAccount: %v",
+				mounts,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireTerminationGracePeriodBounds ensures that Pods (and workloads) set a
+// terminationGracePeriodSeconds between min and max (inclusive). Pods with a
+// grace period of 0 cannot drain their connections, and very long grace periods
+// stall node drains.
+//
+// Pods that do not set terminationGracePeriodSeconds are treated as using the
+// default of 30 seconds.
+//
+// RequireTerminationGracePeriodBounds can inspect Pods, Deployments,
+// StatefulSets, DaemonSets & Jobs. Other object kinds are allowed.
+func RequireTerminationGracePeriodBounds(ignoredNamespaces []string, min, max int64) AdmitFunc {
+	return RequireTerminationGracePeriodBoundsScoped(NamespaceScope{Ignored: ignoredNamespaces}, min, max)
+}
+
+// RequireTerminationGracePeriodBoundsScoped is
+// RequireTerminationGracePeriodBounds, enforced within the namespaces of the
+// given scope.
+func RequireTerminationGracePeriodBoundsScoped(scope NamespaceScope, min, max int64) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		gracePeriod := int64(core.DefaultTerminationGracePeriodSeconds)
+		if pod.spec.TerminationGracePeriodSeconds != nil {
+			gracePeriod = *pod.spec.TerminationGracePeriodSeconds
+		}
+
+		resp := newDefaultDenyResponse()
+		if gracePeriod < min || gracePeriod > max {
+			return resp, denyf(
+				ReasonTerminationGracePeriod,
+				"the submitted Pods have a terminationGracePeriodSeconds of %d, outside of the allowed range of %d to %d",
+				gracePeriod,
+				min,
+				max,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenySidecarInjectionOptOut denies any Pod (or workload) that opts out of
+// service mesh sidecar injection, such as via `sidecar.istio.io/inject:
+// "false"` or `linkerd.io/inject: disabled`.
+//
+// optOutAnnotations maps each opt-out annotation to its opt-out value. An
+// empty value rejects the annotation regardless of its value. Annotations are
+// matched against the Pod template for workloads.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce injection
+// across all namespaces: you should include kube-system, and the namespace of
+// your mesh's control plane.
+//
+// DenySidecarInjectionOptOut can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func DenySidecarInjectionOptOut(ignoredNamespaces []string, optOutAnnotations map[string]string) AdmitFunc {
+	return DenySidecarInjectionOptOutScoped(NamespaceScope{Ignored: ignoredNamespaces}, optOutAnnotations)
+}
+
+// DenySidecarInjectionOptOutScoped is DenySidecarInjectionOptOut, enforced
+// within the namespaces of the given scope.
+func DenySidecarInjectionOptOutScoped(scope NamespaceScope, optOutAnnotations map[string]string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		optedOut := make(map[string]string)
+		for key, optOutValue := range optOutAnnotations {
+			value, ok := pod.annotations[key]
+			if !ok {
+				continue
+			}
+
+			if optOutValue == "" || value == optOutValue {
+				optedOut[key] = value
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(optedOut) > 0 {
+			return resp, denyf(
+				ReasonSidecarInjectionOptOut,
+				"the submitted Pods opt out of sidecar injection, which is required in this cluster: %v",
+				optedOut,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireFsGroup ensures that Pods (and workloads) that mount a
+// PersistentVolumeClaim set securityContext.fsGroup. Without it, shared volumes
+// are often not writable by the (non-root) user the containers run as.
+//
+// Pods that do not mount any PersistentVolumeClaims are allowed.
+//
+// RequireFsGroup can inspect Pods, Deployments, StatefulSets, DaemonSets &
+// Jobs. Other object kinds are allowed.
+func RequireFsGroup(ignoredNamespaces []string) AdmitFunc {
+	return RequireFsGroupScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// RequireFsGroupScoped is RequireFsGroup, enforced within the namespaces of the
+// given scope.
+func RequireFsGroupScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var claims []string
+		for _, volume := range pod.spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(claims) > 0 && (pod.spec.SecurityContext == nil || pod.spec.SecurityContext.FSGroup == nil) {
+			return resp, denyf(
+				ReasonMissingFsGroup,
+				"the submitted Pods mount PersistentVolumeClaims %v without setting securityContext.fsGroup",
+				claims,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireResourceClaims ensures that containers in a Pod (or workload) that
+// request accelerators via their resource limits (e.g. "nvidia.com/gpu") also
+// reference a resource claim, for clusters that allocate accelerators using
+// Dynamic Resource Allocation (DRA).
+//
+// A container's resources.claims must reference a claim declared in the Pod's
+// spec.resourceClaims. gpuResourceNames are the extended resource names that
+// identify accelerators.
+//
+// RequireResourceClaims can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func RequireResourceClaims(ignoredNamespaces []string, gpuResourceNames []string) AdmitFunc {
+	return RequireResourceClaimsScoped(NamespaceScope{Ignored: ignoredNamespaces}, gpuResourceNames)
+}
+
+// RequireResourceClaimsScoped is RequireResourceClaims, enforced within the
+// namespaces of the given scope.
+func RequireResourceClaimsScoped(scope NamespaceScope, gpuResourceNames []string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		// The DRA fields are not present in the k8s.io/api version we build
+		// against, and are decoded separately.
+		var draSpec struct {
+			ResourceClaims []struct {
+				Name string `json:"name"`
+			} `json:"resourceClaims"`
+			InitContainers []draContainer `json:"initContainers"`
+			Containers     []draContainer `json:"containers"`
+		}
+		if err := decodeRawPodSpec(admissionReview, &draSpec); err != nil {
+			return nil, err
+		}
+
+		declared := make(map[string]bool, len(draSpec.ResourceClaims))
+		for _, claim := range draSpec.ResourceClaims {
+			declared[claim.Name] = true
+		}
+
+		claimed := make(map[string]bool)
+		for _, container := range append(draSpec.InitContainers, draSpec.Containers...) {
+			for _, claim := range container.Resources.Claims {
+				if declared[claim.Name] {
+					claimed[container.Name] = true
+				}
+			}
+		}
+
+		unclaimed := make(map[string][]string)
+		for _, container := range allContainers(pod.spec) {
+			if claimed[container.Name] {
+				continue
+			}
+
+			for _, name := range gpuResourceNames {
+				if limit, ok := container.Resources.Limits[core.ResourceName(name)]; ok && !limit.IsZero() {
+					unclaimed[container.Name] = append(unclaimed[container.Name], name)
+				}
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(unclaimed) > 0 {
+			return resp, denyf(
+				ReasonMissingResourceClaim,
+				"the submitted Pods have containers that request accelerators without a resource claim: %v",
+				unclaimed,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// draContainer holds the Dynamic Resource Allocation fields of a Container.
+type draContainer struct {
+	Name      string `json:"name"`
+	Resources struct {
+		Claims []struct {
+			Name string `json:"name"`
+		} `json:"claims"`
+	} `json:"resources"`
+}
+
+// RequireGPUScheduling ensures that any Pod (or workload) with a container that
+// requests the given gpuResource (e.g. "nvidia.com/gpu") both selects GPU nodes
+// and tolerates their taint. Without these, GPU Pods either remain Pending or
+// are scheduled onto nodes without the drivers they need.
+//
+// The Pod's nodeSelector must contain every key-value pair in requiredSelector
+// (e.g. "cloud.google.com/gke-accelerator": "nvidia-tesla-t4"), and the Pod
+// must tolerate a taint keyed by gpuResource, as applied to GPU node pools by
+// most providers. An empty requiredSelector only requires the toleration. An
+// empty gpuResource is a configuration error, and all objects will be
+// rejected. Providing an empty/nil list of ignoredNamespaces will enforce this
+// across all namespaces.
+//
+// RequireGPUScheduling can inspect Pods, Deployments, StatefulSets, DaemonSets
+// & Jobs. Other object kinds are allowed.
+func RequireGPUScheduling(ignoredNamespaces []string, gpuResource string, requiredSelector map[string]string) AdmitFunc {
+	return RequireGPUSchedulingScoped(NamespaceScope{Ignored: ignoredNamespaces}, gpuResource, requiredSelector)
+}
+
+// RequireGPUSchedulingScoped is RequireGPUScheduling, enforced within the
+// namespaces of the given scope.
+func RequireGPUSchedulingScoped(scope NamespaceScope, gpuResource string, requiredSelector map[string]string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	if gpuResource == "" {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "a GPU resource name must be provided")
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		if !requestsResource(pod.spec, core.ResourceName(gpuResource)) {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		if missing, ok := ensureHasAnnotations(requiredSelector, pod.spec.NodeSelector); !ok {
+			return resp, denyf(
+				ReasonMissingGPUScheduling,
+				"the submitted Pods request %s without the required node selector: %v",
+				gpuResource,
+				missing,
+			)
+		}
+
+		if !toleratesTaintKey(pod.spec.Tolerations, gpuResource) {
+			return resp, denyf(
+				ReasonMissingGPUScheduling,
+				"the submitted Pods request %s without tolerating the %q taint",
+				gpuResource,
+				gpuResource,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// requestsResource returns true if any container in spec has a non-zero
+// request or limit for the named resource.
+func requestsResource(spec core.PodSpec, name core.ResourceName) bool {
+	for _, container := range allContainers(spec) {
+		if quantity, ok := container.Resources.Limits[name]; ok && !quantity.IsZero() {
+			return true
+		}
+
+		if quantity, ok := container.Resources.Requests[name]; ok && !quantity.IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toleratesTaintKey returns true if any of the tolerations matches a
+// NoSchedule taint with the given key, regardless of its value.
+func toleratesTaintKey(tolerations []core.Toleration, key string) bool {
+	for _, toleration := range tolerations {
+		if toleration.Effect != "" && toleration.Effect != core.TaintEffectNoSchedule {
+			continue
+		}
+
+		// An empty key with the Exists operator tolerates every taint.
+		if toleration.Key == key || (toleration.Key == "" && toleration.Operator == core.TolerationOpExists) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// digestRegexp matches an image reference that is pinned to a sha256 digest.
+var digestRegexp = regexp.MustCompile(`@sha256:[a-f0-9]{64}$`)
+
+// EnforceImageDigest ensures that every container image in a Pod (or workload)
+// is pinned to a digest (e.g. "nginx@sha256:..."), rather than a mutable tag.
+//
+// When enforcedNamespaces is non-empty, digests are only required within those
+// namespaces (e.g. production), and Pods in other namespaces are allowed.
+// ignoredNamespaces are always allowed.
+//
+// Both containers and initContainers are inspected. EnforceImageDigest can
+// inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other object
+// kinds are allowed.
+func EnforceImageDigest(ignoredNamespaces []string, enforcedNamespaces []string) AdmitFunc {
+	if len(enforcedNamespaces) == 0 {
+		return EnforceImageDigestScoped(NamespaceScope{Ignored: ignoredNamespaces})
+	}
+
+	// ignoredNamespaces take precedence over enforcedNamespaces.
+	var only []string
+	for _, namespace := range enforcedNamespaces {
+		if !containsString(ignoredNamespaces, namespace) {
+			only = append(only, namespace)
+		}
+	}
+
+	if len(only) == 0 {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			resp := newDefaultDenyResponse()
+			resp.Allowed = true
+			return resp, nil
+		}
+	}
+
+	return EnforceImageDigestScoped(NamespaceScope{Only: only})
+}
+
+// EnforceImageDigestScoped is EnforceImageDigest, enforced within the
+// namespaces of the given scope.
+func EnforceImageDigestScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var unpinned []string
+		for _, container := range allContainers(pod.spec) {
+			if !digestRegexp.MatchString(container.Image) {
+				unpinned = append(unpinned, container.Image)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(unpinned) > 0 {
+			return resp, denyf(
+				ReasonImageNotPinned,
+				"the submitted Pods have images that are not pinned to a digest: %v",
+				unpinned,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// EnforceFIPSImagePolicy ensures that every container image in a Pod (or
+// workload) is both pinned to a digest and pulled from one of the
+// allowedRegistries, as required in FIPS-regulated environments. It is
+// equivalent to EnforceImageDigest combined with a registry allow-list, but
+// reports both kinds of violation in a single denial.
+//
+// An allowed registry may be a host (e.g. "registry.example.com"), or a host and
+// repository prefix (e.g. "gcr.io/my-project"). Images without a registry host
+// (e.g. "nginx") are pulled from "docker.io". Providing an empty/nil list of
+// ignoredNamespaces will enforce this across all namespaces.
+//
+// Both containers and initContainers are inspected. EnforceFIPSImagePolicy can
+// inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other object
+// kinds are allowed.
+func EnforceFIPSImagePolicy(ignoredNamespaces []string, allowedRegistries []string) AdmitFunc {
+	return EnforceFIPSImagePolicyScoped(NamespaceScope{Ignored: ignoredNamespaces}, allowedRegistries)
+}
+
+// EnforceFIPSImagePolicyScoped is EnforceFIPSImagePolicy, enforced within the
+// namespaces of the given scope.
+func EnforceFIPSImagePolicyScoped(scope NamespaceScope, allowedRegistries []string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(allowedRegistries) == 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "at least one allowed registry must be provided")
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var unpinned, disallowed []string
+		for _, container := range allContainers(pod.spec) {
+			if !digestRegexp.MatchString(container.Image) {
+				unpinned = append(unpinned, container.Image)
+			}
+
+			if !fromAllowedRegistry(container.Image, allowedRegistries) {
+				disallowed = append(disallowed, container.Image)
+			}
+		}
+
+		var violations []string
+		if len(unpinned) > 0 {
+			violations = append(violations, fmt.Sprintf("images not pinned to a digest: %v", unpinned))
+		}
+
+		if len(disallowed) > 0 {
+			violations = append(violations, fmt.Sprintf("images from registries other than %v: %v", allowedRegistries, disallowed))
+		}
+
+		if len(violations) > 0 {
+			return resp, denyf(
+				ReasonFIPSImagePolicy,
+				"the submitted Pods violate the FIPS image policy: %s",
+				strings.Join(violations, "; "),
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// fromAllowedRegistry returns true if the image is pulled from one of the
+// allowedRegistries, each of which is a registry host, or a host and repository
+// prefix.
+func fromAllowedRegistry(image string, allowedRegistries []string) bool {
+	reference := image
+	if registry := imageRegistry(image); !strings.HasPrefix(image, registry+"/") {
+		reference = registry + "/" + image
+	}
+
+	for _, allowed := range allowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if strings.HasPrefix(reference, allowed+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// imageRegistry returns the registry host of an image reference. Following the
+// conventions of the Docker CLI, the first component of the reference is only a
+// host if it contains a "." or ":", or is "localhost", and images without one
+// are pulled from "docker.io".
+func imageRegistry(image string) string {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return "docker.io"
+	}
+
+	host := image[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return "docker.io"
+	}
+
+	return host
+}
+
+// RequireRunAsUserInRange ensures that every container in a Pod (or workload)
+// runs as a uid within the range assigned to its namespace, for multi-tenant
+// clusters that assign each tenant namespace a distinct range of uids.
+//
+// rangesByNamespace maps a namespace to its inclusive [min, max] uid range.
+// Containers must set runAsUser (on the container or Pod securityContext),
+// as the uid of the image cannot be verified. Namespaces without an assigned
+// range are allowed.
+//
+// Both containers and initContainers are inspected. RequireRunAsUserInRange can
+// inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other object
+// kinds are allowed.
+func RequireRunAsUserInRange(rangesByNamespace map[string][2]int64) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		uidRange, ok := rangesByNamespace[pod.namespace]
+		if !ok {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		var podRunAsUser *int64
+		if pod.spec.SecurityContext != nil {
+			podRunAsUser = pod.spec.SecurityContext.RunAsUser
+		}
+
+		var violations []string
+		for _, container := range allContainers(pod.spec) {
+			runAsUser := podRunAsUser
+			if container.SecurityContext != nil && container.SecurityContext.RunAsUser != nil {
+				runAsUser = container.SecurityContext.RunAsUser
+			}
+
+			switch {
+			case runAsUser == nil:
+				violations = append(violations, fmt.Sprintf("container %q does not set runAsUser", container.Name))
+			case *runAsUser < uidRange[0] || *runAsUser > uidRange[1]:
+				violations = append(violations, fmt.Sprintf("container %q runs as uid %d", container.Name, *runAsUser))
+			}
+		}
+
+		if len(violations) > 0 {
+			return resp, denyf(
+				ReasonRunAsUserOutOfRange,
+				"the submitted Pods must run as a uid in the range %d-%d assigned to the %s namespace: %s",
+				uidRange[0],
+				uidRange[1],
+				pod.namespace,
+				strings.Join(violations, "; "),
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyClusterScopedCreation denies the creation of cluster-scoped resources -
+// such as ClusterRoles or CustomResourceDefinitions - unless the requesting
+// user is a member of one of the allowedGroups (e.g. "system:masters").
+//
+// Requests without a namespace are treated as cluster-scoped. Operations other
+// than CREATE, and namespaced resources, will be allowed. You should scope the
+// webhook's rules to the cluster-scoped resources you wish to protect.
+func DenyClusterScopedCreation(allowedGroups []string) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		request := admissionReview.Request
+		resp := newDefaultDenyResponse()
+
+		if request.Operation != admission.Create || request.Namespace != "" {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		for _, group := range request.UserInfo.Groups {
+			if containsString(allowedGroups, group) {
+				resp.Allowed = true
+				return resp, nil
+			}
+		}
+
+		return resp, denyf(
+			ReasonClusterScopedCreation,
+			"%s objects are cluster-scoped, and may only be created by members of %v",
+			request.Kind.Kind,
+			allowedGroups,
+		)
+	}
+}
+
+// LimitConfigMapSize denies any ConfigMap whose data and binaryData values
+// total more than maxBytes. Large ConfigMaps bloat etcd, and are re-sent to
+// every kubelet that mounts them.
+//
+// Kinds other than ConfigMap will be allowed.
+func LimitConfigMapSize(ignoredNamespaces []string, maxBytes int) AdmitFunc {
+	return LimitConfigMapSizeScoped(NamespaceScope{Ignored: ignoredNamespaces}, maxBytes)
+}
+
+// LimitConfigMapSizeScoped is LimitConfigMapSize, enforced within the
+// namespaces of the given scope.
+func LimitConfigMapSizeScoped(scope NamespaceScope, maxBytes int) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "ConfigMap" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		configMap := core.ConfigMap{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &configMap); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, configMap.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		var size int
+		for _, value := range configMap.Data {
+			size += len(value)
+		}
+
+		for _, value := range configMap.BinaryData {
+			size += len(value)
+		}
+
+		if size > maxBytes {
+			return resp, denyf(
+				ReasonConfigMapTooLarge,
+				"%s objects may contain at most %d bytes of data: %q contains %d bytes",
+				kind,
+				maxBytes,
+				configMap.Name,
+				size,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RestrictPVCAccessModes denies any PersistentVolumeClaim that requests an
+// access mode not in allowedModes - e.g. ReadWriteMany, on storage backends
+// that only support ReadWriteOnce. Such claims would otherwise remain Pending
+// until a (suitable) volume cannot be provisioned.
+//
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces. Kinds other than PersistentVolumeClaim will be allowed.
+func RestrictPVCAccessModes(ignoredNamespaces []string, allowedModes []core.PersistentVolumeAccessMode) AdmitFunc {
+	return RestrictPVCAccessModesScoped(NamespaceScope{Ignored: ignoredNamespaces}, allowedModes)
+}
+
+// RestrictPVCAccessModesScoped is RestrictPVCAccessModes, enforced within the
+// namespaces of the given scope.
+func RestrictPVCAccessModesScoped(scope NamespaceScope, allowedModes []core.PersistentVolumeAccessMode) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if admissionReview.Request.Kind.Kind != "PersistentVolumeClaim" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		claim := core.PersistentVolumeClaim{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &claim); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, claim.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		var disallowed []core.PersistentVolumeAccessMode
+		for _, mode := range claim.Spec.AccessModes {
+			if !containsAccessMode(allowedModes, mode) {
+				disallowed = append(disallowed, mode)
+			}
+		}
+
+		if len(disallowed) > 0 {
+			return resp, denyf(
+				ReasonDisallowedAccessMode,
+				"PersistentVolumeClaim %q requests access modes %v, but only %v are allowed",
+				claim.Name,
+				disallowed,
+				allowedModes,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// containsAccessMode returns true if mode is in the given list.
+func containsAccessMode(modes []core.PersistentVolumeAccessMode, mode core.PersistentVolumeAccessMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnforceSecretTypeConventions ensures that Secrets whose names match a pattern
+// have the required type, so that controllers consume them correctly: e.g.
+// requiring that "*-tls" Secrets are of type kubernetes.io/tls, and not Opaque.
+//
+// rules maps a name pattern - using the syntax of path.Match - to the required
+// SecretType. A Secret must satisfy every rule its name matches. Secrets that
+// do not set a type are treated as Opaque.
+//
+// Kinds other than Secret will be allowed.
+func EnforceSecretTypeConventions(rules map[string]core.SecretType) AdmitFunc {
+	// Evaluate rules in a stable order, so that rejections are consistent.
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		resp := newDefaultDenyResponse()
+
+		if kind != "Secret" {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		secret := core.Secret{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &secret); err != nil {
+			return nil, err
+		}
+
+		secretType := secret.Type
+		if secretType == "" {
+			secretType = core.SecretTypeOpaque
+		}
+
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, secret.Name)
+			if err != nil {
+				return resp, denyf(ReasonInvalidConfiguration, "invalid Secret name pattern %q: %s", pattern, err)
+			}
+
+			if matched && secretType != rules[pattern] {
+				return resp, denyf(
+					ReasonSecretTypeMismatch,
+					"%s objects named %q must be of type %s: %q is of type %s",
+					kind,
+					pattern,
+					rules[pattern],
+					secret.Name,
+					secretType,
+				)
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyWindowsHostProcess denies any Pod (or workload) that runs Windows
+// HostProcess containers, which run with the privileges of the host. This may
+// be set via securityContext.windowsOptions.hostProcess on the Pod, or on
+// individual containers.
+//
+// Both containers and initContainers are inspected. DenyWindowsHostProcess can
+// inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other object
+// kinds are allowed.
+func DenyWindowsHostProcess(ignoredNamespaces []string) AdmitFunc {
+	return DenyWindowsHostProcessScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyWindowsHostProcessScoped is DenyWindowsHostProcess, enforced within the
+// namespaces of the given scope.
+func DenyWindowsHostProcessScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		// hostProcess is not present in the k8s.io/api version we build against,
+		// and is decoded separately.
+		type windowsOptions struct {
+			SecurityContext *struct {
+				WindowsOptions *struct {
+					HostProcess *bool `json:"hostProcess"`
+				} `json:"windowsOptions"`
+			} `json:"securityContext"`
+		}
+
+		type windowsContainer struct {
+			Name string `json:"name"`
+			windowsOptions
+		}
+
+		var windowsSpec struct {
+			windowsOptions
+			InitContainers []windowsContainer `json:"initContainers"`
+			Containers     []windowsContainer `json:"containers"`
+		}
+		if err := decodeRawPodSpec(admissionReview, &windowsSpec); err != nil {
+			return nil, err
+		}
+
+		hostProcess := func(opts windowsOptions) *bool {
+			if opts.SecurityContext == nil || opts.SecurityContext.WindowsOptions == nil {
+				return nil
+			}
+
+			return opts.SecurityContext.WindowsOptions.HostProcess
+		}
+
+		var hostProcessContainers []string
+		podHostProcess := hostProcess(windowsSpec.windowsOptions)
+		for _, container := range append(windowsSpec.InitContainers, windowsSpec.Containers...) {
+			// Container-level settings take precedence over those on the Pod.
+			enabled := podHostProcess
+			if containerHostProcess := hostProcess(container.windowsOptions); containerHostProcess != nil {
+				enabled = containerHostProcess
+			}
+
+			if enabled != nil && *enabled {
+				hostProcessContainers = append(hostProcessContainers, container.Name)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(hostProcessContainers) > 0 {
+			return resp, denyf(
+				ReasonWindowsHostProcess,
+				"the submitted Pods run Windows HostProcess containers, which cannot be deployed to this cluster: %v",
+				hostProcessContainers,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// appArmorAnnotationPrefix is the prefix of the (deprecated) per-container
+// annotation that sets the AppArmor profile of a container.
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// RequireAppArmorProfile ensures that every container in a Pod (or workload)
+// runs with one of the allowedProfiles AppArmor profiles.
+//
+// Profiles may be set via the securityContext.appArmorProfile field - on the
+// container, or on the Pod - or via the older
+// container.apparmor.security.beta.kubernetes.io/<container> annotations. The
+// container field takes precedence over the annotation, which takes precedence
+// over the Pod field. allowedProfiles use the annotation syntax:
+// "runtime/default", "localhost/<profile>" or "unconfined".
+//
+// Both containers and initContainers are inspected. Providing an empty/nil list
+// of ignoredNamespaces will enforce this across all namespaces.
+//
+// RequireAppArmorProfile can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func RequireAppArmorProfile(ignoredNamespaces []string, allowedProfiles []string) AdmitFunc {
+	return RequireAppArmorProfileScoped(NamespaceScope{Ignored: ignoredNamespaces}, allowedProfiles)
+}
+
+// RequireAppArmorProfileScoped is RequireAppArmorProfile, enforced within the
+// namespaces of the given scope.
+func RequireAppArmorProfileScoped(scope NamespaceScope, allowedProfiles []string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(allowedProfiles) == 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "at least one allowed AppArmor profile must be provided")
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		// appArmorProfile is not present in the k8s.io/api version we build
+		// against, and is decoded separately.
+		type appArmorContext struct {
+			SecurityContext *struct {
+				AppArmorProfile *appArmorProfile `json:"appArmorProfile"`
+			} `json:"securityContext"`
+		}
+
+		type appArmorContainer struct {
+			Name string `json:"name"`
+			appArmorContext
+		}
+
+		var appArmorSpec struct {
+			appArmorContext
+			InitContainers []appArmorContainer `json:"initContainers"`
+			Containers     []appArmorContainer `json:"containers"`
+		}
+		if err := decodeRawPodSpec(admissionReview, &appArmorSpec); err != nil {
+			return nil, err
+		}
+
+		profileOf := func(sc appArmorContext) *appArmorProfile {
+			if sc.SecurityContext == nil {
+				return nil
+			}
+
+			return sc.SecurityContext.AppArmorProfile
+		}
+
+		podProfile := profileOf(appArmorSpec.appArmorContext)
+		disallowed := make(map[string]string)
+		for _, container := range append(appArmorSpec.InitContainers, appArmorSpec.Containers...) {
+			var profile string
+			if containerProfile := profileOf(container.appArmorContext); containerProfile != nil {
+				profile = containerProfile.String()
+			} else if annotation, ok := pod.annotations[appArmorAnnotationPrefix+container.Name]; ok {
+				profile = annotation
+			} else if podProfile != nil {
+				profile = podProfile.String()
+			}
+
+			if containsString(allowedProfiles, profile) {
+				continue
+			}
+
+			if profile == "" {
+				profile = "none"
+			}
+
+			disallowed[container.Name] = profile
+		}
+
+		if len(disallowed) > 0 {
+			return resp, denyf(
+				ReasonDisallowedAppArmorProfile,
+				"the submitted Pods have containers without an allowed AppArmor profile (%v): %v",
+				allowedProfiles,
+				disallowed,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// appArmorProfile holds the securityContext.appArmorProfile of a Pod or
+// container.
+type appArmorProfile struct {
+	Type             string `json:"type"`
+	LocalhostProfile string `json:"localhostProfile"`
+}
+
+// String returns the profile in the syntax of the AppArmor annotation - e.g.
+// "runtime/default" or "localhost/<profile>".
+func (p *appArmorProfile) String() string {
+	switch p.Type {
+	case "RuntimeDefault":
+		return "runtime/default"
+	case "Localhost":
+		return "localhost/" + p.LocalhostProfile
+	case "Unconfined":
+		return "unconfined"
+	default:
+		return p.Type
+	}
+}
+
+// DenyUnmaskedProcMount denies any Pod (or workload) with a container that sets
+// securityContext.procMount to Unmasked, which exposes sensitive /proc paths
+// that the container runtime would otherwise mask.
+//
+// Both containers and initContainers are inspected. Providing an empty/nil list
+// of ignoredNamespaces will enforce this across all namespaces.
+//
+// DenyUnmaskedProcMount can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func DenyUnmaskedProcMount(ignoredNamespaces []string) AdmitFunc {
+	return DenyUnmaskedProcMountScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyUnmaskedProcMountScoped is DenyUnmaskedProcMount, enforced within the
+// namespaces of the given scope.
+func DenyUnmaskedProcMountScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var unmasked []string
+		for _, container := range allContainers(pod.spec) {
+			sc := container.SecurityContext
+			if sc != nil && sc.ProcMount != nil && *sc.ProcMount == core.UnmaskedProcMount {
+				unmasked = append(unmasked, container.Name)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(unmasked) > 0 {
+			return resp, denyf(
+				ReasonUnmaskedProcMount,
+				"the submitted Pods have containers with an Unmasked procMount: %v",
+				unmasked,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyServiceLinks denies any Pod (or workload) that does not explicitly set
+// enableServiceLinks to false. Service links inject environment variables for
+// every Service in the namespace into each container, which leaks the topology
+// of the namespace, and can collide with the environment of the application.
+//
+// enableServiceLinks defaults to true, and so Pods that do not set it are
+// denied. Providing an empty/nil list of ignoredNamespaces will enforce this
+// across all namespaces.
+//
+// DenyServiceLinks can inspect Pods, Deployments, StatefulSets, DaemonSets &
+// Jobs. Other object kinds are allowed.
+func DenyServiceLinks(ignoredNamespaces []string) AdmitFunc {
+	return DenyServiceLinksScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyServiceLinksScoped is DenyServiceLinks, enforced within the namespaces of
+// the given scope.
+func DenyServiceLinksScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		if enabled := pod.spec.EnableServiceLinks; enabled == nil || *enabled {
+			return resp, denyf(
+				ReasonServiceLinksEnabled,
+				"the submitted Pods must explicitly set enableServiceLinks: false",
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ValidateWorkingDir denies any Pod (or workload) with a container whose
+// workingDir is not a clean, absolute path - e.g. "app", or "/app/../etc".
+// Relative paths and path traversal in a workingDir are a common sign of a
+// mistake, or of an attempt to run from a sensitive directory.
+//
+// Containers that do not set a workingDir use that of their image, and are
+// allowed. A trailing slash (e.g. "/app/") is allowed. Providing an empty/nil
+// list of ignoredNamespaces will enforce this across all namespaces.
+//
+// Both containers and initContainers are inspected. ValidateWorkingDir can
+// inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other object
+// kinds are allowed.
+func ValidateWorkingDir(ignoredNamespaces []string) AdmitFunc {
+	return ValidateWorkingDirScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// ValidateWorkingDirScoped is ValidateWorkingDir, enforced within the
+// namespaces of the given scope.
+func ValidateWorkingDirScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		invalid := make(map[string]string)
+		for _, container := range allContainers(pod.spec) {
+			workingDir := container.WorkingDir
+			if workingDir == "" {
+				continue
+			}
+
+			trimmed := workingDir
+			if trimmed != "/" {
+				trimmed = strings.TrimSuffix(trimmed, "/")
+			}
+
+			if !path.IsAbs(workingDir) || path.Clean(trimmed) != trimmed {
+				invalid[container.Name] = workingDir
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(invalid) > 0 {
+			return resp, denyf(
+				ReasonInvalidWorkingDir,
+				"the submitted Pods have containers with a workingDir that is not a clean, absolute path: %v",
+				invalid,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireMemoryEmptyDirSizeLimit denies any Pod (or workload) with a
+// Memory-backed emptyDir volume that does not set a sizeLimit. These volumes
+// are backed by node RAM, and can otherwise grow until the node runs out of
+// memory.
+//
+// RequireMemoryEmptyDirSizeLimit can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func RequireMemoryEmptyDirSizeLimit(ignoredNamespaces []string) AdmitFunc {
+	return RequireMemoryEmptyDirSizeLimitScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// RequireMemoryEmptyDirSizeLimitScoped is RequireMemoryEmptyDirSizeLimit,
+// enforced within the namespaces of the given scope.
+func RequireMemoryEmptyDirSizeLimitScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var unbounded []string
+		for _, volume := range pod.spec.Volumes {
+			emptyDir := volume.EmptyDir
+			if emptyDir == nil || emptyDir.Medium != core.StorageMediumMemory {
+				continue
+			}
+
+			if emptyDir.SizeLimit == nil || emptyDir.SizeLimit.IsZero() {
+				unbounded = append(unbounded, volume.Name)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(unbounded) > 0 {
+			return resp, denyf(
+				ReasonUnboundedMemoryVolume,
+				"the submitted Pods have Memory-backed emptyDir volumes without a sizeLimit: %v",
+				unbounded,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyNeverPullPolicy denies any Pod (or workload) with a container that sets
+// imagePullPolicy: Never. These containers assume that their image has been
+// pre-loaded onto the node, which does not hold for nodes added by an
+// autoscaler.
+//
+// Both containers and initContainers are inspected. Providing an empty/nil list
+// of ignoredNamespaces will enforce this across all namespaces.
+func DenyNeverPullPolicy(ignoredNamespaces []string) AdmitFunc {
+	return DenyNeverPullPolicyScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyNeverPullPolicyScoped is DenyNeverPullPolicy, enforced within the
+// namespaces of the given scope.
+func DenyNeverPullPolicyScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var never []string
+		for _, container := range allContainers(pod.spec) {
+			if container.ImagePullPolicy == core.PullNever {
+				never = append(never, container.Name)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(never) > 0 {
+			return resp, denyf(
+				ReasonNeverPullPolicy,
+				"the submitted Pods have containers with imagePullPolicy: %s: %v",
+				core.PullNever,
+				never,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// AllowPullPolicyChangeAnnotation is the annotation that, when set to "true"
+// on the submitted object, allows DenyPullPolicyChanges to admit an update
+// that changes the imagePullPolicy of its containers.
+const AllowPullPolicyChangeAnnotation = "admission-control.elithrar.github.io/allow-pull-policy-change"
+
+// DenyPullPolicyChanges denies any update to a Pod (or workload) that changes
+// the imagePullPolicy of an existing container. Changing the pull policy
+// changes whether nodes re-pull (or re-use) cached images, and should not
+// happen silently as part of an unrelated change.
+//
+// Intentional changes are allowed when the submitted object is annotated with
+// AllowPullPolicyChangeAnnotation: "true". Only Update operations are
+// inspected, and only containers (and initContainers) present in both the old
+// and new object are compared.
+//
+// DenyPullPolicyChanges can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func DenyPullPolicyChanges() AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if admissionReview.Request.Operation != admission.Update {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		object := metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(admissionReview.Request.Object.Raw, &object); err != nil {
+			return nil, err
+		}
+
+		if object.Annotations[AllowPullPolicyChangeAnnotation] == "true" {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		oldPod, err := extractOldPodTemplate(admissionReview)
+		if err != nil {
+			return nil, err
+		}
+
+		oldPolicies := make(map[string]core.PullPolicy)
+		for _, container := range allContainers(oldPod.spec) {
+			oldPolicies[container.Name] = container.ImagePullPolicy
+		}
+
+		changed := make(map[string]string)
+		for _, container := range allContainers(pod.spec) {
+			oldPolicy, ok := oldPolicies[container.Name]
+			if ok && oldPolicy != container.ImagePullPolicy {
+				changed[container.Name] = fmt.Sprintf("%s -> %s", oldPolicy, container.ImagePullPolicy)
+			}
+		}
+
+		if len(changed) > 0 {
+			return resp, denyf(
+				ReasonPullPolicyChanged,
+				"the submitted Pods change the imagePullPolicy of existing containers without the %q annotation: %v",
+				AllowPullPolicyChangeAnnotation,
+				changed,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyDirectNodeAssignment denies the creation of any Pod (or workload) that
+// sets spec.nodeName. Such Pods bypass the scheduler, along with its resource
+// accounting, taints and affinity rules.
+//
+// Only Create operations are inspected: Pods that have already been bound to a
+// node by the scheduler can still be updated. Providing an empty/nil list of
+// ignoredNamespaces will enforce this across all namespaces.
+func DenyDirectNodeAssignment(ignoredNamespaces []string) AdmitFunc {
+	return DenyDirectNodeAssignmentScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyDirectNodeAssignmentScoped is DenyDirectNodeAssignment, enforced within
+// the namespaces of the given scope.
+func DenyDirectNodeAssignmentScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if admissionReview.Request.Operation != admission.Create {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		if pod.spec.NodeName != "" {
+			return resp, denyf(
+				ReasonDirectNodeAssignment,
+				"the submitted Pods may not bypass the scheduler by setting nodeName: %q",
+				pod.spec.NodeName,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ValidateReadinessGateConsistency denies any Pod (or workload) that declares
+// readinessGates, but where no container defines a readinessProbe. Such Pods
+// are treated as misconfigured: the readiness gates are typically expected to
+// complement, rather than replace, the readiness of the containers themselves.
+//
+// Init containers do not support readiness probes, and are not inspected. Pods
+// without readinessGates are allowed.
+func ValidateReadinessGateConsistency(ignoredNamespaces []string) AdmitFunc {
+	return ValidateReadinessGateConsistencyScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// ValidateReadinessGateConsistencyScoped is ValidateReadinessGateConsistency,
+// enforced within the namespaces of the given scope.
+func ValidateReadinessGateConsistencyScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(pod.spec.ReadinessGates) == 0 {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		for _, container := range pod.spec.Containers {
+			if container.ReadinessProbe != nil {
+				resp.Allowed = true
+				return resp, nil
+			}
+		}
+
+		conditionTypes := make([]string, 0, len(pod.spec.ReadinessGates))
+		for _, gate := range pod.spec.ReadinessGates {
+			conditionTypes = append(conditionTypes, string(gate.ConditionType))
+		}
+
+		return resp, denyf(
+			ReasonReadinessGateWithoutProbe,
+			"the submitted Pods declare readinessGates %v, but none of their containers define a readinessProbe",
+			conditionTypes,
+		)
+	}
+}
+
+// EnforceCRDField ensures that objects of the given Kind - typically a custom
+// resource - have a string field at fieldPath (e.g. "spec.tier") whose value
+// satisfies the provided matchFunc. Objects where the field is missing, or is
+// not a string, are rejected.
+//
+// Objects are matched by the group & kind of gvk, and decoded via
+// ExtractUnstructured, so that no typed client is required. Kinds other than
+// gvk will be allowed.
+func EnforceCRDField(gvk schema.GroupVersionKind, fieldPath string, matchFunc func(string) bool) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if normalizeGroupKind(admissionReview.Request.Kind) != gvk.GroupKind() {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if matchFunc == nil {
+			return resp, denyf(ReasonInvalidConfiguration, "cannot validate field (%s) with a nil matchFunc", fieldPath)
+		}
+
+		object, err := ExtractUnstructured(admissionReview)
+		if err != nil {
+			return nil, err
+		}
+
+		value, found, err := unstructured.NestedString(object.Object, strings.Split(fieldPath, ".")...)
+		if err != nil {
+			return resp, denyf(ReasonInvalidField, "%s %q: %s", gvk.Kind, object.GetName(), err)
+		}
+
+		if !found {
+			return resp, denyf(ReasonInvalidField, "%s %q is missing the required field %s", gvk.Kind, object.GetName(), fieldPath)
+		}
+
+		if !matchFunc(value) {
+			return resp, denyf(ReasonInvalidField, "%s %q has an invalid value for %s: %q", gvk.Kind, object.GetName(), fieldPath, value)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// defaultContainerAnnotation selects the container used by kubectl exec, logs
+// & attach when no container is specified.
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// ValidateDefaultContainerAnnotation denies any Pod (or workload) whose
+// kubectl.kubernetes.io/default-container annotation names a container that
+// does not exist in the Pod. A typo in the annotation otherwise breaks kubectl
+// exec & logs for the Pod.
+//
+// Pods without the annotation are allowed. ValidateDefaultContainerAnnotation
+// can inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other
+// object kinds are allowed.
+func ValidateDefaultContainerAnnotation(ignoredNamespaces []string) AdmitFunc {
+	return ValidateDefaultContainerAnnotationScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// ValidateDefaultContainerAnnotationScoped is
+// ValidateDefaultContainerAnnotation, enforced within the namespaces of the
+// given scope.
+func ValidateDefaultContainerAnnotationScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		defaultContainer, ok := pod.annotations[defaultContainerAnnotation]
+		if !ok {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		names := make([]string, 0, len(pod.spec.Containers))
+		for _, container := range pod.spec.Containers {
+			if container.Name == defaultContainer {
+				resp.Allowed = true
+				return resp, nil
+			}
+
+			names = append(names, container.Name)
+		}
+
+		return resp, denyf(
+			ReasonInvalidDefaultContainer,
+			"the %s annotation names a container %q that does not exist in the submitted Pods: must be one of %v",
+			defaultContainerAnnotation,
+			defaultContainer,
+			names,
+		)
+	}
+}
+
+// DenyDeprecatedAnnotations denies any object that carries a deprecated
+// annotation key, so that objects are migrated before the annotation stops
+// being honored: e.g. scheduler.alpha.kubernetes.io/critical-pod.
+//
+// deprecated maps each deprecated annotation key to its replacement, which is
+// suggested in the rejection. An empty replacement indicates that the
+// annotation has no replacement.
+//
+// Only the top-level metadata of the object is inspected, and so any Kind -
+// including custom resources - is supported. Requests without an object (e.g.
+// DELETE) are allowed.
+func DenyDeprecatedAnnotations(deprecated map[string]string) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		raw := admissionReview.Request.Object.Raw
+		if len(raw) == 0 {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		object := metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+
+		var found []string
+		for key := range object.Annotations {
+			replacement, ok := deprecated[key]
+			if !ok {
+				continue
+			}
+
+			if replacement == "" {
+				found = append(found, fmt.Sprintf("%s (no replacement)", key))
+			} else {
+				found = append(found, fmt.Sprintf("%s (use %s instead)", key, replacement))
+			}
+		}
+
+		if len(found) > 0 {
+			sort.Strings(found)
+			return resp, denyf(
+				ReasonDeprecatedAnnotation,
+				"%s %q uses deprecated annotations: %s",
+				admissionReview.Request.Kind.Kind,
+				object.Name,
+				strings.Join(found, ", "),
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// patchOperation is a single RFC 6902 JSON patch operation, as returned by
+// mutating AdmitFuncs.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// InjectDefaultSecurityContext is a mutating AdmitFunc that fills in the
+// securityContext of every container in a Pod (or workload) from the provided
+// defaults - e.g. runAsNonRoot, dropping ALL capabilities, and disallowing
+// privilege escalation - rather than rejecting containers that do not set it.
+//
+// Only fields that a container leaves unset are filled in: explicit settings,
+// such as runAsNonRoot: false, are preserved. Fields are merged at the top level
+// of the securityContext, and so a container that sets its own capabilities
+// does not have the default capabilities applied.
+//
+// The returned JSON patch is empty when every container already satisfies the
+// defaults, and so the AdmitFunc is safe to re-invoke. Pods are only mutated on
+// creation, as their securityContext cannot be updated.
+//
+// Both containers and initContainers are mutated. InjectDefaultSecurityContext
+// can inspect Pods, Deployments, StatefulSets, DaemonSets & Jobs. Other
+// object kinds are allowed.
+func InjectDefaultSecurityContext(ignoredNamespaces []string, defaults core.SecurityContext) AdmitFunc {
+	return InjectDefaultSecurityContextScoped(NamespaceScope{Ignored: ignoredNamespaces}, defaults)
+}
+
+// InjectDefaultSecurityContextScoped is InjectDefaultSecurityContext, enforced
+// within the namespaces of the given scope.
+func InjectDefaultSecurityContextScoped(scope NamespaceScope, defaults core.SecurityContext) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		isPod := normalizeGroupKind(admissionReview.Request.Kind) == core.SchemeGroupVersion.WithKind("Pod").GroupKind()
+		if isPod && admissionReview.Request.Operation != admission.Create {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		specPath := podSpecPath(admissionReview)
+		var patch []patchOperation
+		for field, containers := range map[string][]core.Container{
+			"initContainers": pod.spec.InitContainers,
+			"containers":     pod.spec.Containers,
+		} {
+			for i, container := range containers {
+				merged := mergeSecurityContext(container.SecurityContext, defaults)
+				if container.SecurityContext != nil && reflect.DeepEqual(*container.SecurityContext, merged) {
+					continue
+				}
+
+				patch = append(patch, patchOperation{
+					Op:    "add",
+					Path:  fmt.Sprintf("%s/%s/%d/securityContext", specPath, field, i),
+					Value: merged,
+				})
+			}
+		}
+
+		resp.Allowed = true
+		if len(patch) == 0 {
+			return resp, nil
+		}
+
+		// Map iteration order is random: sort the operations so that the patch is
+		// stable across invocations.
+		sort.Slice(patch, func(i, j int) bool { return patch[i].Path < patch[j].Path })
+
+		resp.Patch, err = json.Marshal(patch)
+		if err != nil {
+			return nil, err
+		}
+
+		patchType := admission.PatchTypeJSONPatch
+		resp.PatchType = &patchType
+
+		return resp, nil
+	}
+}
+
+// mergeSecurityContext returns a copy of the given SecurityContext, with any
+// unset (nil) fields filled in from defaults.
+func mergeSecurityContext(securityContext *core.SecurityContext, defaults core.SecurityContext) core.SecurityContext {
+	merged := core.SecurityContext{}
+	if securityContext != nil {
+		merged = *securityContext.DeepCopy()
+	}
+
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	defaultsValue := reflect.ValueOf(*defaults.DeepCopy())
+	for i := 0; i < mergedValue.NumField(); i++ {
+		field := mergedValue.Field(i)
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			field.Set(defaultsValue.Field(i))
+		}
+	}
+
+	return merged
+}
+
+// RequireRuntimeClass ensures that Pods (and workloads) in the provided
+// namespaces - e.g. those of untrusted tenants - set a runtimeClassName from
+// the allowed list, such as a sandboxed gVisor or Kata Containers runtime.
+//
+// Providing an empty/nil list of namespaces will enforce this across all
+// namespaces. RequireRuntimeClass can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func RequireRuntimeClass(namespaces []string, allowed []string) AdmitFunc {
+	return RequireRuntimeClassScoped(NamespaceScope{Only: namespaces}, allowed)
+}
+
+// RequireRuntimeClassScoped is RequireRuntimeClass, enforced within the
+// namespaces of the given scope.
+func RequireRuntimeClassScoped(scope NamespaceScope, allowed []string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		if runtimeClassName := pod.spec.RuntimeClassName; runtimeClassName == nil || !containsString(allowed, *runtimeClassName) {
+			runtimeClass := "<none>"
+			if runtimeClassName != nil {
+				runtimeClass = *runtimeClassName
+			}
+
+			return resp, denyf(
+				ReasonDisallowedRuntimeClass,
+				"the submitted Pods in namespace %q must set a runtimeClassName from %v: got %s",
+				pod.namespace,
+				allowed,
+				runtimeClass,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// DenyUniversalTolerations denies any Pod (or workload) with a catch-all
+// toleration - one with an empty key, operator: Exists and no effect - which
+// tolerates every taint, and defeats taint-based isolation of nodes.
+//
+// Tolerations for specific keys or effects are allowed. Providing an empty/nil
+// list of ignoredNamespaces will enforce this across all namespaces: ignore
+// the namespaces of node-level DaemonSets (e.g. kube-system) that rely on such
+// tolerations.
+func DenyUniversalTolerations(ignoredNamespaces []string) AdmitFunc {
+	return DenyUniversalTolerationsScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// DenyUniversalTolerationsScoped is DenyUniversalTolerations, enforced within
+// the namespaces of the given scope.
+func DenyUniversalTolerationsScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		for _, toleration := range pod.spec.Tolerations {
+			if toleration.Key == "" && toleration.Operator == core.TolerationOpExists && toleration.Effect == "" {
+				return resp, denyf(
+					ReasonUniversalToleration,
+					"the submitted Pods may not tolerate all taints (a toleration with an empty key, operator: %s and no effect)",
+					core.TolerationOpExists,
+				)
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// LimitAffinityTerms denies any Pod (or workload) with more than max affinity
+// terms, combined. Each term is evaluated against every node (or every Pod) by
+// the scheduler, and Pods with many terms significantly slow scheduling for
+// the whole cluster.
+//
+// The required and preferred terms of nodeAffinity, podAffinity and
+// podAntiAffinity are counted. Providing an empty/nil list of ignoredNamespaces
+// will enforce this across all namespaces.
+//
+// LimitAffinityTerms can inspect Pods, Deployments, StatefulSets, DaemonSets &
+// Jobs. Other object kinds are allowed.
+func LimitAffinityTerms(ignoredNamespaces []string, max int) AdmitFunc {
+	return LimitAffinityTermsScoped(NamespaceScope{Ignored: ignoredNamespaces}, max)
+}
+
+// LimitAffinityTermsScoped is LimitAffinityTerms, enforced within the
+// namespaces of the given scope.
+func LimitAffinityTermsScoped(scope NamespaceScope, max int) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if max <= 0 {
+			return resp, denyf(ReasonInvalidConfiguration, "the maximum number of affinity terms must be greater than zero (got %d)", max)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		if terms := countAffinityTerms(pod.spec.Affinity); terms > max {
+			return resp, denyf(
+				ReasonTooManyAffinityTerms,
+				"the submitted Pods have %d affinity terms, which exceeds the maximum of %d",
+				terms,
+				max,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// countAffinityTerms returns the combined number of required and preferred
+// node affinity, pod affinity & pod anti-affinity terms.
+func countAffinityTerms(affinity *core.Affinity) int {
+	if affinity == nil {
+		return 0
+	}
+
+	var terms int
+	if node := affinity.NodeAffinity; node != nil {
+		if node.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+			terms += len(node.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+		}
+
+		terms += len(node.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+
+	if pod := affinity.PodAffinity; pod != nil {
+		terms += len(pod.RequiredDuringSchedulingIgnoredDuringExecution)
+		terms += len(pod.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+
+	if antiAffinity := affinity.PodAntiAffinity; antiAffinity != nil {
+		terms += len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		terms += len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+
+	return terms
+}
+
+// RestrictAffinityNamespaceSelectors denies any Pod (or workload) with a pod
+// affinity or anti-affinity term whose namespaceSelector is empty, which
+// matches Pods in every namespace. Such terms couple the scheduling of one
+// tenant's Pods to those of every other tenant.
+//
+// A nil namespaceSelector (the default) only matches Pods in the namespaces
+// listed by the term, or the Pod's own namespace, and is allowed. Providing an
+// empty/nil list of ignoredNamespaces will enforce this across all namespaces.
+//
+// RestrictAffinityNamespaceSelectors can inspect Pods, Deployments,
+// StatefulSets, DaemonSets & Jobs. Other object kinds are allowed.
+func RestrictAffinityNamespaceSelectors(ignoredNamespaces []string) AdmitFunc {
+	return RestrictAffinityNamespaceSelectorsScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// RestrictAffinityNamespaceSelectorsScoped is
+// RestrictAffinityNamespaceSelectors, enforced within the namespaces of the
+// given scope.
+func RestrictAffinityNamespaceSelectorsScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		var terms []core.PodAffinityTerm
+		if affinity := pod.spec.Affinity; affinity != nil {
+			if podAffinity := affinity.PodAffinity; podAffinity != nil {
+				terms = append(terms, podAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+				for _, weighted := range podAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+					terms = append(terms, weighted.PodAffinityTerm)
+				}
+			}
+
+			if antiAffinity := affinity.PodAntiAffinity; antiAffinity != nil {
+				terms = append(terms, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+				for _, weighted := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+					terms = append(terms, weighted.PodAffinityTerm)
+				}
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		for _, term := range terms {
+			selector := term.NamespaceSelector
+			if selector != nil && len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+				return resp, denyf(
+					ReasonAllNamespacesAffinity,
+					"the submitted Pods have pod affinity terms with an empty namespaceSelector, which matches all namespaces (topologyKey: %s)",
+					term.TopologyKey,
+				)
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RequireVolumes ensures that Pods (and workloads) declare volumes with each of
+// the requiredNames - e.g. a "logging" volume that a platform's conventions
+// require every Pod to mount.
+//
+// Only the names of the volumes are checked, and not their source. Providing an
+// empty/nil list of ignoredNamespaces will enforce this across all namespaces.
+//
+// RequireVolumes can inspect Pods, Deployments, StatefulSets, DaemonSets &
+// Jobs. Other object kinds are allowed.
+func RequireVolumes(ignoredNamespaces []string, requiredNames []string) AdmitFunc {
+	return RequireVolumesScoped(NamespaceScope{Ignored: ignoredNamespaces}, requiredNames)
+}
+
+// RequireVolumesScoped is RequireVolumes, enforced within the namespaces of the
+// given scope.
+func RequireVolumesScoped(scope NamespaceScope, requiredNames []string) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		declared := make(map[string]bool, len(pod.spec.Volumes))
+		for _, volume := range pod.spec.Volumes {
+			declared[volume.Name] = true
+		}
+
+		var missing []string
+		for _, name := range requiredNames {
+			if !declared[name] {
+				missing = append(missing, name)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(missing) > 0 {
+			return resp, denyf(ReasonMissingVolume, "the submitted Pods are missing required volumes: %v", missing)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ValidateInitContainerRestartPolicy ensures that init containers in a Pod (or
+// workload) only set a restartPolicy - declaring them as native sidecar
+// containers - when allowSidecars is true. Clusters that predate native
+// sidecars do not support the field, and Pods that rely on it will not start
+// as intended.
+//
+// When allowSidecars is true, "Always" is the only valid restartPolicy for an
+// init container. Providing an empty/nil list of ignoredNamespaces will enforce
+// this across all namespaces.
+//
+// ValidateInitContainerRestartPolicy can inspect Pods, Deployments,
+// StatefulSets, DaemonSets & Jobs. Other object kinds are allowed.
+func ValidateInitContainerRestartPolicy(ignoredNamespaces []string, allowSidecars bool) AdmitFunc {
+	return ValidateInitContainerRestartPolicyScoped(NamespaceScope{Ignored: ignoredNamespaces}, allowSidecars)
+}
+
+// ValidateInitContainerRestartPolicyScoped is
+// ValidateInitContainerRestartPolicy, enforced within the namespaces of the
+// given scope.
+func ValidateInitContainerRestartPolicyScoped(scope NamespaceScope, allowSidecars bool) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		// The restartPolicy of a container is not present in the k8s.io/api
+		// version we build against, and is decoded separately.
+		var sidecarSpec struct {
+			InitContainers []struct {
+				Name          string `json:"name"`
+				RestartPolicy string `json:"restartPolicy"`
+			} `json:"initContainers"`
+		}
+		if err := decodeRawPodSpec(admissionReview, &sidecarSpec); err != nil {
+			return nil, err
+		}
+
+		invalid := make(map[string]string)
+		for _, container := range sidecarSpec.InitContainers {
+			if container.RestartPolicy == "" {
+				continue
+			}
+
+			if !allowSidecars || container.RestartPolicy != string(core.RestartPolicyAlways) {
+				invalid[container.Name] = container.RestartPolicy
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(invalid) > 0 {
+			if !allowSidecars {
+				return resp, denyf(
+					ReasonInvalidInitContainerRestartPolicy,
+					"the submitted Pods have init containers that set a restartPolicy, but sidecar containers are not allowed: %v",
+					invalid,
+				)
+			}
+
+			return resp, denyf(
+				ReasonInvalidInitContainerRestartPolicy,
+				"the submitted Pods have init containers with an invalid restartPolicy (only %q is allowed): %v",
+				core.RestartPolicyAlways,
+				invalid,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ValidateHugePages denies any Pod (or workload) with a container that requests
+// huge pages (e.g. "hugepages-2Mi") without a limit equal to its request. Huge
+// pages cannot be overcommitted, and such Pods fail to schedule.
+//
+// A limit without a request is allowed: the request defaults to the limit.
+// Providing an empty/nil list of ignoredNamespaces will enforce this across
+// all namespaces.
+//
+// ValidateHugePages can inspect Pods, Deployments, StatefulSets, DaemonSets &
+// Jobs. Other object kinds are allowed.
+func ValidateHugePages(ignoredNamespaces []string) AdmitFunc {
+	return ValidateHugePagesScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// ValidateHugePagesScoped is ValidateHugePages, enforced within the namespaces
+// of the given scope.
+func ValidateHugePagesScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		mismatched := make(map[string][]string)
+		for _, container := range allContainers(pod.spec) {
+			for name, request := range container.Resources.Requests {
+				if !strings.HasPrefix(string(name), core.ResourceHugePagesPrefix) {
+					continue
+				}
+
+				if limit, ok := container.Resources.Limits[name]; !ok || limit.Cmp(request) != 0 {
+					mismatched[container.Name] = append(mismatched[container.Name], string(name))
+				}
+			}
+
+			sort.Strings(mismatched[container.Name])
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(mismatched) > 0 {
+			return resp, denyf(
+				ReasonInvalidHugePages,
+				"the submitted Pods have containers that request huge pages without an equal limit: %v",
+				mismatched,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ValidateQoSConsistency ensures that Pods (and workloads) have the
+// requiredClass Quality of Service class - e.g. Guaranteed, for latency
+// sensitive workloads whose containers must not be throttled or evicted ahead
+// of others. Pods that intend to be Guaranteed are easily made Burstable by a
+// single container without (equal) CPU and memory limits.
+//
+// The QoS class is computed as the kubelet does, from the CPU and memory
+// requests & limits of every container and initContainer. An invalid
+// requiredClass is a configuration error, and all objects will be rejected.
+// Providing an empty/nil list of ignoredNamespaces will enforce this across all
+// namespaces.
+//
+// ValidateQoSConsistency can inspect Pods, Deployments, StatefulSets,
+// DaemonSets & Jobs. Other object kinds are allowed.
+func ValidateQoSConsistency(ignoredNamespaces []string, requiredClass core.PodQOSClass) AdmitFunc {
+	return ValidateQoSConsistencyScoped(NamespaceScope{Ignored: ignoredNamespaces}, requiredClass)
+}
+
+// ValidateQoSConsistencyScoped is ValidateQoSConsistency, enforced within the
+// namespaces of the given scope.
+func ValidateQoSConsistencyScoped(scope NamespaceScope, requiredClass core.PodQOSClass) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	switch requiredClass {
+	case core.PodQOSGuaranteed, core.PodQOSBurstable, core.PodQOSBestEffort:
+	default:
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "%q is not a valid QoS class", requiredClass)
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		resp := newDefaultDenyResponse()
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		if class := podQOSClass(pod.spec); class != requiredClass {
+			return resp, denyf(
+				ReasonQoSClassMismatch,
+				"the submitted Pods have a QoS class of %s, but must be %s",
+				class,
+				requiredClass,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// podQOSClass returns the QoS class of a Pod with the given spec, following the
+// kubelet's GetPodQOS. Unset requests are treated as equal to their limits, as
+// they are defaulted by the apiserver.
+func podQOSClass(spec core.PodSpec) core.PodQOSClass {
+	resources := []core.ResourceName{core.ResourceCPU, core.ResourceMemory}
+	guaranteed := true
+	var requested, limited bool
+
+	for _, container := range allContainers(spec) {
+		for _, name := range resources {
+			limit, hasLimit := container.Resources.Limits[name]
+			hasLimit = hasLimit && limit.Sign() > 0
+
+			request, hasRequest := container.Resources.Requests[name]
+			hasRequest = hasRequest && request.Sign() > 0
+			if !hasRequest && hasLimit {
+				request, hasRequest = limit, true
+			}
+
+			requested = requested || hasRequest
+			limited = limited || hasLimit
+
+			if !hasLimit || !hasRequest || request.Cmp(limit) != 0 {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case !requested && !limited:
+		return core.PodQOSBestEffort
+	case guaranteed:
+		return core.PodQOSGuaranteed
+	default:
+		return core.PodQOSBurstable
+	}
+}
+
+// ExclusiveCPUAnnotation is the Pod annotation that, when set to "true",
+// declares that the containers of a Pod intend to be allocated exclusive CPUs
+// by the kubelet's static CPU manager policy. It is checked by
+// ValidateExclusiveCPU.
+const ExclusiveCPUAnnotation = "admission-control.elithrar.github.io/exclusive-cpus"
+
+// ValidateExclusiveCPU denies any Pod (or workload) annotated with
+// ExclusiveCPUAnnotation: "true" that cannot be allocated exclusive CPUs by the
+// static CPU manager policy. Only the containers of Guaranteed Pods with an
+// integer CPU limit (e.g. "2", and not "1500m") are allocated exclusive CPUs:
+// other containers silently share the CPU pool with every other Pod.
+//
+// Pods without the annotation are allowed. Providing an empty/nil list of
+// ignoredNamespaces will enforce this across all namespaces.
+//
+// ValidateExclusiveCPU can inspect Pods, Deployments, StatefulSets, DaemonSets
+// & Jobs. Other object kinds are allowed.
+func ValidateExclusiveCPU(ignoredNamespaces []string) AdmitFunc {
+	return ValidateExclusiveCPUScoped(NamespaceScope{Ignored: ignoredNamespaces})
+}
+
+// ValidateExclusiveCPUScoped is ValidateExclusiveCPU, enforced within the
+// namespaces of the given scope.
+func ValidateExclusiveCPUScoped(scope NamespaceScope) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		resp := newDefaultDenyResponse()
+		if pod.annotations[ExclusiveCPUAnnotation] != "true" {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		if class := podQOSClass(pod.spec); class != core.PodQOSGuaranteed {
+			return resp, denyf(
+				ReasonInvalidExclusiveCPU,
+				"the submitted Pods request exclusive CPUs, but have a QoS class of %s (not Guaranteed)",
+				class,
+			)
+		}
+
+		fractional := make(map[string]string)
+		for _, container := range pod.spec.Containers {
+			limit := container.Resources.Limits[core.ResourceCPU]
+			if limit.MilliValue()%1000 != 0 {
+				fractional[container.Name] = limit.String()
+			}
+		}
+
+		if len(fractional) > 0 {
+			return resp, denyf(
+				ReasonInvalidExclusiveCPU,
+				"the submitted Pods request exclusive CPUs, but have containers with non-integer CPU limits: %v",
+				fractional,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// RegistryClient provides access to image metadata from a container registry.
+type RegistryClient interface {
+	// Architectures returns the CPU architectures (e.g. "amd64", "arm64")
+	// supported by the given image, as described by its manifest list. Images
+	// with a single manifest return a single architecture.
+	Architectures(ctx context.Context, image string) ([]string, error)
+}
+
+// WarnImageArchitecture warns when the images of a Pod (or workload) do not
+// support the nodeArch architecture (e.g. "arm64") of the cluster, by querying
+// the image manifests via the provided RegistryClient. Such images will fail
+// to start with an "exec format error".
+//
+// WarnImageArchitecture does not reject images: it returns warnings to the
+// client instead. Images that cannot be inspected are also warned on. A nil
+// registryClient is a configuration error, and all objects will be rejected.
+func WarnImageArchitecture(nodeArch string, registryClient RegistryClient) AdmitFunc {
+	if registryClient == nil {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "a RegistryClient must be provided")
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+		defer cancel()
+
+		resp := newDefaultDenyResponse()
+		checked := make(map[string]bool)
+		for _, container := range allContainers(pod.spec) {
+			if checked[container.Image] {
+				continue
+			}
+			checked[container.Image] = true
+
+			architectures, err := registryClient.Architectures(ctx, container.Image)
+			if err != nil {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"could not verify that image %q (container %q) supports %s: %s",
+					container.Image, container.Name, nodeArch, err,
+				))
+				continue
+			}
+
+			if !containsString(architectures, nodeArch) {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf(
+					"image %q (container %q) does not support the %s architecture of this cluster: %v",
+					container.Image, container.Name, nodeArch, architectures,
+				))
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ImageVerifier verifies the signatures of container images - e.g. against a
+// cosign policy.
+type ImageVerifier interface {
+	// Verify returns an error if the given image is not signed in accordance
+	// with the policy. Failures to reach the signature store, rather than
+	// failed verification, should be returned as a DependencyError.
+	Verify(ctx context.Context, image string) error
+}
+
+// RequireSignedImages denies any Pod (or workload) with a container image that
+// fails verification by the provided ImageVerifier.
+//
+// Errors from the ImageVerifier that are a DependencyError are returned as-is,
+// so that this AdmitFunc can be wrapped with WithCircuitBreaker. A nil verifier
+// is a configuration error, and all objects will be rejected. Providing an
+// empty/nil list of ignoredNamespaces will enforce this across all namespaces.
+func RequireSignedImages(ignoredNamespaces []string, verifier ImageVerifier) AdmitFunc {
+	return RequireSignedImagesScoped(NamespaceScope{Ignored: ignoredNamespaces}, verifier)
+}
+
+// RequireSignedImagesScoped is RequireSignedImages, enforced within the
+// namespaces of the given scope.
+func RequireSignedImagesScoped(scope NamespaceScope, verifier ImageVerifier) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	if verifier == nil {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "an ImageVerifier must be provided")
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+		defer cancel()
+
+		var unverified []string
+		checked := make(map[string]bool)
+		for _, container := range allContainers(pod.spec) {
+			if checked[container.Image] {
+				continue
+			}
+			checked[container.Image] = true
+
+			if err := verifier.Verify(ctx, container.Image); err != nil {
+				var dependencyErr *DependencyError
+				if xerrors.As(err, &dependencyErr) {
+					return nil, err
+				}
+
+				unverified = append(unverified, fmt.Sprintf("%s (%s)", container.Image, err))
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(unverified) > 0 {
+			return resp, denyf(
+				ReasonUnsignedImage,
+				"the submitted Pods have images that failed signature verification: %s",
+				strings.Join(unverified, ", "),
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ResourceLister looks up objects in the cluster - typically from the cache of
+// an informer, so that AdmitFuncs do not call the apiserver on every request.
+type ResourceLister interface {
+	// ConfigMapExists reports whether the named ConfigMap exists in the namespace.
+	ConfigMapExists(namespace, name string) (bool, error)
+	// SecretExists reports whether the named Secret exists in the namespace.
+	SecretExists(namespace, name string) (bool, error)
+}
+
+// RequireReferencedResourcesExist denies any Pod (or workload) that references
+// a ConfigMap or Secret - via envFrom, or a volume - that does not exist in its
+// namespace, as looked up via the provided ResourceLister. Such Pods are
+// otherwise admitted, and then fail to start.
+//
+// References marked as optional are not checked. Failures of the
+// ResourceLister are returned as a DependencyError, so that this AdmitFunc can
+// be wrapped with WithCircuitBreaker.
+//
+// RequireReferencedResourcesExist is best-effort: an informer cache may lag
+// behind objects created in the same apply. A nil lister is a configuration
+// error, and all objects will be rejected.
+func RequireReferencedResourcesExist(ignoredNamespaces []string, lister ResourceLister) AdmitFunc {
+	return RequireReferencedResourcesExistScoped(NamespaceScope{Ignored: ignoredNamespaces}, lister)
+}
+
+// RequireReferencedResourcesExistScoped is RequireReferencedResourcesExist,
+// enforced within the namespaces of the given scope.
+func RequireReferencedResourcesExistScoped(scope NamespaceScope, lister ResourceLister) AdmitFunc {
+	if err := scope.Validate(); err != nil {
+		return invalidNamespaceScope(err)
+	}
+
+	if lister == nil {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "a ResourceLister must be provided")
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		pod, err := extractPodTemplate(admissionReview)
+		if err != nil {
+			return passThroughUnsupportedKindError(admissionReview, err)
+		}
+
+		if resp, ok := scope.allow(pod.namespace); ok {
+			return resp, nil
+		}
+
+		configMaps := make(map[string]bool)
+		secrets := make(map[string]bool)
+		for _, container := range allContainers(pod.spec) {
+			for _, envFrom := range container.EnvFrom {
+				if ref := envFrom.ConfigMapRef; ref != nil && !isOptional(ref.Optional) {
+					configMaps[ref.Name] = true
+				}
+
+				if ref := envFrom.SecretRef; ref != nil && !isOptional(ref.Optional) {
+					secrets[ref.Name] = true
+				}
+			}
+		}
+
+		for _, volume := range pod.spec.Volumes {
+			if source := volume.ConfigMap; source != nil && !isOptional(source.Optional) {
+				configMaps[source.Name] = true
+			}
+
+			if source := volume.Secret; source != nil && !isOptional(source.Optional) {
+				secrets[source.SecretName] = true
+			}
+		}
+
+		var missing []string
+		for name := range configMaps {
+			exists, err := lister.ConfigMapExists(pod.namespace, name)
+			if err != nil {
+				return nil, &DependencyError{Err: err}
+			}
+
+			if !exists {
+				missing = append(missing, "ConfigMap/"+name)
+			}
+		}
+
+		for name := range secrets {
+			exists, err := lister.SecretExists(pod.namespace, name)
+			if err != nil {
+				return nil, &DependencyError{Err: err}
+			}
+
+			if !exists {
+				missing = append(missing, "Secret/"+name)
+			}
+		}
+
+		resp := newDefaultDenyResponse()
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return resp, denyf(
+				ReasonMissingReferencedResource,
+				"the submitted Pods reference resources that do not exist in namespace %q: %v",
+				pod.namespace,
+				missing,
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// isOptional reports whether an optional reference is marked as optional.
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+// IngressLister lists the Ingresses in the cluster - typically from the cache
+// of an informer, so that AdmitFuncs do not call the apiserver on every request.
+type IngressLister interface {
+	// ListIngresses returns the Ingresses across all namespaces.
+	ListIngresses() ([]networking.Ingress, error)
+}
+
+// DenyConflictingIngressPaths denies any Ingress that claims a host & path
+// which an Ingress in another namespace has already claimed, as listed via the
+// provided IngressLister. Conflicting Ingresses otherwise result in
+// nondeterministic routing.
+//
+// Ingresses in the same namespace are not considered to conflict, as they are
+// typically owned by the same team. Failures of the IngressLister are returned
+// as a DependencyError, so that this AdmitFunc can be wrapped with
+// WithCircuitBreaker. A nil lister is a configuration error, and all objects
+// will be rejected.
+//
+// Ingresses from the networking.k8s.io and legacy extensions API groups are
+// inspected. Other Kinds - including Ingress Kinds from other groups - will be
+// allowed.
+func DenyConflictingIngressPaths(lister IngressLister) AdmitFunc {
+	if lister == nil {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "an IngressLister must be provided")
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+
+		if normalizeGroupKind(admissionReview.Request.Kind) != networking.SchemeGroupVersion.WithKind("Ingress").GroupKind() {
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		ingress := networking.Ingress{}
+		deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+		if _, _, err := deserializer.Decode(admissionReview.Request.Object.Raw, nil, &ingress); err != nil {
+			return nil, err
+		}
+
+		namespace := requestNamespace(admissionReview, ingress.Namespace)
+		claimed := ingressPaths(ingress)
+		if len(claimed) == 0 {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		existing, err := lister.ListIngresses()
+		if err != nil {
+			return nil, &DependencyError{Err: err}
+		}
+
+		var conflicts []string
+		for _, other := range existing {
+			if other.Namespace == namespace {
+				continue
+			}
+
+			for hostPath := range ingressPaths(other) {
+				if claimed[hostPath] {
+					conflicts = append(conflicts, fmt.Sprintf("%s (%s/%s)", hostPath, other.Namespace, other.Name))
+				}
+			}
+		}
+
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return resp, denyf(
+				ReasonConflictingIngressPath,
+				"Ingress %q claims host paths that are already claimed in other namespaces: %s",
+				ingress.Name,
+				strings.Join(conflicts, ", "),
+			)
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// ingressPaths returns the set of "host/path" pairs claimed by the rules of the
+// given Ingress. Rules without a host claim the path for all hosts ("*").
+func ingressPaths(ingress networking.Ingress) map[string]bool {
+	paths := make(map[string]bool)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		host := rule.Host
+		if host == "" {
+			host = "*"
+		}
+
+		for _, httpPath := range rule.HTTP.Paths {
+			claimedPath := httpPath.Path
+			if claimedPath == "" {
+				claimedPath = "/"
+			}
+
+			paths[host+claimedPath] = true
+		}
+	}
+
+	return paths
+}
+
+// QuotaLister looks up the ResourceQuotas of a namespace - typically from the
+// cache of an informer.
+type QuotaLister interface {
+	// ListResourceQuotas returns the ResourceQuotas in the namespace.
+	ListResourceQuotas(namespace string) ([]core.ResourceQuota, error)
+}
+
+// PreflightQuotaCheck denies a Deployment or StatefulSet - or a request to the
+// scale subresource of a workload - that would increase its replicas beyond the Pod
+// count quota of its namespace, as looked up via the provided QuotaLister. The
+// scale would otherwise be admitted, and the ReplicaSet controller would then
+// fail to create the excess Pods.
+//
+// The change in replicas is estimated from the old & new objects: Pods created
+// in excess of the replicas during a rolling update are not accounted for.
+// Failures of the QuotaLister are returned as a DependencyError, so that this
+// AdmitFunc can be wrapped with WithCircuitBreaker. A nil lister is a
+// configuration error, and all objects will be rejected. Other Kinds, and
+// requests that do not increase replicas, will be allowed.
+func PreflightQuotaCheck(lister QuotaLister) AdmitFunc {
+	if lister == nil {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "a QuotaLister must be provided")
+		}
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+		request := admissionReview.Request
+
+		// Requests to the scale subresource of a workload submit an
+		// autoscaling/v1 Scale, which has no default for its replicas.
+		var defaultReplicas int32
+		switch groupKind := normalizeGroupKind(request.Kind); {
+		case groupKind == autoscaling.SchemeGroupVersion.WithKind("Scale").GroupKind():
+		case groupKind == apps.SchemeGroupVersion.WithKind("Deployment").GroupKind(),
+			groupKind == apps.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+			defaultReplicas = 1
+		default:
+			return PassThroughUnsupportedKind(admissionReview), nil
+		}
+
+		if request.Operation != admission.Create && request.Operation != admission.Update {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		object, err := decodeReplicas(request.Object.Raw, defaultReplicas)
+		if err != nil {
+			return nil, err
+		}
+
+		var oldReplicas int32
+		if request.Operation == admission.Update {
+			old, err := decodeReplicas(request.OldObject.Raw, defaultReplicas)
+			if err != nil {
+				return nil, err
+			}
+
+			oldReplicas = old.replicas
+		}
+
+		delta := object.replicas - oldReplicas
+		if delta <= 0 {
+			resp.Allowed = true
+			return resp, nil
+		}
+
+		namespace := requestNamespace(admissionReview, object.namespace)
+		quotas, err := lister.ListResourceQuotas(namespace)
+		if err != nil {
+			return nil, &DependencyError{Err: err}
+		}
+
+		for _, quota := range quotas {
+			hard, ok := quota.Spec.Hard[core.ResourcePods]
+			if !ok {
+				continue
+			}
+
+			used := quota.Status.Used[core.ResourcePods]
+			if used.Value()+int64(delta) > hard.Value() {
+				return resp, denyf(
+					ReasonQuotaExceeded,
+					"scaling %q by %d replicas would exceed the Pod quota of ResourceQuota %q in namespace %q: %d of %d Pods are in use",
+					object.name,
+					delta,
+					quota.Name,
+					namespace,
+					used.Value(),
+					hard.Value(),
+				)
+			}
+		}
+
+		resp.Allowed = true
+		return resp, nil
+	}
+}
+
+// replicatedObject holds the name, namespace & replicas of an object with a
+// spec.replicas field.
+type replicatedObject struct {
+	name      string
+	namespace string
+	replicas  int32
+}
+
+// decodeReplicas decodes the name, namespace & replicas of the raw object,
+// using defaultReplicas when spec.replicas is unset.
+func decodeReplicas(raw []byte, defaultReplicas int32) (*replicatedObject, error) {
+	var object struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas *int32 `json:"replicas"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+
+	replicas := defaultReplicas
+	if object.Spec.Replicas != nil {
+		replicas = *object.Spec.Replicas
+	}
+
+	return &replicatedObject{
+		name:      object.Metadata.Name,
+		namespace: object.Metadata.Namespace,
+		replicas:  replicas,
+	}, nil
+}
+
+// podTemplate holds the metadata and PodSpec of a Pod, or of the
+// PodTemplateSpec embedded in a workload Kind.
+type podTemplate struct {
+	// namespace is the namespace of the submitted (outer) object.
+	namespace string
+	// annotations are the annotations on the Pod itself - for workload Kinds,
+	// these are the PodTemplateSpec annotations, and not those of the outer
+	// object.
+	annotations map[string]string
+	spec        core.PodSpec
+}
+
+// extractPodTemplate decodes the object in the AdmissionReview and returns the
+// metadata & PodSpec for the Pods it describes.
+//
+// We handle all built-in Kinds that include a PodTemplateSpec, as described here:
+// https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.15/#pod-v1-core
+//
+// Kinds are matched by group (after normalizing legacy groups), and not
+// version, so that requests sent with matchPolicy: Equivalent are handled
+// consistently. An error with ReasonUnsupportedKind is returned for any other
+// Kind, including Kinds with the same name from other (e.g. CRD) groups: pass it
+// to passThroughUnsupportedKindError to allow them.
+func extractPodTemplate(admissionReview *admission.AdmissionReview) (*podTemplate, error) {
+	return decodePodTemplate(admissionReview, admissionReview.Request.Object.Raw)
+}
+
+// extractOldPodTemplate is extractPodTemplate for the existing object (the
+// OldObject) in an UPDATE request.
+func extractOldPodTemplate(admissionReview *admission.AdmissionReview) (*podTemplate, error) {
+	return decodePodTemplate(admissionReview, admissionReview.Request.OldObject.Raw)
+}
+
+// decodePodTemplate implements extractPodTemplate for the given raw object,
+// which must be of the Kind of the AdmissionReview.
+func decodePodTemplate(admissionReview *admission.AdmissionReview, raw []byte) (*podTemplate, error) {
+	kind := admissionReview.Request.Kind.Kind
+	deserializer := serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
+
+	var (
+		object   metav1.Object
+		template core.PodTemplateSpec
+	)
+
+	// Extract the necessary metadata from our known Kinds
+	switch normalizeGroupKind(admissionReview.Request.Kind) {
+	case core.SchemeGroupVersion.WithKind("Pod").GroupKind():
+		pod := core.Pod{}
+		if _, _, err := deserializer.Decode(raw, nil, &pod); err != nil {
+			return nil, err
+		}
+
+		object = &pod
+		template = core.PodTemplateSpec{ObjectMeta: pod.ObjectMeta, Spec: pod.Spec}
+	case apps.SchemeGroupVersion.WithKind("Deployment").GroupKind():
+		deployment := apps.Deployment{}
+		if _, _, err := deserializer.Decode(raw, nil, &deployment); err != nil {
+			return nil, err
+		}
+
+		object = &deployment
+		template = deployment.Spec.Template
+	case apps.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+		statefulset := apps.StatefulSet{}
+		if _, _, err := deserializer.Decode(raw, nil, &statefulset); err != nil {
+			return nil, err
+		}
+
+		object = &statefulset
+		template = statefulset.Spec.Template
+	case apps.SchemeGroupVersion.WithKind("DaemonSet").GroupKind():
+		daemonset := apps.DaemonSet{}
+		if _, _, err := deserializer.Decode(raw, nil, &daemonset); err != nil {
+			return nil, err
+		}
+
+		object = &daemonset
+		template = daemonset.Spec.Template
+	case batch.SchemeGroupVersion.WithKind("Job").GroupKind():
+		job := batch.Job{}
+		if _, _, err := deserializer.Decode(raw, nil, &job); err != nil {
+			return nil, err
+		}
+
+		object = &job
+		template = job.Spec.Template
+	default:
+		return nil, denyf(ReasonUnsupportedKind, "%s %s", unsupportedKindError, kind)
+	}
+
+	return &podTemplate{
+		namespace:   requestNamespace(admissionReview, object.GetNamespace()),
+		annotations: template.GetAnnotations(),
+		spec:        template.Spec,
+	}, nil
+}
+
+// podSpecPath returns the JSON pointer to the PodSpec of the object in the
+// AdmissionReview - the spec of a Pod, or the Pod template spec of a workload -
+// for use in JSON patches.
+func podSpecPath(admissionReview *admission.AdmissionReview) string {
+	if normalizeGroupKind(admissionReview.Request.Kind) == core.SchemeGroupVersion.WithKind("Pod").GroupKind() {
+		return "/spec"
+	}
+
+	return "/spec/template/spec"
+}
+
+// decodeRawPodSpec decodes the PodSpec of the object in the AdmissionReview - the
+// spec of a Pod, or the Pod template spec of a workload - into spec. This
+// allows AdmitFuncs to inspect PodSpec fields that are newer than the
+// k8s.io/api version we build against, by decoding into a partial struct.
+//
+// The Kind of the object should be validated (e.g. via extractPodTemplate)
+// before calling decodeRawPodSpec.
+func decodeRawPodSpec(admissionReview *admission.AdmissionReview, spec interface{}) error {
+	raw := admissionReview.Request.Object.Raw
+
+	var object struct {
+		Spec json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return err
+	}
+
+	rawSpec := object.Spec
+	if normalizeGroupKind(admissionReview.Request.Kind) != core.SchemeGroupVersion.WithKind("Pod").GroupKind() {
+		var workloadSpec struct {
+			Template struct {
+				Spec json.RawMessage `json:"spec"`
+			} `json:"template"`
+		}
+		if err := json.Unmarshal(object.Spec, &workloadSpec); err != nil {
+			return err
+		}
+
+		rawSpec = workloadSpec.Template.Spec
+	}
+
+	if len(rawSpec) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(rawSpec, spec)
+}
+
+// legacyGroupKinds maps Kinds served from deprecated API groups to the group
+// they are served from today. The object schemas are compatible for the fields
+// we inspect.
+var legacyGroupKinds = map[schema.GroupKind]schema.GroupKind{
+	{Group: "extensions", Kind: "Deployment"}: apps.SchemeGroupVersion.WithKind("Deployment").GroupKind(),
+	{Group: "extensions", Kind: "DaemonSet"}:  apps.SchemeGroupVersion.WithKind("DaemonSet").GroupKind(),
+	{Group: "extensions", Kind: "ReplicaSet"}: apps.SchemeGroupVersion.WithKind("ReplicaSet").GroupKind(),
+	{Group: "extensions", Kind: "Ingress"}:    networking.SchemeGroupVersion.WithKind("Ingress").GroupKind(),
+}
+
+// ExtractUnstructured decodes the object in the AdmissionReview without a typed
+// decoder, so that AdmitFuncs can inspect arbitrary objects - such as custom
+// resources - via the helpers in the unstructured package (e.g.
+// unstructured.NestedString).
+func ExtractUnstructured(admissionReview *admission.AdmissionReview) (*unstructured.Unstructured, error) {
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON(admissionReview.Request.Object.Raw); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// normalizeGroupKind returns the GroupKind of the given GVK, translating Kinds
+// from legacy API groups (e.g. extensions/v1beta1 Deployments) to their current
+// group. Versions (e.g. apps/v1beta2 vs. apps/v1) are discarded.
+func normalizeGroupKind(gvk metav1.GroupVersionKind) schema.GroupKind {
+	groupKind := schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}
+	if normalized, ok := legacyGroupKinds[groupKind]; ok {
+		return normalized
+	}
+
+	return groupKind
+}
+
+// allContainers returns the initContainers and containers of the given PodSpec.
+func allContainers(spec core.PodSpec) []core.Container {
+	containers := make([]core.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	return append(containers, spec.Containers...)
+}
+
+// containsString returns true if s is in the given list. Matching is
+// case-sensitive.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestNamespace returns the namespace of the submitted object, falling back
+// to the namespace of the AdmissionRequest when the object omits it. The
+// apiserver always populates the request namespace for namespaced objects,
+// whereas the object itself may rely on the namespace of the request.
+func requestNamespace(admissionReview *admission.AdmissionReview, objectNamespace string) string {
+	if objectNamespace != "" {
+		return objectNamespace
+	}
+
+	return admissionReview.Request.Namespace
+}
+
+// allowIgnoredNamespace returns an allowed AdmissionResponse (and true) if the
+// given namespace is one of the ignoredNamespaces. Matching is case-sensitive.
+func allowIgnoredNamespace(ignoredNamespaces []string, namespace string) (*admission.AdmissionResponse, bool) {
+	return NamespaceScope{Ignored: ignoredNamespaces}.allow(namespace)
+}
+
 // ensureHasAnnotations checks whether the provided ObjectMeta has the required
 // annotations. It returns both a map of missing annotations, and a boolean
 // value if the meta had all of the provided annotations.
@@ -1,17 +1,33 @@
 package admissioncontrol
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	admission "k8s.io/api/admission/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 var (
@@ -25,10 +41,15 @@ type objectTest struct {
 	cloudProvider       CloudProvider
 	requiredAnnotations map[string]func(string) bool
 	kind                meta.GroupVersionKind
+	namespace           string
+	operation           admission.Operation
+	userInfo            authenticationv1.UserInfo
 	object              interface{}
 	rawObject           []byte
+	oldObject           interface{}
 	ignoredNamespaces   []string
 	expectedMessage     string
+	expectedWarnings    []string
 	shouldAllow         bool
 }
 
@@ -354,10 +375,79 @@ func TestDenyPublicLoadBalancers(t *testing.T) {
 			if resp.Allowed != tt.shouldAllow {
 				t.Fatalf(testErrAdmissionMismatch, tt.kind, resp.Allowed, tt.shouldAllow)
 			}
+
+			// A non-nil, empty expectedWarnings asserts that no warnings were returned.
+			if tt.expectedWarnings != nil && (len(resp.Warnings) > 0 || len(tt.expectedWarnings) > 0) &&
+				!reflect.DeepEqual(resp.Warnings, tt.expectedWarnings) {
+				t.Fatalf("warnings do not match: got %q - expected %q", resp.Warnings, tt.expectedWarnings)
+			}
 		})
 	}
 }
 
+func TestDenyPublicLoadBalancersMultipleProviders(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newLoadBalancer = func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta: meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "hello-service",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Allow GCP internal annotation when configured for GCP & AWS",
+			admitFunc: DenyPublicLoadBalancers(nil, GCP, AWS),
+			kind:      serviceKind,
+			object: newLoadBalancer(map[string]string{
+				"cloud.google.com/load-balancer-type": "Internal",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow AWS internal annotation when configured for GCP & AWS",
+			admitFunc: DenyPublicLoadBalancers(nil, GCP, AWS),
+			kind:      serviceKind,
+			object: newLoadBalancer(map[string]string{
+				"service.beta.kubernetes.io/aws-load-balancer-internal": "0.0.0.0/0",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Reject Azure internal annotation when configured for GCP & AWS",
+			admitFunc: DenyPublicLoadBalancers(nil, GCP, AWS),
+			kind:      serviceKind,
+			object: newLoadBalancer(map[string]string{
+				"service.beta.kubernetes.io/azure-load-balancer-internal": "true",
+			}),
+			expectedMessage: "Service objects of type: LoadBalancer without an internal-only annotation cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject when no providers are configured",
+			admitFunc:       DenyPublicLoadBalancers(nil),
+			kind:            serviceKind,
+			object:          newLoadBalancer(nil),
+			expectedMessage: "at least one provider must be configured to validate internal load balancer annotations",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
 func TestEnforcePodAnnotations(t *testing.T) {
 	t.Parallel()
 
@@ -425,15 +515,15 @@ func TestEnforcePodAnnotations(t *testing.T) {
 			shouldAllow:     true,
 		},
 		{
-			testName: "Unhandled Kinds (Service) are correctly rejected",
+			testName: "Unhandled Kinds (Service) are allowed",
 			kind: meta.GroupVersionKind{
 				Group:   "",
 				Kind:    "Service",
 				Version: "v1",
 			},
 			rawObject:       []byte(`{"kind":"Service","apiVersion":"v1","metadata":{"name":"hello-service","namespace":"default","annotations":{}},"spec":{"ports":[{"protocol":"TCP","port":8000,"targetPort":8080,"nodePort":31433}],"selector":{"app":"hello-app"},"type":"LoadBalancer","externalTrafficPolicy":"Cluster"}}`),
-			expectedMessage: fmt.Sprintf("%s %s", unsupportedKindError, "Service"),
-			shouldAllow:     false,
+			expectedMessage: "",
+			shouldAllow:     true,
 		},
 		{
 			testName: "Allow correctly annotated Pods in a Deployment",
@@ -616,3 +706,5993 @@ func TestEnforcePodAnnotations(t *testing.T) {
 	}
 
 }
+
+// newTestPod returns a Pod in the given namespace with the provided PodSpec.
+func newTestPod(namespace string, spec corev1.PodSpec) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta:   meta.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace},
+		Spec:       spec,
+	}
+}
+
+// runObjectTests runs each objectTest against its admitFunc, and checks both
+// the admission decision and (when rejected) the returned error message.
+func runObjectTests(t *testing.T, tests []objectTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			incomingReview := admission.AdmissionReview{
+				Request: &admission.AdmissionRequest{},
+			}
+
+			incomingReview.Request.Kind = tt.kind
+			incomingReview.Request.Namespace = tt.namespace
+			incomingReview.Request.Operation = tt.operation
+			incomingReview.Request.UserInfo = tt.userInfo
+
+			if tt.rawObject == nil {
+				serialized, err := json.Marshal(tt.object)
+				if err != nil {
+					t.Fatalf("could not marshal k8s API object: %v", err)
+				}
+
+				incomingReview.Request.Object.Raw = serialized
+			} else {
+				incomingReview.Request.Object.Raw = tt.rawObject
+			}
+
+			if tt.oldObject != nil {
+				serialized, err := json.Marshal(tt.oldObject)
+				if err != nil {
+					t.Fatalf("could not marshal k8s API object: %v", err)
+				}
+
+				incomingReview.Request.OldObject.Raw = serialized
+			}
+
+			resp, err := tt.admitFunc(&incomingReview)
+			if err != nil {
+				if tt.expectedMessage != err.Error() {
+					t.Fatalf(testErrMessageMismatch, err.Error(), tt.expectedMessage)
+				}
+
+				if tt.shouldAllow {
+					t.Fatalf("incorrectly rejected admission for Kind: %v: %s", tt.kind, err.Error())
+				}
+
+				t.Logf("correctly rejected admission for Kind: %v: %s", tt.kind, err.Error())
+				return
+			}
+
+			if resp.Allowed != tt.shouldAllow {
+				t.Fatalf(testErrAdmissionMismatch, tt.kind, resp.Allowed, tt.shouldAllow)
+			}
+
+			// A non-nil, empty expectedWarnings asserts that no warnings were returned.
+			if tt.expectedWarnings != nil && (len(resp.Warnings) > 0 || len(tt.expectedWarnings) > 0) &&
+				!reflect.DeepEqual(resp.Warnings, tt.expectedWarnings) {
+				t.Fatalf("warnings do not match: got %q - expected %q", resp.Warnings, tt.expectedWarnings)
+			}
+		})
+	}
+}
+
+func TestDenyClusterAdminBindings(t *testing.T) {
+	t.Parallel()
+
+	var allowAnnotation = "k8s.questionable.services/allow-cluster-admin"
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject ClusterRoleBinding to cluster-admin",
+			admitFunc: DenyClusterAdminBindings(allowAnnotation),
+			kind: meta.GroupVersionKind{
+				Group:   "rbac.authorization.k8s.io",
+				Kind:    "ClusterRoleBinding",
+				Version: "v1",
+			},
+			object: &rbacv1.ClusterRoleBinding{
+				TypeMeta:   meta.TypeMeta{Kind: "ClusterRoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "ci-admin"},
+				Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "ci", Namespace: "default"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+			expectedMessage: "ClusterRoleBinding objects that bind the cluster-admin ClusterRole cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject RoleBinding to cluster-admin",
+			admitFunc: DenyClusterAdminBindings(allowAnnotation),
+			kind: meta.GroupVersionKind{
+				Group:   "rbac.authorization.k8s.io",
+				Kind:    "RoleBinding",
+				Version: "v1",
+			},
+			object: &rbacv1.RoleBinding{
+				TypeMeta:   meta.TypeMeta{Kind: "RoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "ci-admin", Namespace: "default"},
+				Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "ci", Namespace: "default"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+			expectedMessage: "RoleBinding objects that bind the cluster-admin ClusterRole cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow ClusterRoleBinding to view",
+			admitFunc: DenyClusterAdminBindings(allowAnnotation),
+			kind: meta.GroupVersionKind{
+				Group:   "rbac.authorization.k8s.io",
+				Kind:    "ClusterRoleBinding",
+				Version: "v1",
+			},
+			object: &rbacv1.ClusterRoleBinding{
+				TypeMeta:   meta.TypeMeta{Kind: "ClusterRoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "ci-viewer"},
+				Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "ci", Namespace: "default"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"},
+			},
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+		{
+			testName:  "Allow annotated ClusterRoleBinding to cluster-admin",
+			admitFunc: DenyClusterAdminBindings(allowAnnotation),
+			kind: meta.GroupVersionKind{
+				Group:   "rbac.authorization.k8s.io",
+				Kind:    "ClusterRoleBinding",
+				Version: "v1",
+			},
+			object: &rbacv1.ClusterRoleBinding{
+				TypeMeta:   meta.TypeMeta{Kind: "ClusterRoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "break-glass", Annotations: map[string]string{allowAnnotation: "INC-1234"}},
+				Subjects:   []rbacv1.Subject{{Kind: "User", Name: "oncall@example.com"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+		{
+			testName:  "Don't reject Pods",
+			admitFunc: DenyClusterAdminBindings(allowAnnotation),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			rawObject:       nil,
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireLivenessInitialDelay(t *testing.T) {
+	t.Parallel()
+
+	var livenessProbe = func(delay int32) *corev1.Probe {
+		return &corev1.Probe{
+			Handler:             corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"}},
+			InitialDelaySeconds: delay,
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with a liveness probe delay below the minimum",
+			admitFunc: RequireLivenessInitialDelay(nil, 10),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: &corev1.Pod{
+				TypeMeta:   meta.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest", LivenessProbe: livenessProbe(0)}}},
+			},
+			expectedMessage: "the submitted Pods have liveness probes with an initialDelaySeconds below 10: map[nginx:0]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with a liveness probe delay above the minimum",
+			admitFunc: RequireLivenessInitialDelay(nil, 10),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: &corev1.Pod{
+				TypeMeta:   meta.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest", LivenessProbe: livenessProbe(30)}}},
+			},
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+		{
+			testName:  "Allow Pod without a liveness probe",
+			admitFunc: RequireLivenessInitialDelay(nil, 10),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: &corev1.Pod{
+				TypeMeta:   meta.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}}},
+			},
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+		{
+			testName:  "Reject Deployment with a liveness probe delay below the minimum",
+			admitFunc: RequireLivenessInitialDelay(nil, 10),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "Deployment",
+				Version: "v1",
+			},
+			object: &appsv1.Deployment{
+				TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				ObjectMeta: meta.ObjectMeta{Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest", LivenessProbe: livenessProbe(5)}}}}},
+			},
+			expectedMessage: "the submitted Pods have liveness probes with an initialDelaySeconds below 10: map[nginx:5]",
+			shouldAllow:     false,
+		},
+		{
+			testName:          "Allow Deployment in a whitelisted namespace",
+			admitFunc:         RequireLivenessInitialDelay([]string{"kube-system"}, 10),
+			ignoredNamespaces: []string{"kube-system"},
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "Deployment",
+				Version: "v1",
+			},
+			object: &appsv1.Deployment{
+				TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				ObjectMeta: meta.ObjectMeta{Namespace: "kube-system"},
+				Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest", LivenessProbe: livenessProbe(0)}}}}},
+			},
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyPrivilegedContainerPorts(t *testing.T) {
+	t.Parallel()
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with containerPort 80",
+			admitFunc: DenyPrivilegedContainerPorts(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx:latest", Ports: []corev1.ContainerPort{{ContainerPort: 80}}},
+			}}),
+			expectedMessage: "the submitted Pods declare privileged container ports (below 1024): map[nginx:[80]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod with a privileged port on an initContainer",
+			admitFunc: DenyPrivilegedContainerPorts(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "busybox", Ports: []corev1.ContainerPort{{ContainerPort: 443}}}},
+				Containers:     []corev1.Container{{Name: "nginx", Image: "nginx:latest", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}}},
+			}),
+			expectedMessage: "the submitted Pods declare privileged container ports (below 1024): map[init:[443]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with containerPort 8080",
+			admitFunc: DenyPrivilegedContainerPorts(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx:latest", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}},
+			}}),
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+		{
+			testName:  "Allow Pod with containerPort 80 in a whitelisted namespace",
+			admitFunc: DenyPrivilegedContainerPorts([]string{"ingress-nginx"}),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("ingress-nginx", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx:latest", Ports: []corev1.ContainerPort{{ContainerPort: 80}}},
+			}}),
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyDuplicateMountPaths(t *testing.T) {
+	t.Parallel()
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with duplicate /data mounts",
+			admitFunc: DenyDuplicateMountPaths(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", VolumeMounts: []corev1.VolumeMount{
+					{Name: "data", MountPath: "/data"},
+					{Name: "cache", MountPath: "/data"},
+				}},
+			}}),
+			expectedMessage: "the submitted Pods have containers with duplicate volumeMount paths: map[app:[/data]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod with duplicate mounts on an initContainer",
+			admitFunc: DenyDuplicateMountPaths(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "migrate", Image: "app:v1", VolumeMounts: []corev1.VolumeMount{
+						{Name: "data", MountPath: "/data"},
+						{Name: "cache", MountPath: "/data"},
+						{Name: "scratch", MountPath: "/data"},
+					}},
+				},
+				Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			}),
+			expectedMessage: "the submitted Pods have containers with duplicate volumeMount paths: map[migrate:[/data]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with unique mounts",
+			admitFunc: DenyDuplicateMountPaths(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", VolumeMounts: []corev1.VolumeMount{
+					{Name: "data", MountPath: "/data"},
+					{Name: "cache", MountPath: "/cache"},
+				}},
+				// The same path in a different container is not a conflict.
+				{Name: "sidecar", Image: "sidecar:v1", VolumeMounts: []corev1.VolumeMount{
+					{Name: "data", MountPath: "/data"},
+				}},
+			}}),
+			expectedMessage: "",
+			shouldAllow:     true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+// fakeRegistryClient is a RegistryClient that serves architectures from a map
+// of image references.
+type fakeRegistryClient map[string][]string
+
+func (fr fakeRegistryClient) Architectures(_ context.Context, image string) ([]string, error) {
+	architectures, ok := fr[image]
+	if !ok {
+		return nil, errors.New("manifest unknown")
+	}
+
+	return architectures, nil
+}
+
+func TestWarnImageArchitecture(t *testing.T) {
+	t.Parallel()
+
+	var registry = fakeRegistryClient{
+		"example.com/amd64-only:v1": {"amd64"},
+		"example.com/multi-arch:v1": {"amd64", "arm64"},
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on a single-arch image that doesn't support the cluster",
+			admitFunc: WarnImageArchitecture("arm64", registry),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "example.com/amd64-only:v1"},
+			}}),
+			expectedWarnings: []string{
+				`image "example.com/amd64-only:v1" (container "app") does not support the arm64 architecture of this cluster: [amd64]`,
+			},
+			shouldAllow: true,
+		},
+		{
+			testName:  "Warn when an image cannot be inspected",
+			admitFunc: WarnImageArchitecture("arm64", registry),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "example.com/missing:v1"},
+			}}),
+			expectedWarnings: []string{
+				`could not verify that image "example.com/missing:v1" (container "app") supports arm64: manifest unknown`,
+			},
+			shouldAllow: true,
+		},
+		{
+			testName:  "Don't warn on a multi-arch image",
+			admitFunc: WarnImageArchitecture("arm64", registry),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "example.com/multi-arch:v1"},
+			}}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Reject when no RegistryClient is provided",
+			admitFunc: WarnImageArchitecture("arm64", nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "example.com/multi-arch:v1"},
+			}}),
+			expectedMessage: "a RegistryClient must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateLocalTrafficPolicy(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newService = func(spec corev1.ServiceSpec) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+			Spec:       spec,
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Local Service whose healthCheckNodePort conflicts with a nodePort",
+			admitFunc: ValidateLocalTrafficPolicy(nil),
+			kind:      serviceKind,
+			object: newService(corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				HealthCheckNodePort:   31433,
+				Selector:              map[string]string{"app": "hello-app"},
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 31433}},
+			}),
+			expectedMessage: `Service objects with externalTrafficPolicy: Local cannot use the healthCheckNodePort (31433) as the nodePort of port "http"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Cluster Service with a healthCheckNodePort",
+			admitFunc: ValidateLocalTrafficPolicy(nil),
+			kind:      serviceKind,
+			object: newService(corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+				HealthCheckNodePort:   32000,
+				Selector:              map[string]string{"app": "hello-app"},
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80}},
+			}),
+			expectedMessage: "Service objects may only set a healthCheckNodePort (32000) with externalTrafficPolicy: Local",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Warn on Local Service without a selector",
+			admitFunc: ValidateLocalTrafficPolicy(nil),
+			kind:      serviceKind,
+			object: newService(corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80}},
+			}),
+			expectedWarnings: []string{
+				`Service "hello-service" has externalTrafficPolicy: Local but no selector: load balancer health checks will fail unless its Endpoints are managed manually`,
+			},
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow correctly configured Local Service",
+			admitFunc: ValidateLocalTrafficPolicy(nil),
+			kind:      serviceKind,
+			object: newService(corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				HealthCheckNodePort:   32000,
+				Selector:              map[string]string{"app": "hello-app"},
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 31433}},
+			}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Don't reject ClusterIP Services",
+			admitFunc: ValidateLocalTrafficPolicy(nil),
+			kind:      serviceKind,
+			object: newService(corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+// TestRequestNamespaceFallback validates that AdmitFuncs fall back to the
+// namespace of the AdmissionRequest when the submitted object omits one.
+func TestRequestNamespaceFallback(t *testing.T) {
+	t.Parallel()
+
+	var ignoredNamespaces = []string{"istio-system"}
+	var denyTests = []objectTest{
+		{
+			testName:  "DenyIngresses allows an Ingress in a whitelisted request namespace",
+			admitFunc: DenyIngresses(ignoredNamespaces),
+			kind: meta.GroupVersionKind{
+				Group:   "extensions",
+				Kind:    "Ingress",
+				Version: "v1beta1",
+			},
+			namespace:   "istio-system",
+			rawObject:   []byte(`{"kind":"Ingress","apiVersion":"extensions/v1beta1","metadata":{"name":"hello-ingress"},"spec":{"rules":[]}}`),
+			shouldAllow: true,
+		},
+		{
+			testName:  "DenyIngresses rejects an Ingress in another request namespace",
+			admitFunc: DenyIngresses(ignoredNamespaces),
+			kind: meta.GroupVersionKind{
+				Group:   "extensions",
+				Kind:    "Ingress",
+				Version: "v1beta1",
+			},
+			namespace:       "default",
+			rawObject:       []byte(`{"kind":"Ingress","apiVersion":"extensions/v1beta1","metadata":{"name":"hello-ingress"},"spec":{"rules":[]}}`),
+			expectedMessage: "Ingress objects cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "DenyPublicLoadBalancers allows a Service in a whitelisted request namespace",
+			admitFunc: DenyPublicLoadBalancers(ignoredNamespaces, GCP),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Service",
+				Version: "v1",
+			},
+			namespace:   "istio-system",
+			rawObject:   []byte(`{"kind":"Service","apiVersion":"v1","metadata":{"name":"hello-service"},"spec":{"ports":[{"port":80}],"type":"LoadBalancer"}}`),
+			shouldAllow: true,
+		},
+		{
+			testName: "EnforcePodAnnotations allows a Pod in a whitelisted request namespace",
+			admitFunc: EnforcePodAnnotations(ignoredNamespaces, map[string]func(string) bool{
+				"buildVersion": func(s string) bool { return true },
+			}),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			namespace:   "istio-system",
+			object:      newTestPod("", corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}}}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "DenyPrivilegedContainerPorts allows a Deployment in a whitelisted request namespace",
+			admitFunc: DenyPrivilegedContainerPorts(ignoredNamespaces),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "Deployment",
+				Version: "v1",
+			},
+			namespace: "istio-system",
+			object: &appsv1.Deployment{
+				TypeMeta: meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "nginx", Image: "nginx:latest", Ports: []corev1.ContainerPort{{ContainerPort: 80}}},
+				}}}},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforceContainerNames(t *testing.T) {
+	t.Parallel()
+
+	var pattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with an underscore in a container name",
+			admitFunc: EnforceContainerNames(nil, pattern),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			rawObject:       []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"hello-app","namespace":"default"},"spec":{"containers":[{"name":"web_server","image":"nginx:latest"}]}}`),
+			expectedMessage: `the submitted Pods have containers with names that do not match "^[a-z0-9-]+$": [web_server]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod with an invalid initContainer name",
+			admitFunc: EnforceContainerNames(nil, pattern),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "Migrate", Image: "app:v1"}},
+				Containers:     []corev1.Container{{Name: "web-server", Image: "nginx:latest"}},
+			}),
+			expectedMessage: `the submitted Pods have containers with names that do not match "^[a-z0-9-]+$": [Migrate]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with conforming container names",
+			admitFunc: EnforceContainerNames(nil, pattern),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate", Image: "app:v1"}},
+				Containers:     []corev1.Container{{Name: "web-server", Image: "nginx:latest"}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Reject with a nil pattern",
+			admitFunc: EnforceContainerNames(nil, nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "web-server", Image: "nginx:latest"}},
+			}),
+			expectedMessage: "cannot validate container names with a nil pattern",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyAllowAllNetworkPolicies(t *testing.T) {
+	t.Parallel()
+
+	var networkPolicyKind = meta.GroupVersionKind{
+		Group:   "networking.k8s.io",
+		Kind:    "NetworkPolicy",
+		Version: "v1",
+	}
+
+	var newNetworkPolicy = func(namespace string, spec networkingv1.NetworkPolicySpec) *networkingv1.NetworkPolicy {
+		return &networkingv1.NetworkPolicy{
+			TypeMeta:   meta.TypeMeta{Kind: "NetworkPolicy", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-policy", Namespace: namespace},
+			Spec:       spec,
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject allow-all ingress policy",
+			admitFunc: DenyAllowAllNetworkPolicies(nil),
+			kind:      networkPolicyKind,
+			object: newNetworkPolicy("default", networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+			}),
+			expectedMessage: "NetworkPolicy objects that allow all [Ingress] traffic to all Pods in a namespace cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject policy allowing egress to all namespaces & ingress from 0.0.0.0/0",
+			admitFunc: DenyAllowAllNetworkPolicies(nil),
+			kind:      networkPolicyKind,
+			object: newNetworkPolicy("default", networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0"}}},
+				}},
+				Egress: []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &meta.LabelSelector{}}},
+				}},
+			}),
+			expectedMessage: "NetworkPolicy objects that allow all [Ingress Egress] traffic to all Pods in a namespace cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow policy scoped to specific Pods",
+			admitFunc: DenyAllowAllNetworkPolicies(nil),
+			kind:      networkPolicyKind,
+			object: newNetworkPolicy("default", networkingv1.NetworkPolicySpec{
+				PodSelector: meta.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow namespace-wide policy with scoped peers",
+			admitFunc: DenyAllowAllNetworkPolicies(nil),
+			kind:      networkPolicyKind,
+			object: newNetworkPolicy("default", networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{PodSelector: &meta.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}}},
+				}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow default-deny policy",
+			admitFunc: DenyAllowAllNetworkPolicies(nil),
+			kind:      networkPolicyKind,
+			object: newNetworkPolicy("default", networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow allow-all policy in a whitelisted namespace",
+			admitFunc: DenyAllowAllNetworkPolicies([]string{"kube-system"}),
+			kind:      networkPolicyKind,
+			object: newNetworkPolicy("kube-system", networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyCrossNamespaceSelectorOverlap(t *testing.T) {
+	t.Parallel()
+
+	var protectedLabels = map[string]string{
+		"k8s-app":   "kube-dns",
+		"component": "kube-apiserver",
+	}
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newService = func(namespace string, selector map[string]string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Spec:       corev1.ServiceSpec{Selector: selector, Ports: []corev1.ServicePort{{Port: 53}}},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Service selecting k8s-app: kube-dns",
+			admitFunc:       DenyCrossNamespaceSelectorOverlap(protectedLabels),
+			kind:            serviceKind,
+			object:          newService("tenant-a", map[string]string{"k8s-app": "kube-dns"}),
+			expectedMessage: "Service objects with a selector matching protected system labels cannot be deployed to this cluster: map[k8s-app:kube-dns]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Service with a benign selector",
+			admitFunc:   DenyCrossNamespaceSelectorOverlap(protectedLabels),
+			kind:        serviceKind,
+			object:      newService("tenant-a", map[string]string{"app": "hello-app"}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Service with a protected key but different value",
+			admitFunc:   DenyCrossNamespaceSelectorOverlap(protectedLabels),
+			kind:        serviceKind,
+			object:      newService("tenant-a", map[string]string{"k8s-app": "hello-app"}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow kube-system Service selecting k8s-app: kube-dns",
+			admitFunc:   DenyCrossNamespaceSelectorOverlap(protectedLabels),
+			kind:        serviceKind,
+			object:      newService("kube-system", map[string]string{"k8s-app": "kube-dns"}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestWarnInteractiveContainers(t *testing.T) {
+	t.Parallel()
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on tty: true, stdin: false",
+			admitFunc: WarnInteractiveContainers(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "debug", Image: "busybox", TTY: true, Stdin: false},
+			}}),
+			expectedWarnings: []string{`container "debug" sets tty: true without stdin: true`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Don't warn on interactive containers",
+			admitFunc: WarnInteractiveContainers(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "shell", Image: "busybox", TTY: true, Stdin: true},
+				{Name: "app", Image: "app:v1"},
+			}}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Don't warn in a whitelisted namespace",
+			admitFunc: WarnInteractiveContainers([]string{"debug"}),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("debug", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "debug", Image: "busybox", TTY: true},
+			}}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforceRestrictedProfile(t *testing.T) {
+	t.Parallel()
+
+	var (
+		enabled  = true
+		disabled = false
+	)
+
+	var restrictedContext = &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &disabled,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+
+	var restrictedPodContext = &corev1.PodSecurityContext{
+		RunAsNonRoot:   &enabled,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod violating three checks, and report all of them",
+			admitFunc: EnforceRestrictedProfile(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				HostPID:         true,
+				SecurityContext: restrictedPodContext,
+				Containers: []corev1.Container{{
+					Name:  "app",
+					Image: "app:v1",
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &enabled,
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW"}},
+					},
+				}},
+			}),
+			expectedMessage: `the submitted Pods violate the restricted Pod Security Standard: hostPID must not be set; container "app" must set allowPrivilegeEscalation: false; container "app" must drop ALL capabilities`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject container overriding runAsNonRoot & seccomp",
+			admitFunc: EnforceRestrictedProfile(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				SecurityContext: restrictedPodContext,
+				InitContainers: []corev1.Container{{
+					Name:  "init",
+					Image: "app:v1",
+					SecurityContext: &corev1.SecurityContext{
+						RunAsNonRoot:             &disabled,
+						AllowPrivilegeEscalation: &disabled,
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+						SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+					},
+				}},
+				Containers: []corev1.Container{{Name: "app", Image: "app:v1", SecurityContext: restrictedContext}},
+			}),
+			expectedMessage: `the submitted Pods violate the restricted Pod Security Standard: container "init" must set runAsNonRoot: true; container "init" must use a RuntimeDefault or Localhost seccompProfile`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow restricted Pod",
+			admitFunc: EnforceRestrictedProfile(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("default", corev1.PodSpec{
+				SecurityContext: restrictedPodContext,
+				Containers:      []corev1.Container{{Name: "app", Image: "app:v1", SecurityContext: restrictedContext}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow privileged Pod in a whitelisted namespace",
+			admitFunc: EnforceRestrictedProfile([]string{"kube-system"}),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object: newTestPod("kube-system", corev1.PodSpec{
+				HostNetwork: true,
+				Containers:  []corev1.Container{{Name: "kube-proxy", Image: "kube-proxy:v1.21.0"}},
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyBroadPDB(t *testing.T) {
+	t.Parallel()
+
+	var pdbKind = meta.GroupVersionKind{
+		Group:   "policy",
+		Kind:    "PodDisruptionBudget",
+		Version: "v1",
+	}
+
+	var newPDB = func(namespace string, selector *meta.LabelSelector) *policyv1.PodDisruptionBudget {
+		minAvailable := intstr.FromInt(1)
+		return &policyv1.PodDisruptionBudget{
+			TypeMeta:   meta.TypeMeta{Kind: "PodDisruptionBudget", APIVersion: "policy/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-pdb", Namespace: namespace},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     selector,
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject PDB with an empty selector",
+			admitFunc:       DenyBroadPDB(nil),
+			kind:            pdbKind,
+			object:          newPDB("default", &meta.LabelSelector{}),
+			expectedMessage: "PodDisruptionBudget objects with an empty selector match all Pods in a namespace, and cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject PDB with a nil selector",
+			admitFunc:       DenyBroadPDB(nil),
+			kind:            pdbKind,
+			object:          newPDB("default", nil),
+			expectedMessage: "PodDisruptionBudget objects with an empty selector match all Pods in a namespace, and cannot be deployed to this cluster",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow PDB with a scoped selector",
+			admitFunc:   DenyBroadPDB(nil),
+			kind:        pdbKind,
+			object:      newPDB("default", &meta.LabelSelector{MatchLabels: map[string]string{"app": "hello-app"}}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow PDB with an empty selector in a whitelisted namespace",
+			admitFunc:   DenyBroadPDB([]string{"kube-system"}),
+			kind:        pdbKind,
+			object:      newPDB("kube-system", &meta.LabelSelector{}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow unrelated kinds",
+			admitFunc: DenyBroadPDB(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object:      newTestPod("default", corev1.PodSpec{}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyDefaultServiceAccountToken(t *testing.T) {
+	t.Parallel()
+
+	var disabled = false
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var projectedToken = corev1.Volume{
+		Name: "token",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"},
+				}},
+			},
+		},
+	}
+
+	var containers = []corev1.Container{{Name: "app", Image: "app:v1"}}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod projecting the default ServiceAccount token",
+			admitFunc: DenyDefaultServiceAccountToken(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				AutomountServiceAccountToken: &disabled,
+				Volumes:                      []corev1.Volume{projectedToken},
+				Containers:                   containers,
+			}),
+			expectedMessage: `the submitted Pods mount the token of the default ServiceAccount: [projected volume "token"]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod automounting the default ServiceAccount token",
+			admitFunc: DenyDefaultServiceAccountToken(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				ServiceAccountName: "default",
+				Containers:         containers,
+			}),
+			expectedMessage: "the submitted Pods mount the token of the default ServiceAccount: [automountServiceAccountToken]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod projecting a named ServiceAccount token",
+			admitFunc: DenyDefaultServiceAccountToken(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				ServiceAccountName: "hello-app",
+				Volumes:            []corev1.Volume{projectedToken},
+				Containers:         containers,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod that disables automounting the default token",
+			admitFunc: DenyDefaultServiceAccountToken(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				AutomountServiceAccountToken: &disabled,
+				Containers:                   containers,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod projecting the default token in a whitelisted namespace",
+			admitFunc: DenyDefaultServiceAccountToken([]string{"kube-system"}),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{
+				Volumes:    []corev1.Volume{projectedToken},
+				Containers: containers,
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireTerminationGracePeriodBounds(t *testing.T) {
+	t.Parallel()
+
+	var (
+		zero int64
+		ten  int64 = 10
+		day  int64 = 86400
+	)
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var containers = []corev1.Container{{Name: "app", Image: "app:v1"}}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with a grace period of 0",
+			admitFunc: RequireTerminationGracePeriodBounds(nil, 5, 300),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				TerminationGracePeriodSeconds: &zero,
+				Containers:                    containers,
+			}),
+			expectedMessage: "the submitted Pods have a terminationGracePeriodSeconds of 0, outside of the allowed range of 5 to 300",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod with a grace period above the max",
+			admitFunc: RequireTerminationGracePeriodBounds(nil, 5, 300),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				TerminationGracePeriodSeconds: &day,
+				Containers:                    containers,
+			}),
+			expectedMessage: "the submitted Pods have a terminationGracePeriodSeconds of 86400, outside of the allowed range of 5 to 300",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod using the default grace period when min is above it",
+			admitFunc: RequireTerminationGracePeriodBounds(nil, 60, 300),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: containers,
+			}),
+			expectedMessage: "the submitted Pods have a terminationGracePeriodSeconds of 30, outside of the allowed range of 60 to 300",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with a grace period within bounds",
+			admitFunc: RequireTerminationGracePeriodBounds(nil, 5, 300),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				TerminationGracePeriodSeconds: &ten,
+				Containers:                    containers,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod using the default grace period",
+			admitFunc: RequireTerminationGracePeriodBounds(nil, 5, 300),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: containers,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod with a grace period of 0 in a whitelisted namespace",
+			admitFunc: RequireTerminationGracePeriodBounds([]string{"kube-system"}, 5, 300),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{
+				TerminationGracePeriodSeconds: &zero,
+				Containers:                    containers,
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenySidecarInjectionOptOut(t *testing.T) {
+	t.Parallel()
+
+	var optOutAnnotations = map[string]string{
+		"sidecar.istio.io/inject": "false",
+		"linkerd.io/inject":       "disabled",
+	}
+
+	var newAnnotatedPod = func(namespace string, annotations map[string]string) *corev1.Pod {
+		pod := newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+		})
+		pod.Annotations = annotations
+		return pod
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod opting out of injection",
+			admitFunc: DenySidecarInjectionOptOut(nil, optOutAnnotations),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object:          newAnnotatedPod("default", map[string]string{"sidecar.istio.io/inject": "false"}),
+			expectedMessage: "the submitted Pods opt out of sidecar injection, which is required in this cluster: map[sidecar.istio.io/inject:false]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Deployment opting out of injection in its Pod template",
+			admitFunc: DenySidecarInjectionOptOut(nil, map[string]string{"linkerd.io/inject": ""}),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "Deployment",
+				Version: "v1",
+			},
+			object: &appsv1.Deployment{
+				TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+					ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{"linkerd.io/inject": "disabled"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+				}},
+			},
+			expectedMessage: "the submitted Pods opt out of sidecar injection, which is required in this cluster: map[linkerd.io/inject:disabled]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod without an opt-out annotation",
+			admitFunc: DenySidecarInjectionOptOut(nil, optOutAnnotations),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object:      newAnnotatedPod("default", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod explicitly opting in to injection",
+			admitFunc: DenySidecarInjectionOptOut(nil, optOutAnnotations),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object:      newAnnotatedPod("default", map[string]string{"sidecar.istio.io/inject": "true"}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod opting out of injection in a whitelisted namespace",
+			admitFunc: DenySidecarInjectionOptOut([]string{"istio-system"}, optOutAnnotations),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			object:      newAnnotatedPod("istio-system", map[string]string{"sidecar.istio.io/inject": "false"}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyNonFQDNExternalNames(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newExternalNameService = func(namespace string, externalName string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: externalName,
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a short externalName",
+			admitFunc:       DenyNonFQDNExternalNames(nil),
+			kind:            serviceKind,
+			object:          newExternalNameService("default", "db"),
+			expectedMessage: `Service objects must set an externalName that is a fully-qualified domain name: "db"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject an IP address externalName",
+			admitFunc:       DenyNonFQDNExternalNames(nil),
+			kind:            serviceKind,
+			object:          newExternalNameService("default", "10.0.0.1"),
+			expectedMessage: `Service objects must set an externalName that is a fully-qualified domain name: "10.0.0.1"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a fully-qualified externalName",
+			admitFunc:   DenyNonFQDNExternalNames(nil),
+			kind:        serviceKind,
+			object:      newExternalNameService("default", "db.example.com"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a mixed-case fully-qualified externalName",
+			admitFunc:   DenyNonFQDNExternalNames(nil),
+			kind:        serviceKind,
+			object:      newExternalNameService("default", "DB.Example.com"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a fully-qualified externalName with a trailing dot",
+			admitFunc:   DenyNonFQDNExternalNames(nil),
+			kind:        serviceKind,
+			object:      newExternalNameService("default", "db.example.com."),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a short externalName in a whitelisted namespace",
+			admitFunc:   DenyNonFQDNExternalNames([]string{"legacy"}),
+			kind:        serviceKind,
+			object:      newExternalNameService("legacy", "db"),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Services of other types",
+			admitFunc: DenyNonFQDNExternalNames(nil),
+			kind:      serviceKind,
+			object: &corev1.Service{
+				TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEquivalentKindNormalization(t *testing.T) {
+	t.Parallel()
+
+	requiredAnnotations := map[string]func(string) bool{
+		"buildVersion": func(s string) bool { return s != "" },
+	}
+
+	var newDeployment = func(annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{Annotations: annotations},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}}},
+			}},
+		}
+	}
+
+	var tests []objectTest
+	for _, gv := range []meta.GroupVersion{
+		{Group: "apps", Version: "v1"},
+		{Group: "apps", Version: "v1beta2"},
+		{Group: "extensions", Version: "v1beta1"},
+	} {
+		kind := meta.GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: "Deployment"}
+		tests = append(tests,
+			objectTest{
+				testName:    fmt.Sprintf("Allow annotated Deployment under %s/%s", gv.Group, gv.Version),
+				admitFunc:   EnforcePodAnnotations(nil, requiredAnnotations),
+				kind:        kind,
+				object:      newDeployment(map[string]string{"buildVersion": "v1.0.0"}),
+				shouldAllow: true,
+			},
+			objectTest{
+				testName:        fmt.Sprintf("Reject unannotated Deployment under %s/%s", gv.Group, gv.Version),
+				admitFunc:       EnforcePodAnnotations(nil, requiredAnnotations),
+				kind:            kind,
+				object:          newDeployment(nil),
+				expectedMessage: "the submitted Pods are missing required annotations: map[buildVersion:key was not found]",
+				shouldAllow:     false,
+			},
+		)
+	}
+
+	tests = append(tests, objectTest{
+		testName:  "Pass through a Deployment Kind from an unrelated group",
+		admitFunc: EnforcePodAnnotations(nil, requiredAnnotations),
+		kind: meta.GroupVersionKind{
+			Group:   "example.com",
+			Kind:    "Deployment",
+			Version: "v1",
+		},
+		object:      newDeployment(nil),
+		shouldAllow: true,
+	})
+
+	runObjectTests(t, tests)
+}
+
+func TestWarnLongPreStopHooks(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newPreStopContainer = func(name string, command ...string) corev1.Container {
+		return corev1.Container{
+			Name:  name,
+			Image: "app:v1",
+			Lifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.Handler{Exec: &corev1.ExecAction{Command: command}},
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on sleep 3600 with a max of 60",
+			admitFunc: WarnLongPreStopHooks(nil, 60),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				newPreStopContainer("app", "sleep", "3600"),
+			}}),
+			expectedWarnings: []string{`container "app" has a preStop hook that sleeps for 3600s, which exceeds the maximum of 60s`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Warn on a long sleep within a shell command",
+			admitFunc: WarnLongPreStopHooks(nil, 60),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				newPreStopContainer("nginx", "/bin/sh", "-c", "/bin/sleep 2m && nginx -s quit"),
+			}}),
+			expectedWarnings: []string{`container "nginx" has a preStop hook that sleeps for 120s, which exceeds the maximum of 60s`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Don't warn on a short sleep, or other preStop hooks",
+			admitFunc: WarnLongPreStopHooks(nil, 60),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				newPreStopContainer("app", "sleep", "5"),
+				newPreStopContainer("nginx", "nginx", "-s", "quit"),
+				{Name: "sidecar", Image: "sidecar:v1"},
+			}}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Don't warn in a whitelisted namespace",
+			admitFunc: WarnLongPreStopHooks([]string{"kube-system"}, 60),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{Containers: []corev1.Container{
+				newPreStopContainer("app", "sleep", "3600"),
+			}}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRestrictSecretReferences(t *testing.T) {
+	t.Parallel()
+
+	var pattern = regexp.MustCompile(`^hello-app-`)
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newSecretPod = func(namespace string, envFromSecret, envSecret, volumeSecret string) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:v1",
+				EnvFrom: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: envFromSecret}},
+				}},
+				Env: []corev1.EnvVar{{
+					Name: "DB_PASSWORD",
+					ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: envSecret},
+						Key:                  "password",
+					}},
+				}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: volumeSecret}},
+			}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Pod referencing disallowed Secrets",
+			admitFunc:       RestrictSecretReferences(nil, pattern),
+			kind:            podKind,
+			object:          newSecretPod("default", "hello-app-config", "db-credentials", "wildcard-tls"),
+			expectedMessage: `the submitted Pods reference Secrets with names that do not match "^hello-app-": [db-credentials wildcard-tls]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod referencing conforming Secrets",
+			admitFunc:   RestrictSecretReferences(nil, pattern),
+			kind:        podKind,
+			object:      newSecretPod("default", "hello-app-config", "hello-app-db", "hello-app-tls"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod referencing disallowed Secrets in a whitelisted namespace",
+			admitFunc:   RestrictSecretReferences([]string{"kube-system"}, pattern),
+			kind:        podKind,
+			object:      newSecretPod("kube-system", "config", "db-credentials", "wildcard-tls"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject all Pods with a nil pattern",
+			admitFunc:       RestrictSecretReferences(nil, nil),
+			kind:            podKind,
+			object:          newSecretPod("default", "hello-app-config", "hello-app-db", "hello-app-tls"),
+			expectedMessage: "cannot validate Secret references with a nil pattern",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireFsGroup(t *testing.T) {
+	t.Parallel()
+
+	var fsGroup int64 = 2000
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var pvcVolume = corev1.Volume{
+		Name: "shared",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-data"},
+		},
+	}
+
+	var containers = []corev1.Container{{Name: "app", Image: "app:v1"}}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject PVC-mounting Pod without fsGroup",
+			admitFunc: RequireFsGroup(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Volumes:    []corev1.Volume{pvcVolume},
+				Containers: containers,
+			}),
+			expectedMessage: "the submitted Pods mount PersistentVolumeClaims [shared-data] without setting securityContext.fsGroup",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow PVC-mounting Pod with fsGroup",
+			admitFunc: RequireFsGroup(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{FSGroup: &fsGroup},
+				Volumes:         []corev1.Volume{pvcVolume},
+				Containers:      containers,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod without PVCs",
+			admitFunc: RequireFsGroup(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name:         "scratch",
+					VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				}},
+				Containers: containers,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow PVC-mounting Pod without fsGroup in a whitelisted namespace",
+			admitFunc: RequireFsGroup([]string{"kube-system"}),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{
+				Volumes:    []corev1.Volume{pvcVolume},
+				Containers: containers,
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireResourceClaims(t *testing.T) {
+	t.Parallel()
+
+	var gpuResourceNames = []string{"nvidia.com/gpu"}
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject GPU-requesting Pod without a claim",
+			admitFunc: RequireResourceClaims(nil, gpuResourceNames),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "trainer",
+				Image: "trainer:v1",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}}}),
+			expectedMessage: "the submitted Pods have containers that request accelerators without a resource claim: map[trainer:[nvidia.com/gpu]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject GPU-requesting Pod referencing an undeclared claim",
+			admitFunc: RequireResourceClaims(nil, gpuResourceNames),
+			kind:      podKind,
+			rawObject: []byte(`{
+				"apiVersion": "v1",
+				"kind": "Pod",
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {
+					"containers": [{
+						"name": "trainer",
+						"image": "trainer:v1",
+						"resources": {"limits": {"nvidia.com/gpu": "1"}, "claims": [{"name": "gpu"}]}
+					}]
+				}
+			}`),
+			expectedMessage: "the submitted Pods have containers that request accelerators without a resource claim: map[trainer:[nvidia.com/gpu]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow GPU-requesting Pod with a claim",
+			admitFunc: RequireResourceClaims(nil, gpuResourceNames),
+			kind:      podKind,
+			rawObject: []byte(`{
+				"apiVersion": "v1",
+				"kind": "Pod",
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {
+					"resourceClaims": [{"name": "gpu", "source": {"resourceClaimTemplateName": "single-gpu"}}],
+					"containers": [{
+						"name": "trainer",
+						"image": "trainer:v1",
+						"resources": {"limits": {"nvidia.com/gpu": "1"}, "claims": [{"name": "gpu"}]}
+					}]
+				}
+			}`),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow GPU-requesting Job with a claim in its Pod template",
+			admitFunc: RequireResourceClaims(nil, gpuResourceNames),
+			kind: meta.GroupVersionKind{
+				Group:   "batch",
+				Kind:    "Job",
+				Version: "v1",
+			},
+			rawObject: []byte(`{
+				"apiVersion": "batch/v1",
+				"kind": "Job",
+				"metadata": {"name": "hello-job", "namespace": "default"},
+				"spec": {"template": {"spec": {
+					"resourceClaims": [{"name": "gpu", "source": {"resourceClaimTemplateName": "single-gpu"}}],
+					"containers": [{
+						"name": "trainer",
+						"image": "trainer:v1",
+						"resources": {"limits": {"nvidia.com/gpu": "1"}, "claims": [{"name": "gpu"}]}
+					}]
+				}}}
+			}`),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod without accelerators",
+			admitFunc: RequireResourceClaims(nil, gpuResourceNames),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:v1",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			}}}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforceImageDigest(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pinned   = "nginx@sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac"
+		unpinned = "nginx:latest"
+		prod     = []string{"prod"}
+	)
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newImagePod = func(namespace string, image string) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Image: pinned}},
+			Containers:     []corev1.Container{{Name: "nginx", Image: image}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject unpinned image in an enforced namespace",
+			admitFunc:       EnforceImageDigest(nil, prod),
+			kind:            podKind,
+			object:          newImagePod("prod", unpinned),
+			expectedMessage: "the submitted Pods have images that are not pinned to a digest: [nginx:latest]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow pinned image in an enforced namespace",
+			admitFunc:   EnforceImageDigest(nil, prod),
+			kind:        podKind,
+			object:      newImagePod("prod", pinned),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow unpinned image outside of the enforced namespaces",
+			admitFunc:   EnforceImageDigest(nil, prod),
+			kind:        podKind,
+			object:      newImagePod("staging", unpinned),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject unpinned image in any namespace when none are enforced",
+			admitFunc:       EnforceImageDigest(nil, nil),
+			kind:            podKind,
+			object:          newImagePod("staging", unpinned),
+			expectedMessage: "the submitted Pods have images that are not pinned to a digest: [nginx:latest]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow unpinned image in a whitelisted namespace",
+			admitFunc:   EnforceImageDigest([]string{"kube-system"}, nil),
+			kind:        podKind,
+			object:      newImagePod("kube-system", unpinned),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireRunAsUserInRange(t *testing.T) {
+	t.Parallel()
+
+	var (
+		inRange    int64 = 10500
+		outOfRange int64 = 20500
+		root       int64
+	)
+
+	var ranges = map[string][2]int64{
+		"tenant-a": {10000, 19999},
+	}
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod running as an out-of-range uid",
+			admitFunc: RequireRunAsUserInRange(ranges),
+			kind:      podKind,
+			object: newTestPod("tenant-a", corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: &outOfRange},
+				Containers:      []corev1.Container{{Name: "app", Image: "app:v1"}},
+			}),
+			expectedMessage: `the submitted Pods must run as a uid in the range 10000-19999 assigned to the tenant-a namespace: container "app" runs as uid 20500`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject container overriding the Pod uid, and one without a uid",
+			admitFunc: RequireRunAsUserInRange(ranges),
+			kind:      podKind,
+			object: newTestPod("tenant-a", corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "app:v1"}},
+				Containers: []corev1.Container{{
+					Name:            "app",
+					Image:           "app:v1",
+					SecurityContext: &corev1.SecurityContext{RunAsUser: &root},
+				}},
+			}),
+			expectedMessage: `the submitted Pods must run as a uid in the range 10000-19999 assigned to the tenant-a namespace: container "init" does not set runAsUser; container "app" runs as uid 0`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod running as an in-range uid",
+			admitFunc: RequireRunAsUserInRange(ranges),
+			kind:      podKind,
+			object: newTestPod("tenant-a", corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: &outOfRange},
+				Containers: []corev1.Container{{
+					Name:            "app",
+					Image:           "app:v1",
+					SecurityContext: &corev1.SecurityContext{RunAsUser: &inRange},
+				}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod in a namespace without an assigned range",
+			admitFunc: RequireRunAsUserInRange(ranges),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyClusterScopedCreation(t *testing.T) {
+	t.Parallel()
+
+	var crdKind = meta.GroupVersionKind{
+		Group:   "apiextensions.k8s.io",
+		Kind:    "CustomResourceDefinition",
+		Version: "v1",
+	}
+
+	var crd = map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "widgets.example.com"},
+	}
+
+	var allowedGroups = []string{"system:masters", "platform-admins"}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject CRD creation by an unauthorized user",
+			admitFunc: DenyClusterScopedCreation(allowedGroups),
+			kind:      crdKind,
+			operation: admission.Create,
+			userInfo: authenticationv1.UserInfo{
+				Username: "developer",
+				Groups:   []string{"developers", "system:authenticated"},
+			},
+			object:          crd,
+			expectedMessage: "CustomResourceDefinition objects are cluster-scoped, and may only be created by members of [system:masters platform-admins]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow CRD creation by an authorized user",
+			admitFunc: DenyClusterScopedCreation(allowedGroups),
+			kind:      crdKind,
+			operation: admission.Create,
+			userInfo: authenticationv1.UserInfo{
+				Username: "operator",
+				Groups:   []string{"platform-admins", "system:authenticated"},
+			},
+			object:      crd,
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow updates to cluster-scoped resources",
+			admitFunc: DenyClusterScopedCreation(allowedGroups),
+			kind:      crdKind,
+			operation: admission.Update,
+			userInfo: authenticationv1.UserInfo{
+				Username: "developer",
+				Groups:   []string{"developers"},
+			},
+			object:      crd,
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow creation of namespaced resources",
+			admitFunc: DenyClusterScopedCreation(allowedGroups),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			namespace: "default",
+			operation: admission.Create,
+			userInfo: authenticationv1.UserInfo{
+				Username: "developer",
+				Groups:   []string{"developers"},
+			},
+			object:      newTestPod("default", corev1.PodSpec{}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestWarnZeroReplicas(t *testing.T) {
+	t.Parallel()
+
+	var (
+		zero  int32
+		three int32 = 3
+	)
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var newDeployment = func(namespace string, replicas *int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:         "Warn on a zero-replica Deployment",
+			admitFunc:        WarnZeroReplicas(nil),
+			kind:             deploymentKind,
+			object:           newDeployment("default", &zero),
+			expectedWarnings: []string{`Deployment "hello-app" is scaled to 0 replicas, and will not serve any traffic`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Warn on a zero-replica StatefulSet",
+			admitFunc: WarnZeroReplicas(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "StatefulSet",
+				Version: "v1",
+			},
+			object: &appsv1.StatefulSet{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-db", Namespace: "default"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &zero},
+			},
+			expectedWarnings: []string{`StatefulSet "hello-db" is scaled to 0 replicas, and will not serve any traffic`},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Don't warn on a scaled Deployment",
+			admitFunc:        WarnZeroReplicas(nil),
+			kind:             deploymentKind,
+			object:           newDeployment("default", &three),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Don't warn when replicas is unset",
+			admitFunc:        WarnZeroReplicas(nil),
+			kind:             deploymentKind,
+			object:           newDeployment("default", nil),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Don't warn in a whitelisted namespace",
+			admitFunc:        WarnZeroReplicas([]string{"staging"}),
+			kind:             deploymentKind,
+			object:           newDeployment("staging", &zero),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestLimitConfigMapSize(t *testing.T) {
+	t.Parallel()
+
+	var configMapKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "ConfigMap",
+		Version: "v1",
+	}
+
+	var newConfigMap = func(namespace string, data map[string]string, binaryData map[string][]byte) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   meta.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-config", Namespace: namespace},
+			Data:       data,
+			BinaryData: binaryData,
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject oversized ConfigMap",
+			admitFunc: LimitConfigMapSize(nil, 1024),
+			kind:      configMapKind,
+			object: newConfigMap("default",
+				map[string]string{"config.yaml": strings.Repeat("a", 1000)},
+				map[string][]byte{"cert.der": make([]byte, 100)},
+			),
+			expectedMessage: `ConfigMap objects may contain at most 1024 bytes of data: "hello-config" contains 1100 bytes`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow small ConfigMap",
+			admitFunc:   LimitConfigMapSize(nil, 1024),
+			kind:        configMapKind,
+			object:      newConfigMap("default", map[string]string{"config.yaml": "debug: true"}, nil),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow oversized ConfigMap in a whitelisted namespace",
+			admitFunc:   LimitConfigMapSize([]string{"kube-system"}, 1024),
+			kind:        configMapKind,
+			object:      newConfigMap("kube-system", map[string]string{"config.yaml": strings.Repeat("a", 2048)}, nil),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow unrelated kinds",
+			admitFunc: LimitConfigMapSize(nil, 1),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Secret",
+				Version: "v1",
+			},
+			object: &corev1.Secret{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-secret", Namespace: "default"},
+				StringData: map[string]string{"password": "hunter2"},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforceSecretTypeConventions(t *testing.T) {
+	t.Parallel()
+
+	var rules = map[string]corev1.SecretType{
+		"*-tls":      corev1.SecretTypeTLS,
+		"registry-*": corev1.SecretTypeDockerConfigJson,
+	}
+
+	var secretKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Secret",
+		Version: "v1",
+	}
+
+	var newSecret = func(name string, secretType corev1.SecretType) *corev1.Secret {
+		return &corev1.Secret{
+			TypeMeta:   meta.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Type:       secretType,
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Opaque -tls Secret",
+			admitFunc:       EnforceSecretTypeConventions(rules),
+			kind:            secretKind,
+			object:          newSecret("hello-app-tls", corev1.SecretTypeOpaque),
+			expectedMessage: `Secret objects named "*-tls" must be of type kubernetes.io/tls: "hello-app-tls" is of type Opaque`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject -tls Secret without a type",
+			admitFunc:       EnforceSecretTypeConventions(rules),
+			kind:            secretKind,
+			object:          newSecret("hello-app-tls", ""),
+			expectedMessage: `Secret objects named "*-tls" must be of type kubernetes.io/tls: "hello-app-tls" is of type Opaque`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow kubernetes.io/tls -tls Secret",
+			admitFunc:   EnforceSecretTypeConventions(rules),
+			kind:        secretKind,
+			object:      newSecret("hello-app-tls", corev1.SecretTypeTLS),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Secrets that do not match a rule",
+			admitFunc:   EnforceSecretTypeConventions(rules),
+			kind:        secretKind,
+			object:      newSecret("hello-app-config", corev1.SecretTypeOpaque),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject all Secrets with an invalid pattern",
+			admitFunc:       EnforceSecretTypeConventions(map[string]corev1.SecretType{"[-tls": corev1.SecretTypeTLS}),
+			kind:            secretKind,
+			object:          newSecret("hello-app-config", corev1.SecretTypeOpaque),
+			expectedMessage: `invalid Secret name pattern "[-tls": syntax error in pattern`,
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyWindowsHostProcess(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod-level hostProcess",
+			admitFunc: DenyWindowsHostProcess(nil),
+			kind:      podKind,
+			rawObject: []byte(`{
+				"apiVersion": "v1",
+				"kind": "Pod",
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {
+					"hostNetwork": true,
+					"securityContext": {"windowsOptions": {"hostProcess": true, "runAsUserName": "NT AUTHORITY\\SYSTEM"}},
+					"containers": [{"name": "agent", "image": "agent:v1"}]
+				}
+			}`),
+			expectedMessage: "the submitted Pods run Windows HostProcess containers, which cannot be deployed to this cluster: [agent]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject container-level hostProcess in a DaemonSet",
+			admitFunc: DenyWindowsHostProcess(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "DaemonSet",
+				Version: "v1",
+			},
+			rawObject: []byte(`{
+				"apiVersion": "apps/v1",
+				"kind": "DaemonSet",
+				"metadata": {"name": "hello-agent", "namespace": "default"},
+				"spec": {"template": {"spec": {
+					"containers": [
+						{"name": "app", "image": "app:v1"},
+						{"name": "agent", "image": "agent:v1", "securityContext": {"windowsOptions": {"hostProcess": true}}}
+					]
+				}}}
+			}`),
+			expectedMessage: "the submitted Pods run Windows HostProcess containers, which cannot be deployed to this cluster: [agent]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow normal Windows Pod",
+			admitFunc: DenyWindowsHostProcess(nil),
+			kind:      podKind,
+			rawObject: []byte(`{
+				"apiVersion": "v1",
+				"kind": "Pod",
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {
+					"nodeSelector": {"kubernetes.io/os": "windows"},
+					"securityContext": {"windowsOptions": {"runAsUserName": "ContainerUser"}},
+					"containers": [{"name": "app", "image": "app:v1"}]
+				}
+			}`),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow hostProcess Pod in a whitelisted namespace",
+			admitFunc: DenyWindowsHostProcess([]string{"kube-system"}),
+			kind:      podKind,
+			rawObject: []byte(`{
+				"apiVersion": "v1",
+				"kind": "Pod",
+				"metadata": {"name": "hello-app", "namespace": "kube-system"},
+				"spec": {
+					"securityContext": {"windowsOptions": {"hostProcess": true}},
+					"containers": [{"name": "agent", "image": "agent:v1"}]
+				}
+			}`),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireMemoryEmptyDirSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	var sizeLimit = resource.MustParse("256Mi")
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newEmptyDirPod = func(namespace string, emptyDir *corev1.EmptyDirVolumeSource) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			Volumes: []corev1.Volume{
+				{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir}},
+			},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Memory emptyDir without a sizeLimit",
+			admitFunc:       RequireMemoryEmptyDirSizeLimit(nil),
+			kind:            podKind,
+			object:          newEmptyDirPod("default", &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}),
+			expectedMessage: "the submitted Pods have Memory-backed emptyDir volumes without a sizeLimit: [cache]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Memory emptyDir with a sizeLimit",
+			admitFunc: RequireMemoryEmptyDirSizeLimit(nil),
+			kind:      podKind,
+			object: newEmptyDirPod("default", &corev1.EmptyDirVolumeSource{
+				Medium:    corev1.StorageMediumMemory,
+				SizeLimit: &sizeLimit,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Memory emptyDir without a sizeLimit in a whitelisted namespace",
+			admitFunc:   RequireMemoryEmptyDirSizeLimit([]string{"kube-system"}),
+			kind:        podKind,
+			object:      newEmptyDirPod("kube-system", &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforceServiceAccountAnnotations(t *testing.T) {
+	t.Parallel()
+
+	var serviceAccountKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "ServiceAccount",
+		Version: "v1",
+	}
+
+	var requiredAnnotations = map[string]func(string) bool{
+		"iam.gke.io/gcp-service-account": func(val string) bool {
+			return strings.HasSuffix(val, ".iam.gserviceaccount.com")
+		},
+	}
+
+	var newServiceAccount = func(namespace string, annotations map[string]string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{
+			TypeMeta: meta.TypeMeta{Kind: "ServiceAccount", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "hello-app",
+				Namespace:   namespace,
+				Annotations: annotations,
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject ServiceAccount without the IAM annotation",
+			admitFunc:       EnforceServiceAccountAnnotations(nil, requiredAnnotations),
+			kind:            serviceAccountKind,
+			object:          newServiceAccount("default", nil),
+			expectedMessage: `the submitted ServiceAccount "hello-app" is missing required annotations: map[iam.gke.io/gcp-service-account:key was not found]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject ServiceAccount with an invalid IAM annotation",
+			admitFunc: EnforceServiceAccountAnnotations(nil, requiredAnnotations),
+			kind:      serviceAccountKind,
+			object: newServiceAccount("default", map[string]string{
+				"iam.gke.io/gcp-service-account": "hello-app",
+			}),
+			expectedMessage: `the submitted ServiceAccount "hello-app" is missing required annotations: map[iam.gke.io/gcp-service-account:value did not match]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow ServiceAccount with the IAM annotation",
+			admitFunc: EnforceServiceAccountAnnotations(nil, requiredAnnotations),
+			kind:      serviceAccountKind,
+			object: newServiceAccount("default", map[string]string{
+				"iam.gke.io/gcp-service-account": "hello-app@project.iam.gserviceaccount.com",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow ServiceAccount without the IAM annotation in a whitelisted namespace",
+			admitFunc:   EnforceServiceAccountAnnotations([]string{"kube-system"}, requiredAnnotations),
+			kind:        serviceAccountKind,
+			object:      newServiceAccount("kube-system", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow unrelated kinds",
+			admitFunc: EnforceServiceAccountAnnotations(nil, requiredAnnotations),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "ConfigMap",
+				Version: "v1",
+			},
+			object: &corev1.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-config", Namespace: "default"},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforcePodAnnotationsAnyOf(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var anyValue = func(string) bool { return true }
+	var sets = []map[string]func(string) bool{
+		{"vault.hashicorp.com/agent-inject": func(val string) bool { return val == "true" }},
+		{"secrets.example.com/provider": anyValue, "secrets.example.com/role": anyValue},
+	}
+
+	var newAnnotatedPod = func(namespace string, annotations map[string]string) *corev1.Pod {
+		pod := newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+		})
+		pod.Annotations = annotations
+
+		return pod
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Allow Pod satisfying the first set",
+			admitFunc: EnforcePodAnnotationsAnyOf(nil, sets),
+			kind:      podKind,
+			object: newAnnotatedPod("default", map[string]string{
+				"vault.hashicorp.com/agent-inject": "true",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod satisfying the second set (but not the first)",
+			admitFunc: EnforcePodAnnotationsAnyOf(nil, sets),
+			kind:      podKind,
+			object: newAnnotatedPod("default", map[string]string{
+				"vault.hashicorp.com/agent-inject": "false",
+				"secrets.example.com/provider":     "aws",
+				"secrets.example.com/role":         "reader",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Reject Pod satisfying no set",
+			admitFunc: EnforcePodAnnotationsAnyOf(nil, sets),
+			kind:      podKind,
+			object: newAnnotatedPod("default", map[string]string{
+				"vault.hashicorp.com/agent-inject": "false",
+				"secrets.example.com/provider":     "aws",
+			}),
+			expectedMessage: "the submitted Pods do not satisfy any of the required annotation sets: " +
+				"set 0: map[vault.hashicorp.com/agent-inject:value did not match]; " +
+				"set 1: map[secrets.example.com/role:key was not found]",
+			shouldAllow: false,
+		},
+		{
+			testName:        "Reject Pods when no sets are provided",
+			admitFunc:       EnforcePodAnnotationsAnyOf(nil, nil),
+			kind:            podKind,
+			object:          newAnnotatedPod("default", nil),
+			expectedMessage: "cannot validate annotations without any required annotation sets",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod satisfying no set in a whitelisted namespace",
+			admitFunc:   EnforcePodAnnotationsAnyOf([]string{"kube-system"}, sets),
+			kind:        podKind,
+			object:      newAnnotatedPod("kube-system", nil),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyNeverPullPolicy(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject container with imagePullPolicy: Never",
+			admitFunc: DenyNeverPullPolicy(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullNever},
+				},
+			}),
+			expectedMessage: "the submitted Pods have containers with imagePullPolicy: Never: [app]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject initContainer with imagePullPolicy: Never",
+			admitFunc: DenyNeverPullPolicy(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "migrate", Image: "migrate:v1", ImagePullPolicy: corev1.PullNever},
+				},
+				Containers: []corev1.Container{
+					{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullIfNotPresent},
+				},
+			}),
+			expectedMessage: "the submitted Pods have containers with imagePullPolicy: Never: [migrate]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow container with imagePullPolicy: IfNotPresent",
+			admitFunc: DenyNeverPullPolicy(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullIfNotPresent},
+				},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow container with imagePullPolicy: Never in a whitelisted namespace",
+			admitFunc: DenyNeverPullPolicy([]string{"kube-system"}),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullNever},
+				},
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+// fakeResourceLister is a ResourceLister backed by sets of "namespace/name"
+// keys.
+type fakeResourceLister struct {
+	configMaps map[string]bool
+	secrets    map[string]bool
+	err        error
+}
+
+func (fl *fakeResourceLister) ConfigMapExists(namespace, name string) (bool, error) {
+	return fl.configMaps[namespace+"/"+name], fl.err
+}
+
+func (fl *fakeResourceLister) SecretExists(namespace, name string) (bool, error) {
+	return fl.secrets[namespace+"/"+name], fl.err
+}
+
+func TestRequireReferencedResourcesExist(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var lister = &fakeResourceLister{
+		configMaps: map[string]bool{"default/app-config": true},
+		secrets:    map[string]bool{"default/app-secrets": true},
+	}
+
+	var optional = true
+
+	var newReferencingPod = func(namespace string, configMap string, secret string, optional *bool) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:v1",
+				EnvFrom: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+					},
+				}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "config",
+				VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+					Optional:             optional,
+				}},
+			}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Pod referencing a missing ConfigMap",
+			admitFunc:       RequireReferencedResourcesExist(nil, lister),
+			kind:            podKind,
+			object:          newReferencingPod("default", "missing-config", "app-secrets", nil),
+			expectedMessage: `the submitted Pods reference resources that do not exist in namespace "default": [ConfigMap/missing-config]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject Pod referencing resources from another namespace",
+			admitFunc:       RequireReferencedResourcesExist(nil, lister),
+			kind:            podKind,
+			object:          newReferencingPod("staging", "app-config", "app-secrets", nil),
+			expectedMessage: `the submitted Pods reference resources that do not exist in namespace "staging": [ConfigMap/app-config Secret/app-secrets]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod referencing existing resources",
+			admitFunc:   RequireReferencedResourcesExist(nil, lister),
+			kind:        podKind,
+			object:      newReferencingPod("default", "app-config", "app-secrets", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod with an optional reference to a missing ConfigMap",
+			admitFunc:   RequireReferencedResourcesExist(nil, lister),
+			kind:        podKind,
+			object:      newReferencingPod("default", "missing-config", "app-secrets", &optional),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod referencing a missing ConfigMap in a whitelisted namespace",
+			admitFunc:   RequireReferencedResourcesExist([]string{"kube-system"}, lister),
+			kind:        podKind,
+			object:      newReferencingPod("kube-system", "missing-config", "app-secrets", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject Pod when the lister fails",
+			admitFunc:       RequireReferencedResourcesExist(nil, &fakeResourceLister{err: errors.New("cache not synced")}),
+			kind:            podKind,
+			object:          newReferencingPod("default", "app-config", "app-secrets", nil),
+			expectedMessage: "an external dependency failed: cache not synced",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject when no ResourceLister is provided",
+			admitFunc:       RequireReferencedResourcesExist(nil, nil),
+			kind:            podKind,
+			object:          newReferencingPod("default", "app-config", "app-secrets", nil),
+			expectedMessage: "a ResourceLister must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyDirectNodeAssignment(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newAssignedPod = func(namespace string, nodeName string) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			NodeName:   nodeName,
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Pod created with nodeName set",
+			admitFunc:       DenyDirectNodeAssignment(nil),
+			kind:            podKind,
+			operation:       admission.Create,
+			object:          newAssignedPod("default", "node-1"),
+			expectedMessage: `the submitted Pods may not bypass the scheduler by setting nodeName: "node-1"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod created without nodeName",
+			admitFunc:   DenyDirectNodeAssignment(nil),
+			kind:        podKind,
+			operation:   admission.Create,
+			object:      newAssignedPod("default", ""),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow updates to scheduled Pods",
+			admitFunc:   DenyDirectNodeAssignment(nil),
+			kind:        podKind,
+			operation:   admission.Update,
+			object:      newAssignedPod("default", "node-1"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod created with nodeName in a whitelisted namespace",
+			admitFunc:   DenyDirectNodeAssignment([]string{"kube-system"}),
+			kind:        podKind,
+			operation:   admission.Create,
+			object:      newAssignedPod("kube-system", "node-1"),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateReadinessGateConsistency(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var readinessProbe = &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)},
+		},
+	}
+
+	var readinessGates = []corev1.PodReadinessGate{
+		{ConditionType: "target-health.elbv2.k8s.aws/app"},
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with readinessGates but no readinessProbe",
+			admitFunc: ValidateReadinessGateConsistency(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers:     []corev1.Container{{Name: "app", Image: "app:v1"}},
+				ReadinessGates: readinessGates,
+			}),
+			expectedMessage: "the submitted Pods declare readinessGates [target-health.elbv2.k8s.aws/app], but none of their containers define a readinessProbe",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with readinessGates and a readinessProbe",
+			admitFunc: ValidateReadinessGateConsistency(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "sidecar", Image: "sidecar:v1"},
+					{Name: "app", Image: "app:v1", ReadinessProbe: readinessProbe},
+				},
+				ReadinessGates: readinessGates,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod without readinessGates",
+			admitFunc: ValidateReadinessGateConsistency(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod with readinessGates but no readinessProbe in a whitelisted namespace",
+			admitFunc: ValidateReadinessGateConsistency([]string{"kube-system"}),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{
+				Containers:     []corev1.Container{{Name: "app", Image: "app:v1"}},
+				ReadinessGates: readinessGates,
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestWarnTopologyHintsMisuse(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newHintedService = func(namespace string, annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta: meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "hello-service",
+				Namespace:   namespace,
+				Annotations: annotations,
+			},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on an unrecognized topology hints value",
+			admitFunc: WarnTopologyHintsMisuse(nil),
+			kind:      serviceKind,
+			object: newHintedService("default", map[string]string{
+				"service.kubernetes.io/topology-aware-hints": "true",
+			}),
+			expectedWarnings: []string{`Service "hello-service" sets service.kubernetes.io/topology-aware-hints to an unrecognized value "true", and Topology Aware Hints will be disabled: use one of [Auto auto Disabled disabled]`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn on a recognized topology hints value",
+			admitFunc: WarnTopologyHintsMisuse(nil),
+			kind:      serviceKind,
+			object: newHintedService("default", map[string]string{
+				"service.kubernetes.io/topology-aware-hints": "Auto",
+			}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Do not warn on Services without the annotation",
+			admitFunc:        WarnTopologyHintsMisuse(nil),
+			kind:             serviceKind,
+			object:           newHintedService("default", nil),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn in a whitelisted namespace",
+			admitFunc: WarnTopologyHintsMisuse([]string{"kube-system"}),
+			kind:      serviceKind,
+			object: newHintedService("kube-system", map[string]string{
+				"service.kubernetes.io/topology-aware-hints": "enabled",
+			}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestExtractUnstructured(t *testing.T) {
+	t.Parallel()
+
+	review := &admission.AdmissionReview{
+		Request: &admission.AdmissionRequest{
+			Object: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"orders","namespace":"default"},"spec":{"storage":{"class":"ssd"}}}`),
+			},
+		},
+	}
+
+	object, err := ExtractUnstructured(review)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gvk := object.GroupVersionKind(); gvk.Group != "example.com" || gvk.Kind != "Database" {
+		t.Fatalf("unexpected GroupVersionKind: got %v", gvk)
+	}
+
+	class, found, err := unstructured.NestedString(object.Object, "spec", "storage", "class")
+	if err != nil || !found {
+		t.Fatalf("could not read the nested field: found %t, err %v", found, err)
+	}
+
+	if class != "ssd" {
+		t.Fatalf("unexpected value for spec.storage.class: got %q (wanted %q)", class, "ssd")
+	}
+}
+
+func TestEnforceCRDField(t *testing.T) {
+	t.Parallel()
+
+	var databaseGVK = schema.GroupVersionKind{
+		Group:   "example.com",
+		Kind:    "Database",
+		Version: "v1",
+	}
+
+	var databaseKind = meta.GroupVersionKind{
+		Group:   "example.com",
+		Kind:    "Database",
+		Version: "v1",
+	}
+
+	var isAllowedClass = func(val string) bool {
+		return val == "ssd" || val == "nvme"
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:    "Allow CR with a valid nested field",
+			admitFunc:   EnforceCRDField(databaseGVK, "spec.storage.class", isAllowedClass),
+			kind:        databaseKind,
+			rawObject:   []byte(`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"orders"},"spec":{"storage":{"class":"ssd"}}}`),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject CR with an invalid nested field",
+			admitFunc:       EnforceCRDField(databaseGVK, "spec.storage.class", isAllowedClass),
+			kind:            databaseKind,
+			rawObject:       []byte(`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"orders"},"spec":{"storage":{"class":"hdd"}}}`),
+			expectedMessage: `Database "orders" has an invalid value for spec.storage.class: "hdd"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject CR without the nested field",
+			admitFunc:       EnforceCRDField(databaseGVK, "spec.storage.class", isAllowedClass),
+			kind:            databaseKind,
+			rawObject:       []byte(`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"orders"},"spec":{}}`),
+			expectedMessage: `Database "orders" is missing the required field spec.storage.class`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject CR with a non-string field",
+			admitFunc:       EnforceCRDField(databaseGVK, "spec.storage", isAllowedClass),
+			kind:            databaseKind,
+			rawObject:       []byte(`{"apiVersion":"example.com/v1","kind":"Database","metadata":{"name":"orders"},"spec":{"storage":{"class":"ssd"}}}`),
+			expectedMessage: `Database "orders": .spec.storage accessor error: map[class:ssd] is of the type map[string]interface {}, expected string`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow other Kinds",
+			admitFunc: EnforceCRDField(databaseGVK, "spec.storage.class", isAllowedClass),
+			kind: meta.GroupVersionKind{
+				Group:   "other.example.com",
+				Kind:    "Database",
+				Version: "v1",
+			},
+			rawObject:   []byte(`{"apiVersion":"other.example.com/v1","kind":"Database","metadata":{"name":"orders"},"spec":{}}`),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateDefaultContainerAnnotation(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newDefaultContainerPod = func(namespace string, annotations map[string]string) *corev1.Pod {
+		pod := newTestPod(namespace, corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "migrate", Image: "migrate:v1"}},
+			Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1"},
+				{Name: "proxy", Image: "proxy:v1"},
+			},
+		})
+		pod.Annotations = annotations
+
+		return pod
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with a mismatched default-container annotation",
+			admitFunc: ValidateDefaultContainerAnnotation(nil),
+			kind:      podKind,
+			object: newDefaultContainerPod("default", map[string]string{
+				"kubectl.kubernetes.io/default-container": "ap",
+			}),
+			expectedMessage: `the kubectl.kubernetes.io/default-container annotation names a container "ap" that does not exist in the submitted Pods: must be one of [app proxy]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Pod with a default-container annotation naming an initContainer",
+			admitFunc: ValidateDefaultContainerAnnotation(nil),
+			kind:      podKind,
+			object: newDefaultContainerPod("default", map[string]string{
+				"kubectl.kubernetes.io/default-container": "migrate",
+			}),
+			expectedMessage: `the kubectl.kubernetes.io/default-container annotation names a container "migrate" that does not exist in the submitted Pods: must be one of [app proxy]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with a correct default-container annotation",
+			admitFunc: ValidateDefaultContainerAnnotation(nil),
+			kind:      podKind,
+			object: newDefaultContainerPod("default", map[string]string{
+				"kubectl.kubernetes.io/default-container": "proxy",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod without a default-container annotation",
+			admitFunc:   ValidateDefaultContainerAnnotation(nil),
+			kind:        podKind,
+			object:      newDefaultContainerPod("default", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod with a mismatched default-container annotation in a whitelisted namespace",
+			admitFunc: ValidateDefaultContainerAnnotation([]string{"kube-system"}),
+			kind:      podKind,
+			object: newDefaultContainerPod("kube-system", map[string]string{
+				"kubectl.kubernetes.io/default-container": "ap",
+			}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestWarnDeploymentsLackingRequestsForHPA(t *testing.T) {
+	t.Parallel()
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var newRequestingDeployment = func(namespace string, containers ...corev1.Container) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: containers},
+				},
+			},
+		}
+	}
+
+	var requested = corev1.Container{
+		Name:  "app",
+		Image: "app:v1",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+		},
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on a Deployment without requests",
+			admitFunc: WarnDeploymentsLackingRequestsForHPA(nil),
+			kind:      deploymentKind,
+			object: newRequestingDeployment("default",
+				requested,
+				corev1.Container{Name: "proxy", Image: "proxy:v1"},
+			),
+			expectedWarnings: []string{
+				`container "proxy" of Deployment "hello-app" does not request cpu or memory: a HorizontalPodAutoscaler cannot scale it on resource utilization`,
+			},
+			shouldAllow: true,
+		},
+		{
+			testName:  "Warn on a Deployment without memory requests",
+			admitFunc: WarnDeploymentsLackingRequestsForHPA(nil),
+			kind:      deploymentKind,
+			object: newRequestingDeployment("default", corev1.Container{
+				Name:  "app",
+				Image: "app:v1",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+			}),
+			expectedWarnings: []string{
+				`container "app" of Deployment "hello-app" does not request memory: a HorizontalPodAutoscaler cannot scale it on resource utilization`,
+			},
+			shouldAllow: true,
+		},
+		{
+			testName:         "Do not warn on a Deployment with requests",
+			admitFunc:        WarnDeploymentsLackingRequestsForHPA(nil),
+			kind:             deploymentKind,
+			object:           newRequestingDeployment("default", requested),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn on a Deployment with requests defaulted from limits",
+			admitFunc: WarnDeploymentsLackingRequestsForHPA(nil),
+			kind:      deploymentKind,
+			object: newRequestingDeployment("default", corev1.Container{
+				Name:  "app",
+				Image: "app:v1",
+				Resources: corev1.ResourceRequirements{
+					Limits: requested.Resources.Requests,
+				},
+			}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Do not warn in a whitelisted namespace",
+			admitFunc:        WarnDeploymentsLackingRequestsForHPA([]string{"kube-system"}),
+			kind:             deploymentKind,
+			object:           newRequestingDeployment("kube-system", corev1.Container{Name: "app", Image: "app:v1"}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyDeprecatedAnnotations(t *testing.T) {
+	t.Parallel()
+
+	var deprecated = map[string]string{
+		"scheduler.alpha.kubernetes.io/critical-pod": "",
+		"seccomp.security.alpha.kubernetes.io/pod":   "securityContext.seccompProfile",
+		"kubernetes.io/ingress.class":                "spec.ingressClassName",
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject Pod with deprecated annotations",
+			admitFunc: DenyDeprecatedAnnotations(deprecated),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			rawObject:       []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"hello-app","namespace":"default","annotations":{"seccomp.security.alpha.kubernetes.io/pod":"runtime/default","scheduler.alpha.kubernetes.io/critical-pod":"","buildVersion":"v1.0.2"}},"spec":{"containers":[{"name":"nginx","image":"nginx:latest"}]}}`),
+			expectedMessage: `Pod "hello-app" uses deprecated annotations: scheduler.alpha.kubernetes.io/critical-pod (no replacement), seccomp.security.alpha.kubernetes.io/pod (use securityContext.seccompProfile instead)`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Ingress with a deprecated annotation",
+			admitFunc: DenyDeprecatedAnnotations(deprecated),
+			kind: meta.GroupVersionKind{
+				Group:   "networking.k8s.io",
+				Kind:    "Ingress",
+				Version: "v1",
+			},
+			rawObject:       []byte(`{"kind":"Ingress","apiVersion":"networking.k8s.io/v1","metadata":{"name":"hello-ingress","namespace":"default","annotations":{"kubernetes.io/ingress.class":"nginx"}},"spec":{}}`),
+			expectedMessage: `Ingress "hello-ingress" uses deprecated annotations: kubernetes.io/ingress.class (use spec.ingressClassName instead)`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod without deprecated annotations",
+			admitFunc: DenyDeprecatedAnnotations(deprecated),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			rawObject:   []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"hello-app","namespace":"default","annotations":{"buildVersion":"v1.0.2"}},"spec":{"containers":[{"name":"nginx","image":"nginx:latest"}]}}`),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestInjectDefaultSecurityContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		yes = true
+		no  = false
+	)
+
+	var defaults = corev1.SecurityContext{
+		RunAsNonRoot:             &yes,
+		AllowPrivilegeEscalation: &no,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+
+	var podSpec = corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{Name: "migrate", Image: "migrate:v1"},
+		},
+		Containers: []corev1.Container{
+			{Name: "app", Image: "app:v1"},
+			{
+				Name:  "proxy",
+				Image: "proxy:v1",
+				SecurityContext: &corev1.SecurityContext{
+					RunAsNonRoot: &no,
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"NET_ADMIN"}},
+				},
+			},
+		},
+	}
+
+	// admit invokes the AdmitFunc, and returns the object with the patch (if any)
+	// applied.
+	var admit = func(t *testing.T, kind string, operation admission.Operation, object interface{}) ([]byte, []byte) {
+		t.Helper()
+
+		raw, err := json.Marshal(object)
+		if err != nil {
+			t.Fatalf("could not marshal k8s API object: %v", err)
+		}
+
+		group := ""
+		if kind == "Deployment" {
+			group = "apps"
+		}
+
+		review := &admission.AdmissionReview{
+			Request: &admission.AdmissionRequest{
+				Kind:      meta.GroupVersionKind{Group: group, Version: "v1", Kind: kind},
+				Operation: operation,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		}
+
+		resp, err := InjectDefaultSecurityContext(nil, defaults)(review)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !resp.Allowed {
+			t.Fatalf("the request was not allowed")
+		}
+
+		if len(resp.Patch) == 0 {
+			return raw, nil
+		}
+
+		if resp.PatchType == nil || *resp.PatchType != admission.PatchTypeJSONPatch {
+			t.Fatalf("unexpected patch type: got %v", resp.PatchType)
+		}
+
+		patch, err := jsonpatch.DecodePatch(resp.Patch)
+		if err != nil {
+			t.Fatalf("could not decode the patch: %v", err)
+		}
+
+		patched, err := patch.Apply(raw)
+		if err != nil {
+			t.Fatalf("could not apply the patch: %v", err)
+		}
+
+		return patched, resp.Patch
+	}
+
+	t.Run("Only unset fields are filled in", func(t *testing.T) {
+		patched, patch := admit(t, "Pod", admission.Create, newTestPod("default", podSpec))
+		if patch == nil {
+			t.Fatal("expected a patch to be returned")
+		}
+
+		pod := corev1.Pod{}
+		if err := json.Unmarshal(patched, &pod); err != nil {
+			t.Fatalf("could not unmarshal the patched Pod: %v", err)
+		}
+
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers[0]) {
+			if !reflect.DeepEqual(container.SecurityContext, &defaults) {
+				t.Fatalf("container %q did not receive the defaults: got %+v", container.Name, container.SecurityContext)
+			}
+		}
+
+		proxy := pod.Spec.Containers[1].SecurityContext
+		if proxy.RunAsNonRoot == nil || *proxy.RunAsNonRoot {
+			t.Fatalf("the explicit runAsNonRoot: false was overwritten: got %v", proxy.RunAsNonRoot)
+		}
+
+		if !reflect.DeepEqual(proxy.Capabilities, &corev1.Capabilities{Add: []corev1.Capability{"NET_ADMIN"}}) {
+			t.Fatalf("the explicit capabilities were overwritten: got %+v", proxy.Capabilities)
+		}
+
+		if proxy.AllowPrivilegeEscalation == nil || *proxy.AllowPrivilegeEscalation {
+			t.Fatalf("the unset allowPrivilegeEscalation was not filled in: got %v", proxy.AllowPrivilegeEscalation)
+		}
+
+		// Re-invoking the AdmitFunc on the patched object must not patch it again.
+		if _, patch := admit(t, "Pod", admission.Create, json.RawMessage(patched)); patch != nil {
+			t.Fatalf("expected an empty patch on re-invocation: got %s", patch)
+		}
+	})
+
+	t.Run("Deployments are patched at the Pod template", func(t *testing.T) {
+		deployment := &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{Spec: podSpec},
+			},
+		}
+
+		patched, _ := admit(t, "Deployment", admission.Update, deployment)
+
+		patchedDeployment := appsv1.Deployment{}
+		if err := json.Unmarshal(patched, &patchedDeployment); err != nil {
+			t.Fatalf("could not unmarshal the patched Deployment: %v", err)
+		}
+
+		if container := patchedDeployment.Spec.Template.Spec.Containers[0]; !reflect.DeepEqual(container.SecurityContext, &defaults) {
+			t.Fatalf("container %q did not receive the defaults: got %+v", container.Name, container.SecurityContext)
+		}
+	})
+
+	t.Run("Pods are not patched on update", func(t *testing.T) {
+		if _, patch := admit(t, "Pod", admission.Update, newTestPod("default", podSpec)); patch != nil {
+			t.Fatalf("expected an empty patch on update: got %s", patch)
+		}
+	})
+}
+
+func TestWarnSensitivePortsExposed(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var sensitivePorts = []int32{9090, 6443}
+
+	var newExposedService = func(namespace string, serviceType corev1.ServiceType, ports ...corev1.ServicePort) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Type:  serviceType,
+				Ports: ports,
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on a LoadBalancer exposing port 9090",
+			admitFunc: WarnSensitivePortsExposed(nil, sensitivePorts),
+			kind:      serviceKind,
+			object: newExposedService("default", corev1.ServiceTypeLoadBalancer,
+				corev1.ServicePort{Name: "http", Port: 80},
+				corev1.ServicePort{Name: "metrics", Port: 9090},
+			),
+			expectedWarnings: []string{`LoadBalancer Service "hello-service" exposes the sensitive port 9090 outside of the cluster`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Warn on a NodePort targeting port 6443",
+			admitFunc: WarnSensitivePortsExposed(nil, sensitivePorts),
+			kind:      serviceKind,
+			object: newExposedService("default", corev1.ServiceTypeNodePort,
+				corev1.ServicePort{Name: "https", Port: 443, TargetPort: intstr.FromInt(6443)},
+			),
+			expectedWarnings: []string{`NodePort Service "hello-service" exposes the sensitive port 6443 outside of the cluster`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn on a ClusterIP Service exposing port 9090",
+			admitFunc: WarnSensitivePortsExposed(nil, sensitivePorts),
+			kind:      serviceKind,
+			object: newExposedService("default", corev1.ServiceTypeClusterIP,
+				corev1.ServicePort{Name: "metrics", Port: 9090},
+			),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn on a LoadBalancer exposing other ports",
+			admitFunc: WarnSensitivePortsExposed(nil, sensitivePorts),
+			kind:      serviceKind,
+			object: newExposedService("default", corev1.ServiceTypeLoadBalancer,
+				corev1.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromString("http")},
+			),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn in a whitelisted namespace",
+			admitFunc: WarnSensitivePortsExposed([]string{"monitoring"}, sensitivePorts),
+			kind:      serviceKind,
+			object: newExposedService("monitoring", corev1.ServiceTypeLoadBalancer,
+				corev1.ServicePort{Name: "metrics", Port: 9090},
+			),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireRuntimeClass(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var (
+		gvisor = "gvisor"
+		runc   = "runc"
+	)
+
+	var tenantNamespaces = []string{"tenant-a", "tenant-b"}
+	var sandboxed = []string{"gvisor", "kata"}
+
+	var newRuntimeClassPod = func(namespace string, runtimeClassName *string) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers:       []corev1.Container{{Name: "app", Image: "app:v1"}},
+			RuntimeClassName: runtimeClassName,
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Pod without a runtimeClass in a tenant namespace",
+			admitFunc:       RequireRuntimeClass(tenantNamespaces, sandboxed),
+			kind:            podKind,
+			object:          newRuntimeClassPod("tenant-a", nil),
+			expectedMessage: `the submitted Pods in namespace "tenant-a" must set a runtimeClassName from [gvisor kata]: got <none>`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject Pod with a non-sandboxed runtimeClass in a tenant namespace",
+			admitFunc:       RequireRuntimeClass(tenantNamespaces, sandboxed),
+			kind:            podKind,
+			object:          newRuntimeClassPod("tenant-b", &runc),
+			expectedMessage: `the submitted Pods in namespace "tenant-b" must set a runtimeClassName from [gvisor kata]: got runc`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod with a sandboxed runtimeClass in a tenant namespace",
+			admitFunc:   RequireRuntimeClass(tenantNamespaces, sandboxed),
+			kind:        podKind,
+			object:      newRuntimeClassPod("tenant-a", &gvisor),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod without a runtimeClass in other namespaces",
+			admitFunc:   RequireRuntimeClass(tenantNamespaces, sandboxed),
+			kind:        podKind,
+			object:      newRuntimeClassPod("default", nil),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyUniversalTolerations(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newTolerantPod = func(namespace string, tolerations ...corev1.Toleration) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers:  []corev1.Container{{Name: "app", Image: "app:v1"}},
+			Tolerations: tolerations,
+		})
+	}
+
+	var universal = corev1.Toleration{Operator: corev1.TolerationOpExists}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Pod with a universal toleration",
+			admitFunc:       DenyUniversalTolerations(nil),
+			kind:            podKind,
+			object:          newTolerantPod("default", universal),
+			expectedMessage: "the submitted Pods may not tolerate all taints (a toleration with an empty key, operator: Exists and no effect)",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow Pod with a specific toleration",
+			admitFunc: DenyUniversalTolerations(nil),
+			kind:      podKind,
+			object: newTolerantPod("default", corev1.Toleration{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "gpu",
+				Effect:   corev1.TaintEffectNoSchedule,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow Pod tolerating all taints of a specific effect",
+			admitFunc: DenyUniversalTolerations(nil),
+			kind:      podKind,
+			object: newTolerantPod("default", corev1.Toleration{
+				Operator: corev1.TolerationOpExists,
+				Effect:   corev1.TaintEffectNoExecute,
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pod with a universal toleration in a whitelisted namespace",
+			admitFunc:   DenyUniversalTolerations([]string{"kube-system"}),
+			kind:        podKind,
+			object:      newTolerantPod("kube-system", universal),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+// fakeIngressLister is an IngressLister backed by a fixed list of Ingresses.
+type fakeIngressLister []networkingv1.Ingress
+
+func (fl fakeIngressLister) ListIngresses() ([]networkingv1.Ingress, error) {
+	return fl, nil
+}
+
+// newTestIngress returns an Ingress with a single host & path.
+func newTestIngress(namespace string, name string, host string, path string) networkingv1.Ingress {
+	return networkingv1.Ingress{
+		TypeMeta:   meta.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{Path: path}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestDenyConflictingIngressPaths(t *testing.T) {
+	t.Parallel()
+
+	var ingressKind = meta.GroupVersionKind{
+		Group:   "networking.k8s.io",
+		Kind:    "Ingress",
+		Version: "v1",
+	}
+
+	var lister = fakeIngressLister{
+		newTestIngress("team-a", "api", "example.com", "/api"),
+		newTestIngress("team-a", "web", "example.com", "/"),
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Ingress with a colliding host & path",
+			admitFunc:       DenyConflictingIngressPaths(lister),
+			kind:            ingressKind,
+			object:          newTestIngress("team-b", "hello-ingress", "example.com", "/api"),
+			expectedMessage: `Ingress "hello-ingress" claims host paths that are already claimed in other namespaces: example.com/api (team-a/api)`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Ingress with a unique path",
+			admitFunc:   DenyConflictingIngressPaths(lister),
+			kind:        ingressKind,
+			object:      newTestIngress("team-b", "hello-ingress", "example.com", "/hello"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Ingress with the same path on another host",
+			admitFunc:   DenyConflictingIngressPaths(lister),
+			kind:        ingressKind,
+			object:      newTestIngress("team-b", "hello-ingress", "hello.example.com", "/api"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Ingress colliding within its own namespace",
+			admitFunc:   DenyConflictingIngressPaths(lister),
+			kind:        ingressKind,
+			object:      newTestIngress("team-a", "api-v2", "example.com", "/api"),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Reject legacy extensions Ingress with a colliding host & path",
+			admitFunc: DenyConflictingIngressPaths(lister),
+			kind: meta.GroupVersionKind{
+				Group:   "extensions",
+				Kind:    "Ingress",
+				Version: "v1beta1",
+			},
+			object:          newTestIngress("team-b", "hello-ingress", "example.com", "/api"),
+			expectedMessage: `Ingress "hello-ingress" claims host paths that are already claimed in other namespaces: example.com/api (team-a/api)`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow an Ingress Kind from another group",
+			admitFunc: DenyConflictingIngressPaths(lister),
+			kind: meta.GroupVersionKind{
+				Group:   "example.com",
+				Kind:    "Ingress",
+				Version: "v1",
+			},
+			object:      newTestIngress("team-b", "hello-ingress", "example.com", "/api"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject when no IngressLister is provided",
+			admitFunc:       DenyConflictingIngressPaths(nil),
+			kind:            ingressKind,
+			object:          newTestIngress("team-b", "hello-ingress", "example.com", "/hello"),
+			expectedMessage: "an IngressLister must be provided",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow unrelated kinds",
+			admitFunc: DenyConflictingIngressPaths(lister),
+			kind: meta.GroupVersionKind{
+				Group:   "",
+				Kind:    "Service",
+				Version: "v1",
+			},
+			object: &corev1.Service{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "team-b"},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireVolumes(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newVolumePod = func(namespace string, volumeNames ...string) *corev1.Pod {
+		volumes := make([]corev1.Volume, 0, len(volumeNames))
+		for _, name := range volumeNames {
+			volumes = append(volumes, corev1.Volume{
+				Name:         name,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
+
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			Volumes:    volumes,
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Pod missing the logging volume",
+			admitFunc:       RequireVolumes(nil, []string{"logging"}),
+			kind:            podKind,
+			object:          newVolumePod("default", "cache"),
+			expectedMessage: "the submitted Pods are missing required volumes: [logging]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod with the logging volume",
+			admitFunc:   RequireVolumes(nil, []string{"logging"}),
+			kind:        podKind,
+			object:      newVolumePod("default", "cache", "logging"),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Reject Deployment missing the logging volume",
+			admitFunc: RequireVolumes(nil, []string{"logging", "config"}),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "Deployment",
+				Version: "v1",
+			},
+			object: &appsv1.Deployment{
+				TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{Spec: newVolumePod("default", "config").Spec},
+				},
+			},
+			expectedMessage: "the submitted Pods are missing required volumes: [logging]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Pod missing the logging volume in a whitelisted namespace",
+			admitFunc:   RequireVolumes([]string{"kube-system"}, []string{"logging"}),
+			kind:        podKind,
+			object:      newVolumePod("kube-system"),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyAggregatedClusterRoleEdits(t *testing.T) {
+	t.Parallel()
+
+	var clusterRoleKind = meta.GroupVersionKind{
+		Group:   "rbac.authorization.k8s.io",
+		Kind:    "ClusterRole",
+		Version: "v1",
+	}
+
+	var allowAnnotation = "admission.example.com/allow-aggregated-edit"
+
+	var newAggregatedClusterRole = func(annotations map[string]string) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			TypeMeta: meta.TypeMeta{Kind: "ClusterRole", APIVersion: "rbac.authorization.k8s.io/v1"},
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "monitoring",
+				Annotations: annotations,
+			},
+			AggregationRule: &rbacv1.AggregationRule{
+				ClusterRoleSelectors: []meta.LabelSelector{
+					{MatchLabels: map[string]string{"rbac.example.com/aggregate-to-monitoring": "true"}},
+				},
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject an unauthorized edit of an aggregated ClusterRole",
+			admitFunc:       DenyAggregatedClusterRoleEdits(allowAnnotation),
+			kind:            clusterRoleKind,
+			operation:       admission.Update,
+			object:          newAggregatedClusterRole(nil),
+			expectedMessage: `ClusterRole "monitoring" has an aggregationRule, and cannot be edited without the "admission.example.com/allow-aggregated-edit" annotation`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow an annotated edit of an aggregated ClusterRole",
+			admitFunc:   DenyAggregatedClusterRoleEdits(allowAnnotation),
+			kind:        clusterRoleKind,
+			operation:   admission.Update,
+			object:      newAggregatedClusterRole(map[string]string{allowAnnotation: "CHANGE-1234"}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow edits by the aggregation controller",
+			admitFunc: DenyAggregatedClusterRoleEdits(allowAnnotation),
+			kind:      clusterRoleKind,
+			operation: admission.Update,
+			userInfo: authenticationv1.UserInfo{
+				Username: "system:serviceaccount:kube-system:clusterrole-aggregation-controller",
+			},
+			object:      newAggregatedClusterRole(nil),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow the creation of an aggregated ClusterRole",
+			admitFunc:   DenyAggregatedClusterRoleEdits(allowAnnotation),
+			kind:        clusterRoleKind,
+			operation:   admission.Create,
+			object:      newAggregatedClusterRole(nil),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow edits of non-aggregated ClusterRoles",
+			admitFunc: DenyAggregatedClusterRoleEdits(allowAnnotation),
+			kind:      clusterRoleKind,
+			operation: admission.Update,
+			object: &rbacv1.ClusterRole{
+				TypeMeta:   meta.TypeMeta{Kind: "ClusterRole", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "pod-reader"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+				},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestWarnInlineSecretEnv(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var secretNamePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`PASSWORD`),
+		regexp.MustCompile(`TOKEN`),
+	}
+
+	var newEnvPod = func(namespace string, env ...corev1.EnvVar) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1", Env: env}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Warn on an inline DB_PASSWORD",
+			admitFunc: WarnInlineSecretEnv(nil, secretNamePatterns),
+			kind:      podKind,
+			object: newEnvPod("default",
+				corev1.EnvVar{Name: "DB_HOST", Value: "db.example.com"},
+				corev1.EnvVar{Name: "DB_PASSWORD", Value: "hunter2"},
+			),
+			expectedWarnings: []string{`container "app" sets DB_PASSWORD to an inline value: reference a Secret via valueFrom.secretKeyRef instead`},
+			shouldAllow:      true,
+		},
+		{
+			testName:  "Do not warn on a DB_PASSWORD from a Secret",
+			admitFunc: WarnInlineSecretEnv(nil, secretNamePatterns),
+			kind:      podKind,
+			object: newEnvPod("default", corev1.EnvVar{
+				Name: "DB_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+						Key:                  "password",
+					},
+				},
+			}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Do not warn in a whitelisted namespace",
+			admitFunc:        WarnInlineSecretEnv([]string{"kube-system"}, secretNamePatterns),
+			kind:             podKind,
+			object:           newEnvPod("kube-system", corev1.EnvVar{Name: "API_TOKEN", Value: "abc123"}),
+			expectedWarnings: []string{},
+			shouldAllow:      true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateInitContainerRestartPolicy(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newSidecarPod = func(namespace string, restartPolicy string) []byte {
+		return []byte(fmt.Sprintf(`{
+			"apiVersion": "v1",
+			"kind": "Pod",
+			"metadata": {"name": "hello-app", "namespace": %q},
+			"spec": {
+				"initContainers": [{"name": "proxy", "image": "proxy:v1", "restartPolicy": %q}],
+				"containers": [{"name": "app", "image": "app:v1"}]
+			}
+		}`, namespace, restartPolicy))
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a sidecar when sidecars are not allowed",
+			admitFunc:       ValidateInitContainerRestartPolicy(nil, false),
+			kind:            podKind,
+			rawObject:       newSidecarPod("default", "Always"),
+			expectedMessage: "the submitted Pods have init containers that set a restartPolicy, but sidecar containers are not allowed: map[proxy:Always]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a sidecar when sidecars are allowed",
+			admitFunc:   ValidateInitContainerRestartPolicy(nil, true),
+			kind:        podKind,
+			rawObject:   newSidecarPod("default", "Always"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an invalid restartPolicy when sidecars are allowed",
+			admitFunc:       ValidateInitContainerRestartPolicy(nil, true),
+			kind:            podKind,
+			rawObject:       newSidecarPod("default", "OnFailure"),
+			expectedMessage: `the submitted Pods have init containers with an invalid restartPolicy (only "Always" is allowed): map[proxy:OnFailure]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow init containers without a restartPolicy",
+			admitFunc: ValidateInitContainerRestartPolicy(nil, false),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate", Image: "migrate:v1"}},
+				Containers:     []corev1.Container{{Name: "app", Image: "app:v1"}},
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a sidecar in a whitelisted namespace",
+			admitFunc:   ValidateInitContainerRestartPolicy([]string{"kube-system"}, false),
+			kind:        podKind,
+			rawObject:   newSidecarPod("kube-system", "Always"),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyPublishNotReadyAddresses(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newService = func(namespace string, publishNotReadyAddresses bool) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Ports:                    []corev1.ServicePort{{Name: "http", Port: 80}},
+				PublishNotReadyAddresses: publishNotReadyAddresses,
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Service publishing not-ready addresses",
+			admitFunc:       DenyPublishNotReadyAddresses(nil),
+			kind:            serviceKind,
+			object:          newService("default", true),
+			expectedMessage: `Service "hello-service" sets publishNotReadyAddresses, which routes traffic to Pods that are not ready`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Service without publishNotReadyAddresses",
+			admitFunc:   DenyPublishNotReadyAddresses(nil),
+			kind:        serviceKind,
+			object:      newService("default", false),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Service publishing not-ready addresses in a whitelisted namespace",
+			admitFunc:   DenyPublishNotReadyAddresses([]string{"databases"}),
+			kind:        serviceKind,
+			object:      newService("databases", true),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRestrictPVCAccessModes(t *testing.T) {
+	t.Parallel()
+
+	var claimKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "PersistentVolumeClaim",
+		Version: "v1",
+	}
+
+	var allowedModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+
+	var newClaim = func(namespace string, modes ...corev1.PersistentVolumeAccessMode) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			TypeMeta:   meta.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "data", Namespace: namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: modes,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a ReadWriteMany PVC when only ReadWriteOnce is allowed",
+			admitFunc:       RestrictPVCAccessModes(nil, allowedModes),
+			kind:            claimKind,
+			object:          newClaim("default", corev1.ReadWriteMany),
+			expectedMessage: `PersistentVolumeClaim "data" requests access modes [ReadWriteMany], but only [ReadWriteOnce] are allowed`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a ReadWriteOnce PVC",
+			admitFunc:   RestrictPVCAccessModes(nil, allowedModes),
+			kind:        claimKind,
+			object:      newClaim("default", corev1.ReadWriteOnce),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a ReadWriteMany PVC in a whitelisted namespace",
+			admitFunc:   RestrictPVCAccessModes([]string{"shared-storage"}, allowedModes),
+			kind:        claimKind,
+			object:      newClaim("shared-storage", corev1.ReadWriteMany),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+// fakeQuotaLister is a QuotaLister backed by a map of namespaces to their
+// ResourceQuotas.
+type fakeQuotaLister struct {
+	quotas map[string][]corev1.ResourceQuota
+	err    error
+}
+
+func (fl *fakeQuotaLister) ListResourceQuotas(namespace string) ([]corev1.ResourceQuota, error) {
+	return fl.quotas[namespace], fl.err
+}
+
+func TestPreflightQuotaCheck(t *testing.T) {
+	t.Parallel()
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var lister = &fakeQuotaLister{
+		quotas: map[string][]corev1.ResourceQuota{
+			"default": {{
+				ObjectMeta: meta.ObjectMeta{Name: "pod-count", Namespace: "default"},
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+				},
+				Status: corev1.ResourceQuotaStatus{
+					Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("6")},
+				},
+			}},
+		},
+	}
+
+	var newDeployment = func(replicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+
+	var newScale = func(replicas int32) *autoscalingv1.Scale {
+		return &autoscalingv1.Scale{
+			TypeMeta:   meta.TypeMeta{Kind: "Scale", APIVersion: "autoscaling/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject scaling a Deployment past the Pod quota",
+			admitFunc:       PreflightQuotaCheck(lister),
+			kind:            deploymentKind,
+			operation:       admission.Update,
+			object:          newDeployment(8),
+			oldObject:       newDeployment(3),
+			expectedMessage: `scaling "hello-app" by 5 replicas would exceed the Pod quota of ResourceQuota "pod-count" in namespace "default": 6 of 10 Pods are in use`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow scaling a Deployment within the Pod quota",
+			admitFunc:   PreflightQuotaCheck(lister),
+			kind:        deploymentKind,
+			operation:   admission.Update,
+			object:      newDeployment(7),
+			oldObject:   newDeployment(3),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject when no QuotaLister is provided",
+			admitFunc:       PreflightQuotaCheck(nil),
+			kind:            deploymentKind,
+			operation:       admission.Update,
+			object:          newDeployment(7),
+			oldObject:       newDeployment(3),
+			expectedMessage: "a QuotaLister must be provided",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject creating a Deployment past the Pod quota",
+			admitFunc:       PreflightQuotaCheck(lister),
+			kind:            deploymentKind,
+			operation:       admission.Create,
+			object:          newDeployment(5),
+			expectedMessage: `scaling "hello-app" by 5 replicas would exceed the Pod quota of ResourceQuota "pod-count" in namespace "default": 6 of 10 Pods are in use`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a scale subresource update past the Pod quota",
+			admitFunc:       PreflightQuotaCheck(lister),
+			kind:            meta.GroupVersionKind{Group: "autoscaling", Kind: "Scale", Version: "v1"},
+			operation:       admission.Update,
+			object:          newScale(10),
+			oldObject:       newScale(3),
+			expectedMessage: `scaling "hello-app" by 7 replicas would exceed the Pod quota of ResourceQuota "pod-count" in namespace "default": 6 of 10 Pods are in use`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow scaling down",
+			admitFunc:   PreflightQuotaCheck(lister),
+			kind:        deploymentKind,
+			operation:   admission.Update,
+			object:      newDeployment(1),
+			oldObject:   newDeployment(20),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject when the quota cannot be listed",
+			admitFunc:       PreflightQuotaCheck(&fakeQuotaLister{err: errors.New("informer not synced")}),
+			kind:            deploymentKind,
+			operation:       admission.Create,
+			object:          newDeployment(1),
+			expectedMessage: "an external dependency failed: informer not synced",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireSessionAffinityTimeout(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newAffinityService = func(namespace string, affinity corev1.ServiceAffinity, timeoutSeconds *int32) *corev1.Service {
+		service := &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Ports:           []corev1.ServicePort{{Name: "http", Port: 80}},
+				SessionAffinity: affinity,
+			},
+		}
+
+		if timeoutSeconds != nil {
+			service.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+				ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: timeoutSeconds},
+			}
+		}
+
+		return service
+	}
+
+	var (
+		explicitTimeout int32 = 600
+		defaultTimeout  int32 = 10800
+	)
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject ClientIP affinity without a timeout",
+			admitFunc:       RequireSessionAffinityTimeout(nil),
+			kind:            serviceKind,
+			object:          newAffinityService("default", corev1.ServiceAffinityClientIP, nil),
+			expectedMessage: `Service "hello-service" uses ClientIP session affinity without an explicit sessionAffinityConfig.clientIP.timeoutSeconds (other than the default of 10800)`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject ClientIP affinity with the defaulted timeout",
+			admitFunc:       RequireSessionAffinityTimeout(nil),
+			kind:            serviceKind,
+			object:          newAffinityService("default", corev1.ServiceAffinityClientIP, &defaultTimeout),
+			expectedMessage: `Service "hello-service" uses ClientIP session affinity without an explicit sessionAffinityConfig.clientIP.timeoutSeconds (other than the default of 10800)`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow ClientIP affinity with an explicit timeout",
+			admitFunc:   RequireSessionAffinityTimeout(nil),
+			kind:        serviceKind,
+			object:      newAffinityService("default", corev1.ServiceAffinityClientIP, &explicitTimeout),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Services without session affinity",
+			admitFunc:   RequireSessionAffinityTimeout(nil),
+			kind:        serviceKind,
+			object:      newAffinityService("default", corev1.ServiceAffinityNone, nil),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow ClientIP affinity without a timeout in a whitelisted namespace",
+			admitFunc:   RequireSessionAffinityTimeout([]string{"legacy"}),
+			kind:        serviceKind,
+			object:      newAffinityService("legacy", corev1.ServiceAffinityClientIP, nil),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateHugePages(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newHugePagesPod = func(namespace string, requests, limits corev1.ResourceList) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  "dpdk",
+			Image: "dpdk:v1",
+			Resources: corev1.ResourceRequirements{
+				Requests: requests,
+				Limits:   limits,
+			},
+		}}})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject huge pages with a mismatched limit",
+			admitFunc: ValidateHugePages(nil),
+			kind:      podKind,
+			object: newHugePagesPod("default",
+				corev1.ResourceList{"hugepages-2Mi": resource.MustParse("100Mi"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				corev1.ResourceList{"hugepages-2Mi": resource.MustParse("200Mi"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			),
+			expectedMessage: "the submitted Pods have containers that request huge pages without an equal limit: map[dpdk:[hugepages-2Mi]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject huge pages without a limit",
+			admitFunc: ValidateHugePages(nil),
+			kind:      podKind,
+			object: newHugePagesPod("default",
+				corev1.ResourceList{"hugepages-1Gi": resource.MustParse("2Gi")},
+				nil,
+			),
+			expectedMessage: "the submitted Pods have containers that request huge pages without an equal limit: map[dpdk:[hugepages-1Gi]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow huge pages with an equal limit",
+			admitFunc: ValidateHugePages(nil),
+			kind:      podKind,
+			object: newHugePagesPod("default",
+				corev1.ResourceList{"hugepages-2Mi": resource.MustParse("100Mi"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				corev1.ResourceList{"hugepages-2Mi": resource.MustParse("100Mi"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+			),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow huge pages with only a limit",
+			admitFunc: ValidateHugePages(nil),
+			kind:      podKind,
+			object: newHugePagesPod("default",
+				nil,
+				corev1.ResourceList{"hugepages-2Mi": resource.MustParse("100Mi")},
+			),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow mismatched huge pages in a whitelisted namespace",
+			admitFunc: ValidateHugePages([]string{"dataplane"}),
+			kind:      podKind,
+			object: newHugePagesPod("dataplane",
+				corev1.ResourceList{"hugepages-2Mi": resource.MustParse("100Mi")},
+				nil,
+			),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireChartVersionAnnotation(t *testing.T) {
+	t.Parallel()
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var chartVersionKey = "helm.sh/chart-version"
+
+	var newDeployment = func(namespace string, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace, Annotations: annotations},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				// The Pod template annotations are not inspected.
+				ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{chartVersionKey: "1.2.3"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+			}},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Deployment without the chart version annotation",
+			admitFunc:       RequireChartVersionAnnotation(nil, chartVersionKey),
+			kind:            deploymentKind,
+			object:          newDeployment("default", nil),
+			expectedMessage: `the submitted Deployment "hello-app" is missing the chart version annotation "helm.sh/chart-version"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Deployment with an empty chart version annotation",
+			admitFunc:       RequireChartVersionAnnotation(nil, chartVersionKey),
+			kind:            deploymentKind,
+			object:          newDeployment("default", map[string]string{chartVersionKey: ""}),
+			expectedMessage: `the submitted Deployment "hello-app" is missing the chart version annotation "helm.sh/chart-version"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Deployment with the chart version annotation",
+			admitFunc:   RequireChartVersionAnnotation(nil, chartVersionKey),
+			kind:        deploymentKind,
+			object:      newDeployment("default", map[string]string{chartVersionKey: "1.2.3"}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment without the annotation in a whitelisted namespace",
+			admitFunc:   RequireChartVersionAnnotation([]string{"kube-system"}, chartVersionKey),
+			kind:        deploymentKind,
+			object:      newDeployment("kube-system", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow Pods without the annotation",
+			admitFunc:   RequireChartVersionAnnotation(nil, chartVersionKey),
+			kind:        meta.GroupVersionKind{Group: "", Kind: "Pod", Version: "v1"},
+			object:      newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}}),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an empty annotation key",
+			admitFunc:       RequireChartVersionAnnotation(nil, ""),
+			kind:            deploymentKind,
+			object:          newDeployment("default", map[string]string{chartVersionKey: "1.2.3"}),
+			expectedMessage: "a chart version annotation key must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+// fakeImageVerifier is an ImageVerifier that returns the error for each image
+// reference. Images that are not present fail verification.
+type fakeImageVerifier map[string]error
+
+func (fv fakeImageVerifier) Verify(_ context.Context, image string) error {
+	err, ok := fv[image]
+	if !ok {
+		return errors.New("no matching signatures")
+	}
+
+	return err
+}
+
+func TestRequireSignedImages(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var verifier = fakeImageVerifier{
+		"registry.example.com/signed:v1":  nil,
+		"registry.example.com/sidecar:v1": nil,
+		"registry.example.com/offline:v1": &DependencyError{Err: errors.New("signature store unavailable")},
+	}
+
+	var newImagePod = func(namespace string, images ...string) *corev1.Pod {
+		containers := make([]corev1.Container, 0, len(images))
+		for i, image := range images {
+			containers = append(containers, corev1.Container{Name: fmt.Sprintf("app-%d", i), Image: image})
+		}
+
+		return newTestPod(namespace, corev1.PodSpec{Containers: containers})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Pod with an unsigned image",
+			admitFunc:       RequireSignedImages(nil, verifier),
+			kind:            podKind,
+			object:          newImagePod("default", "registry.example.com/signed:v1", "registry.example.com/unsigned:v1"),
+			expectedMessage: "the submitted Pods have images that failed signature verification: registry.example.com/unsigned:v1 (no matching signatures)",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Pod with signed images",
+			admitFunc:   RequireSignedImages(nil, verifier),
+			kind:        podKind,
+			object:      newImagePod("default", "registry.example.com/signed:v1", "registry.example.com/sidecar:v1"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Return errors reaching the signature store",
+			admitFunc:       RequireSignedImages(nil, verifier),
+			kind:            podKind,
+			object:          newImagePod("default", "registry.example.com/offline:v1"),
+			expectedMessage: "an external dependency failed: signature store unavailable",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow an unsigned image in a whitelisted namespace",
+			admitFunc:   RequireSignedImages([]string{"sandbox"}, verifier),
+			kind:        podKind,
+			object:      newImagePod("sandbox", "registry.example.com/unsigned:v1"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject when no ImageVerifier is provided",
+			admitFunc:       RequireSignedImages(nil, nil),
+			kind:            podKind,
+			object:          newImagePod("default", "registry.example.com/signed:v1"),
+			expectedMessage: "an ImageVerifier must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyDebugCapabilities(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newCapabilityPod = func(namespace string, capabilities ...corev1.Capability) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  "app",
+			Image: "app:v1",
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{Add: capabilities},
+			},
+		}}})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject SYS_PTRACE",
+			admitFunc:       DenyDebugCapabilities(nil),
+			kind:            podKind,
+			object:          newCapabilityPod("default", "NET_BIND_SERVICE", "SYS_PTRACE"),
+			expectedMessage: "the submitted Pods have containers that add debugging capabilities: map[app:[SYS_PTRACE]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a prefixed, lower-case SYS_ADMIN",
+			admitFunc:       DenyDebugCapabilities(nil),
+			kind:            podKind,
+			object:          newCapabilityPod("default", "cap_sys_admin"),
+			expectedMessage: "the submitted Pods have containers that add debugging capabilities: map[app:[cap_sys_admin]]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow NET_BIND_SERVICE",
+			admitFunc:   DenyDebugCapabilities(nil),
+			kind:        podKind,
+			object:      newCapabilityPod("default", "NET_BIND_SERVICE"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow SYS_PTRACE in a whitelisted namespace",
+			admitFunc:   DenyDebugCapabilities([]string{"debug"}),
+			kind:        podKind,
+			object:      newCapabilityPod("debug", "SYS_PTRACE"),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireServiceOwnerLabel(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newLabeledService = func(namespace string, labels map[string]string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace, Labels: labels},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Service without labels",
+			admitFunc:       RequireServiceOwnerLabel(nil, "cost-center"),
+			kind:            serviceKind,
+			object:          newLabeledService("default", nil),
+			expectedMessage: `Service "hello-service" is missing the owner label "cost-center"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Service with an empty owner label",
+			admitFunc:       RequireServiceOwnerLabel(nil, "cost-center"),
+			kind:            serviceKind,
+			object:          newLabeledService("default", map[string]string{"cost-center": ""}),
+			expectedMessage: `Service "hello-service" is missing the owner label "cost-center"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Service with the owner label",
+			admitFunc:   RequireServiceOwnerLabel(nil, "cost-center"),
+			kind:        serviceKind,
+			object:      newLabeledService("default", map[string]string{"cost-center": "cc-1234"}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Service without labels in a whitelisted namespace",
+			admitFunc:   RequireServiceOwnerLabel([]string{"kube-system"}, "cost-center"),
+			kind:        serviceKind,
+			object:      newLabeledService("kube-system", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an empty label key",
+			admitFunc:       RequireServiceOwnerLabel(nil, ""),
+			kind:            serviceKind,
+			object:          newLabeledService("default", map[string]string{"cost-center": "cc-1234"}),
+			expectedMessage: "an owner label key must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireGPUScheduling(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var (
+		gpuSelector   = map[string]string{"cloud.google.com/gke-accelerator": "nvidia-tesla-t4"}
+		gpuToleration = corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	)
+
+	var newGPUPod = func(namespace string, nodeSelector map[string]string, tolerations ...corev1.Toleration) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			NodeSelector: nodeSelector,
+			Tolerations:  tolerations,
+			Containers: []corev1.Container{{
+				Name:  "trainer",
+				Image: "trainer:v1",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a GPU Pod without the required node selector",
+			admitFunc:       RequireGPUScheduling(nil, "nvidia.com/gpu", gpuSelector),
+			kind:            podKind,
+			object:          newGPUPod("default", nil, gpuToleration),
+			expectedMessage: "the submitted Pods request nvidia.com/gpu without the required node selector: map[cloud.google.com/gke-accelerator:nvidia-tesla-t4]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a GPU Pod with a mismatched node selector",
+			admitFunc:       RequireGPUScheduling(nil, "nvidia.com/gpu", gpuSelector),
+			kind:            podKind,
+			object:          newGPUPod("default", map[string]string{"cloud.google.com/gke-accelerator": "nvidia-tesla-a100"}, gpuToleration),
+			expectedMessage: "the submitted Pods request nvidia.com/gpu without the required node selector: map[cloud.google.com/gke-accelerator:nvidia-tesla-t4]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a GPU Pod without a toleration",
+			admitFunc:       RequireGPUScheduling(nil, "nvidia.com/gpu", gpuSelector),
+			kind:            podKind,
+			object:          newGPUPod("default", gpuSelector),
+			expectedMessage: `the submitted Pods request nvidia.com/gpu without tolerating the "nvidia.com/gpu" taint`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a GPU Pod that only tolerates NoExecute",
+			admitFunc:       RequireGPUScheduling(nil, "nvidia.com/gpu", nil),
+			kind:            podKind,
+			object:          newGPUPod("default", nil, corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute}),
+			expectedMessage: `the submitted Pods request nvidia.com/gpu without tolerating the "nvidia.com/gpu" taint`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a GPU Pod with the node selector and toleration",
+			admitFunc:   RequireGPUScheduling(nil, "nvidia.com/gpu", gpuSelector),
+			kind:        podKind,
+			object:      newGPUPod("default", gpuSelector, gpuToleration),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a GPU Pod that tolerates every taint",
+			admitFunc:   RequireGPUScheduling(nil, "nvidia.com/gpu", nil),
+			kind:        podKind,
+			object:      newGPUPod("default", nil, corev1.Toleration{Operator: corev1.TolerationOpExists}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Pod that does not request GPUs",
+			admitFunc:   RequireGPUScheduling(nil, "nvidia.com/gpu", gpuSelector),
+			kind:        podKind,
+			object:      newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a GPU Pod in an ignored namespace",
+			admitFunc:   RequireGPUScheduling([]string{"ml-sandbox"}, "nvidia.com/gpu", gpuSelector),
+			kind:        podKind,
+			object:      newGPUPod("ml-sandbox", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an empty GPU resource name",
+			admitFunc:       RequireGPUScheduling(nil, "", gpuSelector),
+			kind:            podKind,
+			object:          newGPUPod("default", gpuSelector, gpuToleration),
+			expectedMessage: "a GPU resource name must be provided",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject other kinds with an empty GPU resource name",
+			admitFunc: RequireGPUScheduling(nil, "", gpuSelector),
+			kind:      meta.GroupVersionKind{Group: "", Kind: "Service", Version: "v1"},
+			object: &corev1.Service{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+			},
+			expectedMessage: "a GPU resource name must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireProgressDeadline(t *testing.T) {
+	t.Parallel()
+
+	var (
+		defaultDeadline int32 = 600
+		shortDeadline   int32 = 120
+		noDeadline      int32 = math.MaxInt32
+	)
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var newDeployment = func(namespace string, deadline *int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{ProgressDeadlineSeconds: deadline},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Deployment without a progress deadline",
+			admitFunc:       RequireProgressDeadline(nil, 600),
+			kind:            deploymentKind,
+			object:          newDeployment("default", nil),
+			expectedMessage: `Deployment "hello-app" does not set a spec.progressDeadlineSeconds`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject a legacy Deployment with the default (unbounded) progress deadline",
+			admitFunc: RequireProgressDeadline(nil, 600),
+			kind: meta.GroupVersionKind{
+				Group:   "extensions",
+				Kind:    "Deployment",
+				Version: "v1beta1",
+			},
+			object:          newDeployment("default", &noDeadline),
+			expectedMessage: `Deployment "hello-app" does not set a spec.progressDeadlineSeconds`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Deployment with a progress deadline above the maximum",
+			admitFunc:       RequireProgressDeadline(nil, 300),
+			kind:            deploymentKind,
+			object:          newDeployment("default", &defaultDeadline),
+			expectedMessage: `Deployment "hello-app" has a spec.progressDeadlineSeconds of 600, which exceeds the maximum of 300`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Deployment with the default progress deadline",
+			admitFunc:   RequireProgressDeadline(nil, 600),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &defaultDeadline),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment with a progress deadline below the maximum",
+			admitFunc:   RequireProgressDeadline(nil, 300),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &shortDeadline),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment in an ignored namespace",
+			admitFunc:   RequireProgressDeadline([]string{"kube-system"}, 600),
+			kind:        deploymentKind,
+			object:      newDeployment("kube-system", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject a non-positive maximum",
+			admitFunc:       RequireProgressDeadline(nil, 0),
+			kind:            deploymentKind,
+			object:          newDeployment("default", &defaultDeadline),
+			expectedMessage: "the maximum progress deadline must be greater than zero (got 0)",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyUnmaskedProcMount(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var (
+		unmasked     = corev1.UnmaskedProcMount
+		defaultMount = corev1.DefaultProcMount
+	)
+
+	var procMountContainer = func(name string, procMount *corev1.ProcMountType) corev1.Container {
+		return corev1.Container{
+			Name:            name,
+			Image:           name + ":v1",
+			SecurityContext: &corev1.SecurityContext{ProcMount: procMount},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject a container with an Unmasked procMount",
+			admitFunc: DenyUnmaskedProcMount(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				procMountContainer("app", &unmasked),
+			}}),
+			expectedMessage: "the submitted Pods have containers with an Unmasked procMount: [app]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject an initContainer with an Unmasked procMount",
+			admitFunc: DenyUnmaskedProcMount(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{procMountContainer("setup", &unmasked)},
+				Containers:     []corev1.Container{procMountContainer("app", &defaultMount)},
+			}),
+			expectedMessage: "the submitted Pods have containers with an Unmasked procMount: [setup]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow a container with the Default procMount",
+			admitFunc: DenyUnmaskedProcMount(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				procMountContainer("app", &defaultMount),
+			}}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a container without a securityContext",
+			admitFunc:   DenyUnmaskedProcMount(nil),
+			kind:        podKind,
+			object:      newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow an Unmasked procMount in an ignored namespace",
+			admitFunc: DenyUnmaskedProcMount([]string{"kube-system"}),
+			kind:      podKind,
+			object: newTestPod("kube-system", corev1.PodSpec{Containers: []corev1.Container{
+				procMountContainer("app", &unmasked),
+			}}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyExternalEndpoints(t *testing.T) {
+	t.Parallel()
+
+	var (
+		endpointsKind = meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Endpoints"}
+		sliceKind     = meta.GroupVersionKind{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice"}
+		clusterCIDRs  = []string{"10.0.0.0/8", "fd00::/8"}
+	)
+
+	var newEndpoints = func(namespace string, ips ...string) *corev1.Endpoints {
+		addresses := make([]corev1.EndpointAddress, 0, len(ips))
+		for _, ip := range ips {
+			addresses = append(addresses, corev1.EndpointAddress{IP: ip})
+		}
+
+		return &corev1.Endpoints{
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Subsets:    []corev1.EndpointSubset{{Addresses: addresses}},
+		}
+	}
+
+	var newEndpointSlice = func(namespace string, addressType discoveryv1.AddressType, addresses ...string) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta:  meta.ObjectMeta{Name: "hello-service-abcde", Namespace: namespace},
+			AddressType: addressType,
+			Endpoints:   []discoveryv1.Endpoint{{Addresses: addresses}},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject Endpoints with an external IP",
+			admitFunc:       DenyExternalEndpoints(nil, clusterCIDRs),
+			kind:            endpointsKind,
+			object:          newEndpoints("default", "10.4.0.12", "203.0.113.10"),
+			expectedMessage: "the submitted Endpoints has addresses outside of the cluster CIDRs [10.0.0.0/8 fd00::/8]: [203.0.113.10]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject Endpoints with an external not-ready IP",
+			admitFunc: DenyExternalEndpoints(nil, clusterCIDRs),
+			kind:      endpointsKind,
+			object: &corev1.Endpoints{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+				Subsets: []corev1.EndpointSubset{{
+					NotReadyAddresses: []corev1.EndpointAddress{{IP: "192.168.1.20"}},
+				}},
+			},
+			expectedMessage: "the submitted Endpoints has addresses outside of the cluster CIDRs [10.0.0.0/8 fd00::/8]: [192.168.1.20]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject an EndpointSlice with an external IP",
+			admitFunc:       DenyExternalEndpoints(nil, clusterCIDRs),
+			kind:            sliceKind,
+			object:          newEndpointSlice("default", discoveryv1.AddressTypeIPv6, "2001:db8::1"),
+			expectedMessage: "the submitted EndpointSlice has addresses outside of the cluster CIDRs [10.0.0.0/8 fd00::/8]: [2001:db8::1]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject an EndpointSlice with an FQDN address",
+			admitFunc:       DenyExternalEndpoints(nil, clusterCIDRs),
+			kind:            sliceKind,
+			object:          newEndpointSlice("default", discoveryv1.AddressTypeFQDN, "db.example.com"),
+			expectedMessage: "the submitted EndpointSlice has addresses outside of the cluster CIDRs [10.0.0.0/8 fd00::/8]: [db.example.com]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow Endpoints within the cluster CIDRs",
+			admitFunc:   DenyExternalEndpoints(nil, clusterCIDRs),
+			kind:        endpointsKind,
+			object:      newEndpoints("default", "10.4.0.12", "10.8.1.3"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow an EndpointSlice within the cluster CIDRs",
+			admitFunc:   DenyExternalEndpoints(nil, clusterCIDRs),
+			kind:        sliceKind,
+			object:      newEndpointSlice("default", discoveryv1.AddressTypeIPv6, "fd00::12"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow external Endpoints in an ignored namespace",
+			admitFunc:   DenyExternalEndpoints([]string{"kube-system"}, clusterCIDRs),
+			kind:        endpointsKind,
+			object:      newEndpoints("kube-system", "203.0.113.10"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an invalid cluster CIDR",
+			admitFunc:       DenyExternalEndpoints(nil, []string{"10.0.0.0"}),
+			kind:            endpointsKind,
+			object:          newEndpoints("default", "10.4.0.12"),
+			expectedMessage: `the cluster CIDR "10.0.0.0" is invalid: invalid CIDR address: 10.0.0.0`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject an empty list of cluster CIDRs",
+			admitFunc:       DenyExternalEndpoints(nil, nil),
+			kind:            endpointsKind,
+			object:          newEndpoints("default", "10.4.0.12"),
+			expectedMessage: "at least one cluster CIDR must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyServiceLinks(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var (
+		enabled  = true
+		disabled = false
+	)
+
+	var newServiceLinksPod = func(namespace string, enableServiceLinks *bool) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			EnableServiceLinks: enableServiceLinks,
+			Containers:         []corev1.Container{{Name: "app", Image: "app:v1"}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Pod that does not set enableServiceLinks",
+			admitFunc:       DenyServiceLinks(nil),
+			kind:            podKind,
+			object:          newServiceLinksPod("default", nil),
+			expectedMessage: "the submitted Pods must explicitly set enableServiceLinks: false",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Pod that enables service links",
+			admitFunc:       DenyServiceLinks(nil),
+			kind:            podKind,
+			object:          newServiceLinksPod("default", &enabled),
+			expectedMessage: "the submitted Pods must explicitly set enableServiceLinks: false",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Pod that disables service links",
+			admitFunc:   DenyServiceLinks(nil),
+			kind:        podKind,
+			object:      newServiceLinksPod("default", &disabled),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Pod in an ignored namespace",
+			admitFunc:   DenyServiceLinks([]string{"kube-system"}),
+			kind:        podKind,
+			object:      newServiceLinksPod("kube-system", nil),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestLimitAffinityTerms(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newAntiAffinityTerms = func(n int) []corev1.PodAffinityTerm {
+		terms := make([]corev1.PodAffinityTerm, 0, n)
+		for i := 0; i < n; i++ {
+			terms = append(terms, corev1.PodAffinityTerm{
+				LabelSelector: &meta.LabelSelector{MatchLabels: map[string]string{"app": fmt.Sprintf("app-%d", i)}},
+				TopologyKey:   "kubernetes.io/hostname",
+			})
+		}
+
+		return terms
+	}
+
+	var newAffinityPod = func(namespace string, affinity *corev1.Affinity) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Affinity:   affinity,
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject a Pod with 50 affinity terms",
+			admitFunc: LimitAffinityTerms(nil, 10),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: newAntiAffinityTerms(50),
+			}}),
+			expectedMessage: "the submitted Pods have 50 affinity terms, which exceeds the maximum of 10",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject a Pod whose combined affinity terms exceed the maximum",
+			admitFunc: LimitAffinityTerms(nil, 10),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: make([]corev1.NodeSelectorTerm, 4),
+					},
+					PreferredDuringSchedulingIgnoredDuringExecution: make([]corev1.PreferredSchedulingTerm, 2),
+				},
+				PodAffinity: &corev1.PodAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: make([]corev1.WeightedPodAffinityTerm, 3),
+				},
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: newAntiAffinityTerms(2),
+				},
+			}),
+			expectedMessage: "the submitted Pods have 11 affinity terms, which exceeds the maximum of 10",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow a Pod with a modest number of affinity terms",
+			admitFunc: LimitAffinityTerms(nil, 10),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: newAntiAffinityTerms(2),
+			}}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Pod without affinity",
+			admitFunc:   LimitAffinityTerms(nil, 10),
+			kind:        podKind,
+			object:      newAffinityPod("default", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow a Pod in an ignored namespace",
+			admitFunc: LimitAffinityTerms([]string{"kube-system"}, 10),
+			kind:      podKind,
+			object: newAffinityPod("kube-system", &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: newAntiAffinityTerms(50),
+			}}),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject a non-positive maximum",
+			admitFunc:       LimitAffinityTerms(nil, 0),
+			kind:            podKind,
+			object:          newAffinityPod("default", nil),
+			expectedMessage: "the maximum number of affinity terms must be greater than zero (got 0)",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestEnforceFIPSImagePolicy(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	const digest = "@sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac"
+
+	var allowedRegistries = []string{"registry.example.com", "gcr.io/fips-images"}
+
+	var newImagesPod = func(namespace string, images ...string) *corev1.Pod {
+		containers := make([]corev1.Container, 0, len(images))
+		for i, image := range images {
+			containers = append(containers, corev1.Container{Name: fmt.Sprintf("app-%d", i), Image: image})
+		}
+
+		return newTestPod(namespace, corev1.PodSpec{Containers: containers})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject images that are unpinned and from a disallowed registry",
+			admitFunc: EnforceFIPSImagePolicy(nil, allowedRegistries),
+			kind:      podKind,
+			object: newImagesPod("default",
+				"registry.example.com/app:v1",
+				"nginx"+digest,
+			),
+			expectedMessage: "the submitted Pods violate the FIPS image policy: images not pinned to a digest: [registry.example.com/app:v1]; images from registries other than [registry.example.com gcr.io/fips-images]: [nginx" + digest + "]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a single image with both violations",
+			admitFunc:       EnforceFIPSImagePolicy(nil, allowedRegistries),
+			kind:            podKind,
+			object:          newImagesPod("default", "quay.io/app:latest"),
+			expectedMessage: "the submitted Pods violate the FIPS image policy: images not pinned to a digest: [quay.io/app:latest]; images from registries other than [registry.example.com gcr.io/fips-images]: [quay.io/app:latest]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject an image outside an allowed repository prefix",
+			admitFunc:       EnforceFIPSImagePolicy(nil, allowedRegistries),
+			kind:            podKind,
+			object:          newImagesPod("default", "gcr.io/other-project/app"+digest),
+			expectedMessage: "the submitted Pods violate the FIPS image policy: images from registries other than [registry.example.com gcr.io/fips-images]: [gcr.io/other-project/app" + digest + "]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow pinned images from allowed registries",
+			admitFunc: EnforceFIPSImagePolicy(nil, allowedRegistries),
+			kind:      podKind,
+			object: newImagesPod("default",
+				"registry.example.com/app"+digest,
+				"gcr.io/fips-images/base"+digest,
+			),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a pinned Docker Hub image when docker.io is allowed",
+			admitFunc:   EnforceFIPSImagePolicy(nil, []string{"docker.io"}),
+			kind:        podKind,
+			object:      newImagesPod("default", "library/nginx"+digest),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow any image in an ignored namespace",
+			admitFunc:   EnforceFIPSImagePolicy([]string{"kube-system"}, allowedRegistries),
+			kind:        podKind,
+			object:      newImagesPod("kube-system", "nginx:latest"),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an empty list of allowed registries",
+			admitFunc:       EnforceFIPSImagePolicy(nil, nil),
+			kind:            podKind,
+			object:          newImagesPod("default", "registry.example.com/app"+digest),
+			expectedMessage: "at least one allowed registry must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireAppArmorProfile(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var allowedProfiles = []string{"runtime/default", "localhost/k8s-apparmor-example"}
+
+	var newAnnotatedPod = func(namespace string, annotations map[string]string) *corev1.Pod {
+		pod := newTestPod(namespace, corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}})
+		pod.Annotations = annotations
+		return pod
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject a container with an Unconfined annotation",
+			admitFunc: RequireAppArmorProfile(nil, allowedProfiles),
+			kind:      podKind,
+			object: newAnnotatedPod("default", map[string]string{
+				"container.apparmor.security.beta.kubernetes.io/app": "unconfined",
+			}),
+			expectedMessage: "the submitted Pods have containers without an allowed AppArmor profile ([runtime/default localhost/k8s-apparmor-example]): map[app:unconfined]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a container without a profile",
+			admitFunc:       RequireAppArmorProfile(nil, allowedProfiles),
+			kind:            podKind,
+			object:          newAnnotatedPod("default", nil),
+			expectedMessage: "the submitted Pods have containers without an allowed AppArmor profile ([runtime/default localhost/k8s-apparmor-example]): map[app:none]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject a container with a disallowed securityContext profile",
+			admitFunc: RequireAppArmorProfile(nil, allowedProfiles),
+			kind:      podKind,
+			rawObject: []byte(`{
+				"apiVersion": "v1",
+				"kind": "Pod",
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {
+					"securityContext": {"appArmorProfile": {"type": "RuntimeDefault"}},
+					"initContainers": [{"name": "setup", "image": "setup:v1"}],
+					"containers": [{
+						"name": "app",
+						"image": "app:v1",
+						"securityContext": {"appArmorProfile": {"type": "Localhost", "localhostProfile": "custom"}}
+					}]
+				}
+			}`),
+			expectedMessage: "the submitted Pods have containers without an allowed AppArmor profile ([runtime/default localhost/k8s-apparmor-example]): map[app:localhost/custom]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow a container with an allowed annotation",
+			admitFunc: RequireAppArmorProfile(nil, allowedProfiles),
+			kind:      podKind,
+			object: newAnnotatedPod("default", map[string]string{
+				"container.apparmor.security.beta.kubernetes.io/app": "localhost/k8s-apparmor-example",
+			}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow containers with an allowed securityContext profile",
+			admitFunc: RequireAppArmorProfile(nil, allowedProfiles),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "Deployment",
+				Version: "v1",
+			},
+			rawObject: []byte(`{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {"template": {"spec": {
+					"securityContext": {"appArmorProfile": {"type": "RuntimeDefault"}},
+					"containers": [
+						{"name": "app", "image": "app:v1"},
+						{
+							"name": "sidecar",
+							"image": "sidecar:v1",
+							"securityContext": {"appArmorProfile": {"type": "Localhost", "localhostProfile": "k8s-apparmor-example"}}
+						}
+					]
+				}}}
+			}`),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a container without a profile in an ignored namespace",
+			admitFunc:   RequireAppArmorProfile([]string{"kube-system"}, allowedProfiles),
+			kind:        podKind,
+			object:      newAnnotatedPod("kube-system", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an empty list of allowed profiles",
+			admitFunc:       RequireAppArmorProfile(nil, nil),
+			kind:            podKind,
+			object:          newAnnotatedPod("default", nil),
+			expectedMessage: "at least one allowed AppArmor profile must be provided",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRestrictAffinityNamespaceSelectors(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newAffinityTerm = func(namespaceSelector *meta.LabelSelector) corev1.PodAffinityTerm {
+		return corev1.PodAffinityTerm{
+			LabelSelector:     &meta.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+			NamespaceSelector: namespaceSelector,
+			TopologyKey:       "topology.kubernetes.io/zone",
+		}
+	}
+
+	var newAffinityPod = func(namespace string, affinity *corev1.Affinity) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{
+			Affinity:   affinity,
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+		})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:  "Reject a pod affinity term selecting all namespaces",
+			admitFunc: RestrictAffinityNamespaceSelectors(nil),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					newAffinityTerm(&meta.LabelSelector{}),
+				},
+			}}),
+			expectedMessage: "the submitted Pods have pod affinity terms with an empty namespaceSelector, which matches all namespaces (topologyKey: topology.kubernetes.io/zone)",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject a preferred anti-affinity term selecting all namespaces",
+			admitFunc: RestrictAffinityNamespaceSelectors(nil),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{Weight: 100, PodAffinityTerm: newAffinityTerm(&meta.LabelSelector{})},
+				},
+			}}),
+			expectedMessage: "the submitted Pods have pod affinity terms with an empty namespaceSelector, which matches all namespaces (topologyKey: topology.kubernetes.io/zone)",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Allow a pod affinity term with a scoped namespaceSelector",
+			admitFunc: RestrictAffinityNamespaceSelectors(nil),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					newAffinityTerm(&meta.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}),
+				},
+			}}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow a pod affinity term without a namespaceSelector",
+			admitFunc: RestrictAffinityNamespaceSelectors(nil),
+			kind:      podKind,
+			object: newAffinityPod("default", &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{newAffinityTerm(nil)},
+			}}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Allow an all-namespaces selector in an ignored namespace",
+			admitFunc: RestrictAffinityNamespaceSelectors([]string{"kube-system"}),
+			kind:      podKind,
+			object: newAffinityPod("kube-system", &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					newAffinityTerm(&meta.LabelSelector{}),
+				},
+			}}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestWarnClusterTrafficPolicyOnLB(t *testing.T) {
+	t.Parallel()
+
+	var serviceKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Service",
+		Version: "v1",
+	}
+
+	var newTrafficPolicyService = func(namespace string, serviceType corev1.ServiceType, policy corev1.ServiceExternalTrafficPolicyType) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Type:                  serviceType,
+				ExternalTrafficPolicy: policy,
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		}
+	}
+
+	const clusterPolicyWarning = `LoadBalancer Service "hello-service" uses externalTrafficPolicy: Cluster, which does not preserve client source IPs: use externalTrafficPolicy: Local instead`
+
+	var denyTests = []objectTest{
+		{
+			testName:         "Warn on a LoadBalancer with the Cluster policy",
+			admitFunc:        WarnClusterTrafficPolicyOnLB(nil),
+			kind:             serviceKind,
+			object:           newTrafficPolicyService("default", corev1.ServiceTypeLoadBalancer, corev1.ServiceExternalTrafficPolicyTypeCluster),
+			expectedWarnings: []string{clusterPolicyWarning},
+			shouldAllow:      true,
+		},
+		{
+			testName:         "Warn on a LoadBalancer without a policy",
+			admitFunc:        WarnClusterTrafficPolicyOnLB(nil),
+			kind:             serviceKind,
+			object:           newTrafficPolicyService("default", corev1.ServiceTypeLoadBalancer, ""),
+			expectedWarnings: []string{clusterPolicyWarning},
+			shouldAllow:      true,
+		},
+		{
+			testName:    "Don't warn on a LoadBalancer with the Local policy",
+			admitFunc:   WarnClusterTrafficPolicyOnLB(nil),
+			kind:        serviceKind,
+			object:      newTrafficPolicyService("default", corev1.ServiceTypeLoadBalancer, corev1.ServiceExternalTrafficPolicyTypeLocal),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Don't warn on a NodePort with the Cluster policy",
+			admitFunc:   WarnClusterTrafficPolicyOnLB(nil),
+			kind:        serviceKind,
+			object:      newTrafficPolicyService("default", corev1.ServiceTypeNodePort, corev1.ServiceExternalTrafficPolicyTypeCluster),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Don't warn in an ignored namespace",
+			admitFunc:   WarnClusterTrafficPolicyOnLB([]string{"kube-system"}),
+			kind:        serviceKind,
+			object:      newTrafficPolicyService("kube-system", corev1.ServiceTypeLoadBalancer, corev1.ServiceExternalTrafficPolicyTypeCluster),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestDenyPullPolicyChanges(t *testing.T) {
+	t.Parallel()
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var newDeployment = func(annotations map[string]string, containers ...corev1.Container) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default", Annotations: annotations},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: containers},
+			}},
+		}
+	}
+
+	var (
+		alwaysPull    = corev1.Container{Name: "app", Image: "app:v2", ImagePullPolicy: corev1.PullAlways}
+		ifNotPresent  = corev1.Container{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullIfNotPresent}
+		newSidecar    = corev1.Container{Name: "sidecar", Image: "sidecar:v1", ImagePullPolicy: corev1.PullAlways}
+		allowOverride = map[string]string{AllowPullPolicyChangeAnnotation: "true"}
+	)
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject an update that changes the pull policy",
+			admitFunc:       DenyPullPolicyChanges(),
+			kind:            deploymentKind,
+			operation:       admission.Update,
+			object:          newDeployment(nil, alwaysPull),
+			oldObject:       newDeployment(nil, ifNotPresent),
+			expectedMessage: `the submitted Pods change the imagePullPolicy of existing containers without the "admission-control.elithrar.github.io/allow-pull-policy-change" annotation: map[app:IfNotPresent -> Always]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow an update with an unchanged pull policy",
+			admitFunc:   DenyPullPolicyChanges(),
+			kind:        deploymentKind,
+			operation:   admission.Update,
+			object:      newDeployment(nil, corev1.Container{Name: "app", Image: "app:v2", ImagePullPolicy: corev1.PullIfNotPresent}),
+			oldObject:   newDeployment(nil, ifNotPresent),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow an update that adds a container",
+			admitFunc:   DenyPullPolicyChanges(),
+			kind:        deploymentKind,
+			operation:   admission.Update,
+			object:      newDeployment(nil, ifNotPresent, newSidecar),
+			oldObject:   newDeployment(nil, ifNotPresent),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a pull policy change with the allow annotation",
+			admitFunc:   DenyPullPolicyChanges(),
+			kind:        deploymentKind,
+			operation:   admission.Update,
+			object:      newDeployment(allowOverride, alwaysPull),
+			oldObject:   newDeployment(nil, ifNotPresent),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow creating a Deployment",
+			admitFunc:   DenyPullPolicyChanges(),
+			kind:        deploymentKind,
+			operation:   admission.Create,
+			object:      newDeployment(nil, alwaysPull),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateQoSConsistency(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newResources = func(cpu, memory string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+	}
+
+	var newQoSPod = func(namespace string, containers ...corev1.ResourceRequirements) *corev1.Pod {
+		spec := corev1.PodSpec{}
+		for i, resources := range containers {
+			spec.Containers = append(spec.Containers, corev1.Container{
+				Name:      fmt.Sprintf("app-%d", i),
+				Image:     "app:v1",
+				Resources: resources,
+			})
+		}
+
+		return newTestPod(namespace, spec)
+	}
+
+	var (
+		guaranteed = corev1.ResourceRequirements{Requests: newResources("500m", "256Mi"), Limits: newResources("500m", "256Mi")}
+		burstable  = corev1.ResourceRequirements{Requests: newResources("250m", "256Mi"), Limits: newResources("500m", "256Mi")}
+	)
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Burstable Pod when Guaranteed is required",
+			admitFunc:       ValidateQoSConsistency(nil, corev1.PodQOSGuaranteed),
+			kind:            podKind,
+			object:          newQoSPod("default", burstable),
+			expectedMessage: "the submitted Pods have a QoS class of Burstable, but must be Guaranteed",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Pod with one container lacking limits when Guaranteed is required",
+			admitFunc:       ValidateQoSConsistency(nil, corev1.PodQOSGuaranteed),
+			kind:            podKind,
+			object:          newQoSPod("default", guaranteed, corev1.ResourceRequirements{}),
+			expectedMessage: "the submitted Pods have a QoS class of Burstable, but must be Guaranteed",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a BestEffort Pod when Burstable is required",
+			admitFunc:       ValidateQoSConsistency(nil, corev1.PodQOSBurstable),
+			kind:            podKind,
+			object:          newQoSPod("default", corev1.ResourceRequirements{}),
+			expectedMessage: "the submitted Pods have a QoS class of BestEffort, but must be Burstable",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Guaranteed Pod when Guaranteed is required",
+			admitFunc:   ValidateQoSConsistency(nil, corev1.PodQOSGuaranteed),
+			kind:        podKind,
+			object:      newQoSPod("default", guaranteed, guaranteed),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Pod with only limits when Guaranteed is required",
+			admitFunc:   ValidateQoSConsistency(nil, corev1.PodQOSGuaranteed),
+			kind:        podKind,
+			object:      newQoSPod("default", corev1.ResourceRequirements{Limits: newResources("1", "1Gi")}),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Burstable Pod in an ignored namespace",
+			admitFunc:   ValidateQoSConsistency([]string{"batch"}, corev1.PodQOSGuaranteed),
+			kind:        podKind,
+			object:      newQoSPod("batch", burstable),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject an invalid QoS class",
+			admitFunc:       ValidateQoSConsistency(nil, "Premium"),
+			kind:            podKind,
+			object:          newQoSPod("default", guaranteed),
+			expectedMessage: `"Premium" is not a valid QoS class`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject other kinds with an invalid QoS class",
+			admitFunc: ValidateQoSConsistency(nil, "Premium"),
+			kind:      meta.GroupVersionKind{Group: "", Kind: "Service", Version: "v1"},
+			object: &corev1.Service{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+			},
+			expectedMessage: `"Premium" is not a valid QoS class`,
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireZoneSpread(t *testing.T) {
+	t.Parallel()
+
+	var (
+		one   int32 = 1
+		three int32 = 3
+	)
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var newSpreadConstraint = func(topologyKey string) corev1.TopologySpreadConstraint {
+		return corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     &meta.LabelSelector{MatchLabels: map[string]string{"app": "hello"}},
+		}
+	}
+
+	var newDeployment = func(namespace string, replicas *int32, constraints ...corev1.TopologySpreadConstraint) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					TopologySpreadConstraints: constraints,
+					Containers:                []corev1.Container{{Name: "app", Image: "app:v1"}},
+				}},
+			},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a 3-replica Deployment without zone spread",
+			admitFunc:       RequireZoneSpread(nil),
+			kind:            deploymentKind,
+			object:          newDeployment("default", &three),
+			expectedMessage: `Deployment "hello-app" has 3 replicas, but no topologySpreadConstraint with a topologyKey of "topology.kubernetes.io/zone"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a 3-replica Deployment spread only across hosts",
+			admitFunc:       RequireZoneSpread(nil),
+			kind:            deploymentKind,
+			object:          newDeployment("default", &three, newSpreadConstraint("kubernetes.io/hostname")),
+			expectedMessage: `Deployment "hello-app" has 3 replicas, but no topologySpreadConstraint with a topologyKey of "topology.kubernetes.io/zone"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject a 3-replica StatefulSet without zone spread",
+			admitFunc: RequireZoneSpread(nil),
+			kind: meta.GroupVersionKind{
+				Group:   "apps",
+				Kind:    "StatefulSet",
+				Version: "v1",
+			},
+			object: &appsv1.StatefulSet{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-db", Namespace: "default"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &three},
+			},
+			expectedMessage: `StatefulSet "hello-db" has 3 replicas, but no topologySpreadConstraint with a topologyKey of "topology.kubernetes.io/zone"`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a 3-replica Deployment with zone spread",
+			admitFunc:   RequireZoneSpread(nil),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &three, newSpreadConstraint("kubernetes.io/hostname"), newSpreadConstraint("topology.kubernetes.io/zone")),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a 3-replica Deployment with the beta zone label",
+			admitFunc:   RequireZoneSpread(nil),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &three, newSpreadConstraint("failure-domain.beta.kubernetes.io/zone")),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a single-replica Deployment",
+			admitFunc:   RequireZoneSpread(nil),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &one),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment without a replica count",
+			admitFunc:   RequireZoneSpread(nil),
+			kind:        deploymentKind,
+			object:      newDeployment("default", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment in an ignored namespace",
+			admitFunc:   RequireZoneSpread([]string{"kube-system"}),
+			kind:        deploymentKind,
+			object:      newDeployment("kube-system", &three),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newWorkingDirPod = func(namespace string, workingDir string) *corev1.Pod {
+		return newTestPod(namespace, corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app", Image: "app:v1", WorkingDir: workingDir},
+		}})
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a workingDir with path traversal",
+			admitFunc:       ValidateWorkingDir(nil),
+			kind:            podKind,
+			object:          newWorkingDirPod("default", "/app/../etc"),
+			expectedMessage: "the submitted Pods have containers with a workingDir that is not a clean, absolute path: map[app:/app/../etc]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a relative workingDir",
+			admitFunc:       ValidateWorkingDir(nil),
+			kind:            podKind,
+			object:          newWorkingDirPod("default", "app"),
+			expectedMessage: "the submitted Pods have containers with a workingDir that is not a clean, absolute path: map[app:app]",
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject an initContainer workingDir with redundant separators",
+			admitFunc: ValidateWorkingDir(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "setup", Image: "setup:v1", WorkingDir: "/app//./data"}},
+				Containers:     []corev1.Container{{Name: "app", Image: "app:v1", WorkingDir: "/app"}},
+			}),
+			expectedMessage: "the submitted Pods have containers with a workingDir that is not a clean, absolute path: map[setup:/app//./data]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a clean, absolute workingDir",
+			admitFunc:   ValidateWorkingDir(nil),
+			kind:        podKind,
+			object:      newWorkingDirPod("default", "/app"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a workingDir with a trailing slash",
+			admitFunc:   ValidateWorkingDir(nil),
+			kind:        podKind,
+			object:      newWorkingDirPod("default", "/srv/app/"),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow an unset workingDir",
+			admitFunc:   ValidateWorkingDir(nil),
+			kind:        podKind,
+			object:      newWorkingDirPod("default", ""),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow path traversal in an ignored namespace",
+			admitFunc:   ValidateWorkingDir([]string{"kube-system"}),
+			kind:        podKind,
+			object:      newWorkingDirPod("kube-system", "/app/../etc"),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestValidateExclusiveCPU(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var newCPUPod = func(namespace string, exclusive bool, requests, limits corev1.ResourceList) *corev1.Pod {
+		pod := newTestPod(namespace, corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "worker",
+			Image:     "worker:v1",
+			Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits},
+		}}})
+
+		if exclusive {
+			pod.Annotations = map[string]string{ExclusiveCPUAnnotation: "true"}
+		}
+
+		return pod
+	}
+
+	var newResources = func(cpu string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a fractional-CPU exclusive request",
+			admitFunc:       ValidateExclusiveCPU(nil),
+			kind:            podKind,
+			object:          newCPUPod("default", true, newResources("1500m"), newResources("1500m")),
+			expectedMessage: "the submitted Pods request exclusive CPUs, but have containers with non-integer CPU limits: map[worker:1500m]",
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Burstable exclusive request",
+			admitFunc:       ValidateExclusiveCPU(nil),
+			kind:            podKind,
+			object:          newCPUPod("default", true, newResources("1"), newResources("2")),
+			expectedMessage: "the submitted Pods request exclusive CPUs, but have a QoS class of Burstable (not Guaranteed)",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow an integer-CPU exclusive request",
+			admitFunc:   ValidateExclusiveCPU(nil),
+			kind:        podKind,
+			object:      newCPUPod("default", true, newResources("2"), newResources("2")),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a fractional-CPU Pod that does not request exclusive CPUs",
+			admitFunc:   ValidateExclusiveCPU(nil),
+			kind:        podKind,
+			object:      newCPUPod("default", false, newResources("1500m"), newResources("1500m")),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a fractional-CPU exclusive request in an ignored namespace",
+			admitFunc:   ValidateExclusiveCPU([]string{"kube-system"}),
+			kind:        podKind,
+			object:      newCPUPod("kube-system", true, newResources("1500m"), newResources("1500m")),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestRequireRevisionHistoryLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		defaultLimit int32 = 10
+		saneLimit    int32 = 3
+		unbounded    int32 = math.MaxInt32
+	)
+
+	var deploymentKind = meta.GroupVersionKind{
+		Group:   "apps",
+		Kind:    "Deployment",
+		Version: "v1",
+	}
+
+	var newDeployment = func(namespace string, limit *int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{RevisionHistoryLimit: limit},
+		}
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject a Deployment without a revision history limit",
+			admitFunc:       RequireRevisionHistoryLimit(nil, 10),
+			kind:            deploymentKind,
+			object:          newDeployment("default", nil),
+			expectedMessage: `Deployment "hello-app" does not set a spec.revisionHistoryLimit`,
+			shouldAllow:     false,
+		},
+		{
+			testName:  "Reject a legacy Deployment with the default (unbounded) limit",
+			admitFunc: RequireRevisionHistoryLimit(nil, 10),
+			kind: meta.GroupVersionKind{
+				Group:   "extensions",
+				Kind:    "Deployment",
+				Version: "v1beta1",
+			},
+			object:          newDeployment("default", &unbounded),
+			expectedMessage: `Deployment "hello-app" does not set a spec.revisionHistoryLimit`,
+			shouldAllow:     false,
+		},
+		{
+			testName:        "Reject a Deployment with a limit above the maximum",
+			admitFunc:       RequireRevisionHistoryLimit(nil, 5),
+			kind:            deploymentKind,
+			object:          newDeployment("default", &defaultLimit),
+			expectedMessage: `Deployment "hello-app" has a spec.revisionHistoryLimit of 10, which exceeds the maximum of 5`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a Deployment with a sane limit",
+			admitFunc:   RequireRevisionHistoryLimit(nil, 5),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &saneLimit),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment with the default limit",
+			admitFunc:   RequireRevisionHistoryLimit(nil, 10),
+			kind:        deploymentKind,
+			object:      newDeployment("default", &defaultLimit),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a Deployment in an ignored namespace",
+			admitFunc:   RequireRevisionHistoryLimit([]string{"kube-system"}, 10),
+			kind:        deploymentKind,
+			object:      newDeployment("kube-system", nil),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject a negative maximum",
+			admitFunc:       RequireRevisionHistoryLimit(nil, -1),
+			kind:            deploymentKind,
+			object:          newDeployment("default", &saneLimit),
+			expectedMessage: "the maximum revision history limit must not be negative (got -1)",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
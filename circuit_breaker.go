@@ -0,0 +1,128 @@
+package admissioncontrol
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// DependencyError indicates that an AdmitFunc could not evaluate a request
+// because an external dependency - such as a container registry, or the
+// apiserver - failed. AdmitFuncs that call external systems should wrap those
+// failures in a DependencyError, so that they can be distinguished from
+// denials by WithCircuitBreaker.
+type DependencyError struct {
+	Err error
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("an external dependency failed: %s", e.Err)
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// CircuitBreakerOptions configures WithCircuitBreaker.
+type CircuitBreakerOptions struct {
+	// Threshold is the number of consecutive DependencyErrors after which the
+	// breaker trips. Defaults to 5.
+	Threshold int
+	// Cooldown is how long the breaker stays open before the wrapped AdmitFunc
+	// is tried again. Defaults to 30 seconds.
+	Cooldown time.Duration
+	// FailurePolicy determines whether requests are allowed or denied while the
+	// breaker is open.
+	FailurePolicy FailurePolicy
+}
+
+// circuitBreaker tracks the consecutive DependencyErrors of an AdmitFunc.
+type circuitBreaker struct {
+	admitFunc AdmitFunc
+	opts      CircuitBreakerOptions
+	// now returns the current time, and allows tests to control the cooldown.
+	now func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// WithCircuitBreaker wraps an AdmitFunc that depends on external systems. After
+// opts.Threshold consecutive DependencyErrors, the breaker trips: for the
+// following opts.Cooldown, requests are allowed or denied as per
+// opts.FailurePolicy without invoking the wrapped AdmitFunc. Once the cooldown
+// has elapsed, the next request is passed through, and the breaker resets if it
+// succeeds, or trips again if it fails.
+//
+// Errors that are not DependencyErrors (i.e. denials) are returned as-is, and
+// reset the count of consecutive failures.
+func WithCircuitBreaker(admitFunc AdmitFunc, opts CircuitBreakerOptions) AdmitFunc {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 5
+	}
+
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = time.Second * 30
+	}
+
+	cb := &circuitBreaker{
+		admitFunc: admitFunc,
+		opts:      opts,
+		now:       time.Now,
+	}
+
+	return cb.admit
+}
+
+func (cb *circuitBreaker) admit(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+	cb.mu.Lock()
+	open := cb.now().Before(cb.openUntil)
+	cb.mu.Unlock()
+
+	if open {
+		return cb.fallback()
+	}
+
+	resp, err := cb.admitFunc(admissionReview)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	var depErr *DependencyError
+	if err != nil && xerrors.As(err, &depErr) {
+		cb.failures++
+		if cb.failures >= cb.opts.Threshold {
+			cb.openUntil = cb.now().Add(cb.opts.Cooldown)
+			// A single failure after the cooldown re-opens the breaker.
+			cb.failures = cb.opts.Threshold - 1
+		}
+
+		return resp, err
+	}
+
+	cb.failures = 0
+	cb.openUntil = time.Time{}
+	return resp, err
+}
+
+// fallback returns the decision of the configured FailurePolicy.
+func (cb *circuitBreaker) fallback() (*admission.AdmissionResponse, error) {
+	resp := newDefaultDenyResponse()
+	if cb.opts.FailurePolicy != FailOpen {
+		return resp, denyf(
+			ReasonDependencyUnavailable,
+			"the admission webhook's dependencies are unavailable, and the request could not be evaluated",
+		)
+	}
+
+	resp.Allowed = true
+	resp.Warnings = append(resp.Warnings,
+		"the admission webhook's dependencies are unavailable: admission was allowed without being evaluated",
+	)
+
+	return resp, nil
+}
@@ -0,0 +1,124 @@
+package admissioncontrol
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var breakerTests = []struct {
+		testName      string
+		failurePolicy FailurePolicy
+		shouldAllow   bool
+	}{
+		{
+			testName:      "Open breaker denies when failing closed",
+			failurePolicy: FailClosed,
+			shouldAllow:   false,
+		},
+		{
+			testName:      "Open breaker allows when failing open",
+			failurePolicy: FailOpen,
+			shouldAllow:   true,
+		},
+	}
+
+	for _, tt := range breakerTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			var (
+				calls   int
+				healthy bool
+			)
+
+			admitFunc := func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+				calls++
+				resp := newDefaultDenyResponse()
+				if !healthy {
+					return resp, &DependencyError{Err: errors.New("registry unavailable")}
+				}
+
+				resp.Allowed = true
+				return resp, nil
+			}
+
+			now := time.Now()
+			cb := &circuitBreaker{
+				admitFunc: admitFunc,
+				opts:      CircuitBreakerOptions{Threshold: 3, Cooldown: time.Minute, FailurePolicy: tt.failurePolicy},
+				now:       func() time.Time { return now },
+			}
+
+			review := &admission.AdmissionReview{Request: &admission.AdmissionRequest{}}
+
+			// Trip the breaker.
+			for i := 0; i < 3; i++ {
+				if _, err := cb.admit(review); err == nil {
+					t.Fatalf("expected a DependencyError on call %d", i+1)
+				}
+			}
+
+			// While open, the fallback decision is returned without calling the
+			// underlying AdmitFunc.
+			resp, err := cb.admit(review)
+			if calls != 3 {
+				t.Fatalf("the open breaker called the AdmitFunc: %d calls (wanted 3)", calls)
+			}
+
+			if allowed := err == nil && resp.Allowed; allowed != tt.shouldAllow {
+				t.Fatalf("fallback decision does not match: got allowed: %t (wanted %t)", allowed, tt.shouldAllow)
+			}
+
+			if !tt.shouldAllow && ReasonCodeOf(err) != ReasonDependencyUnavailable {
+				t.Fatalf("unexpected reason code: %q", ReasonCodeOf(err))
+			}
+
+			// After the cooldown, a single failure re-opens the breaker.
+			now = now.Add(time.Minute * 2)
+			cb.admit(review)
+			cb.admit(review)
+			if calls != 4 {
+				t.Fatalf("the breaker did not re-open after a failed trial: %d calls (wanted 4)", calls)
+			}
+
+			// A successful trial closes the breaker.
+			now = now.Add(time.Minute * 2)
+			healthy = true
+			for i := 0; i < 2; i++ {
+				if resp, err := cb.admit(review); err != nil || !resp.Allowed {
+					t.Fatalf("expected the closed breaker to allow: %v", err)
+				}
+			}
+
+			if calls != 6 {
+				t.Fatalf("the breaker did not close: %d calls (wanted 6)", calls)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerIgnoresDenials(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	admitFunc := WithCircuitBreaker(func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		calls++
+		return newDefaultDenyResponse(), errors.New("denied")
+	}, CircuitBreakerOptions{Threshold: 1})
+
+	review := &admission.AdmissionReview{Request: &admission.AdmissionRequest{}}
+	for i := 0; i < 3; i++ {
+		if _, err := admitFunc(review); err == nil || err.Error() != "denied" {
+			t.Fatalf("expected the denial to be returned as-is: %v", err)
+		}
+	}
+
+	if calls != 3 {
+		t.Fatalf("denials should not trip the breaker: %d calls (wanted 3)", calls)
+	}
+}
@@ -0,0 +1,149 @@
+package admissioncontrol
+
+import (
+	"crypto/tls"
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// The environment variables read by LoadConfigFromEnv.
+const (
+	EnvHost          = "ADMISSION_HOST"
+	EnvPort          = "ADMISSION_PORT"
+	EnvTLSCertPath   = "ADMISSION_TLS_CERT_PATH"
+	EnvTLSKeyPath    = "ADMISSION_TLS_KEY_PATH"
+	EnvHTTPOnly      = "ADMISSION_HTTP_ONLY"
+	EnvGracePeriod   = "ADMISSION_GRACE_PERIOD"
+	EnvFailurePolicy = "ADMISSION_FAILURE_POLICY"
+)
+
+// Config represents the common configuration for a server hosting one or more
+// AdmissionHandlers.
+//
+// Use LoadConfigFromEnv or LoadConfigFromFlags to create a Config.
+type Config struct {
+	// Host is the hostname the server is reachable at, and is used as the
+	// ServerName in its TLS configuration.
+	Host string
+	// Port is the port to listen on.
+	Port string
+	// TLSCertPath is the path to the PEM-encoded TLS certificate.
+	TLSCertPath string
+	// TLSKeyPath is the path to the unencrypted, PEM-encoded TLS key.
+	TLSKeyPath string
+	// HTTPOnly serves plaintext HTTP only, for environments where TLS is
+	// terminated by a proxy.
+	HTTPOnly bool
+	// GracePeriod is how long in-flight requests are given to complete when
+	// the server shuts down.
+	GracePeriod time.Duration
+	// FailurePolicy determines whether requests that cannot be evaluated are
+	// allowed or denied.
+	FailurePolicy FailurePolicy
+}
+
+// newDefaultConfig returns a Config populated with our default values.
+func newDefaultConfig() *Config {
+	return &Config{
+		Port:          "8443",
+		TLSCertPath:   "./cert.crt",
+		TLSKeyPath:    "./key.key",
+		GracePeriod:   defaultGracePeriod,
+		FailurePolicy: FailClosed,
+	}
+}
+
+// LoadConfigFromEnv creates a Config from the ADMISSION_* environment
+// variables. Unset variables retain their default values, and an error is
+// returned for any value that cannot be parsed.
+func LoadConfigFromEnv() (*Config, error) {
+	return loadConfigFromEnv(os.Getenv)
+}
+
+func loadConfigFromEnv(getenv func(key string) string) (*Config, error) {
+	conf := newDefaultConfig()
+
+	if val := getenv(EnvHost); val != "" {
+		conf.Host = val
+	}
+
+	if val := getenv(EnvPort); val != "" {
+		if port, err := strconv.Atoi(val); err != nil || port < 1 || port > 65535 {
+			return nil, xerrors.Errorf("invalid value for %s: %q is not a valid port", EnvPort, val)
+		}
+		conf.Port = val
+	}
+
+	if val := getenv(EnvTLSCertPath); val != "" {
+		conf.TLSCertPath = val
+	}
+
+	if val := getenv(EnvTLSKeyPath); val != "" {
+		conf.TLSKeyPath = val
+	}
+
+	if val := getenv(EnvHTTPOnly); val != "" {
+		httpOnly, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid value for %s: %w", EnvHTTPOnly, err)
+		}
+		conf.HTTPOnly = httpOnly
+	}
+
+	if val := getenv(EnvGracePeriod); val != "" {
+		gracePeriod, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid value for %s: %w", EnvGracePeriod, err)
+		}
+		conf.GracePeriod = gracePeriod
+	}
+
+	if val := getenv(EnvFailurePolicy); val != "" {
+		if err := conf.FailurePolicy.Set(val); err != nil {
+			return nil, xerrors.Errorf("invalid value for %s: %w", EnvFailurePolicy, err)
+		}
+	}
+
+	return conf, nil
+}
+
+// LoadConfigFromFlags registers the configuration flags on the provided
+// FlagSet, and returns a Config that is populated once the FlagSet is parsed.
+//
+// Pass flag.CommandLine to register the flags globally, and call flag.Parse
+// before using the returned Config.
+func LoadConfigFromFlags(fs *flag.FlagSet) *Config {
+	conf := newDefaultConfig()
+
+	fs.StringVar(&conf.TLSCertPath, "cert-path", conf.TLSCertPath, "The path to the PEM-encoded TLS certificate")
+	fs.StringVar(&conf.TLSKeyPath, "key-path", conf.TLSKeyPath, "The path to the unencrypted TLS key")
+	fs.BoolVar(&conf.HTTPOnly, "http-only", conf.HTTPOnly, "Only listen on unencrypted HTTP (e.g. for proxied environments)")
+	fs.StringVar(&conf.Port, "port", conf.Port, "The port to listen on (HTTPS).")
+	fs.StringVar(&conf.Host, "host", conf.Host, "The hostname for the service")
+	fs.DurationVar(&conf.GracePeriod, "grace-period", conf.GracePeriod, "How long to wait for in-flight requests to complete on shutdown")
+	fs.Var(&conf.FailurePolicy, "failure-policy", "Whether to allow (Ignore) or deny (Fail) requests that cannot be evaluated")
+
+	return conf
+}
+
+// TLSConfig loads the configured TLS keypair, and returns a *tls.Config for
+// serving it. It returns a nil *tls.Config if HTTPOnly is set.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c.HTTPOnly {
+		return nil, nil
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(c.TLSCertPath, c.TLSKeyPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load the TLS keypair: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+		ServerName:   c.Host,
+	}, nil
+}
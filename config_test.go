@@ -0,0 +1,119 @@
+package admissioncontrol
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Parallel()
+
+	var envTests = []struct {
+		testName string
+		env      map[string]string
+		expected *Config
+		wantErr  bool
+	}{
+		{
+			testName: "Defaults are used when no variables are set",
+			env:      map[string]string{},
+			expected: newDefaultConfig(),
+		},
+		{
+			testName: "All variables are parsed",
+			env: map[string]string{
+				EnvHost:          "admissiond.example.com",
+				EnvPort:          "9443",
+				EnvTLSCertPath:   "/certs/tls.crt",
+				EnvTLSKeyPath:    "/certs/tls.key",
+				EnvHTTPOnly:      "true",
+				EnvGracePeriod:   "30s",
+				EnvFailurePolicy: "Ignore",
+			},
+			expected: &Config{
+				Host:          "admissiond.example.com",
+				Port:          "9443",
+				TLSCertPath:   "/certs/tls.crt",
+				TLSKeyPath:    "/certs/tls.key",
+				HTTPOnly:      true,
+				GracePeriod:   time.Second * 30,
+				FailurePolicy: FailOpen,
+			},
+		},
+		{
+			testName: "Invalid port",
+			env:      map[string]string{EnvPort: "https"},
+			wantErr:  true,
+		},
+		{
+			testName: "Out of range port",
+			env:      map[string]string{EnvPort: "70000"},
+			wantErr:  true,
+		},
+		{
+			testName: "Invalid boolean",
+			env:      map[string]string{EnvHTTPOnly: "sometimes"},
+			wantErr:  true,
+		},
+		{
+			testName: "Invalid duration",
+			env:      map[string]string{EnvGracePeriod: "15"},
+			wantErr:  true,
+		},
+		{
+			testName: "Invalid failure policy",
+			env:      map[string]string{EnvFailurePolicy: "Allow"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range envTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			conf, err := loadConfigFromEnv(func(key string) string { return tt.env[key] })
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got config: %#v", conf)
+				}
+
+				t.Logf("correctly returned an error: %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *conf != *tt.expected {
+				t.Fatalf("config does not match: got %#v - wanted %#v", conf, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromFlags(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := LoadConfigFromFlags(fs)
+	if err := fs.Parse([]string{"-port", "9443", "-http-only", "-failure-policy", "Ignore"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	expected := newDefaultConfig()
+	expected.Port = "9443"
+	expected.HTTPOnly = true
+	expected.FailurePolicy = FailOpen
+	if *conf != *expected {
+		t.Fatalf("config does not match: got %#v - wanted %#v", conf, expected)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	LoadConfigFromFlags(fs)
+	if err := fs.Parse([]string{"-failure-policy", "Allow"}); err == nil {
+		t.Fatalf("expected an invalid -failure-policy to return an error")
+	}
+}
@@ -0,0 +1,136 @@
+package admissioncontrol
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// DecisionCache memoizes the allow decisions of an AdmitFunc for identical
+// requests, for a fixed TTL. This avoids repeating expensive AdmitFuncs - such
+// as those that call external systems - when the apiserver sends the same
+// object repeatedly, such as during a rollout.
+//
+// Requests are keyed on a hash of the requested Kind, namespace, name,
+// subresource, operation, requesting user (and their groups), and the
+// submitted & existing objects. Denials are never cached, so that a fixed
+// object is re-evaluated immediately.
+//
+// A DecisionCache is safe for concurrent use, but should not be shared between
+// AdmissionHandlers with different AdmitFuncs.
+type DecisionCache struct {
+	ttl time.Duration
+	// now returns the current time, and allows tests to control expiry.
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]cachedDecision
+	lastSweep time.Time
+}
+
+// cachedDecision is an AdmissionResponse along with the time it expires.
+type cachedDecision struct {
+	response *admission.AdmissionResponse
+	expires  time.Time
+}
+
+// NewDecisionCache creates a DecisionCache that keeps decisions for the given
+// TTL.
+func NewDecisionCache(ttl time.Duration) *DecisionCache {
+	return &DecisionCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cachedDecision),
+	}
+}
+
+// get returns a copy of the cached response for the given request, if it has
+// not yet expired.
+func (dc *DecisionCache) get(request *admission.AdmissionRequest) (*admission.AdmissionResponse, bool) {
+	key := decisionKey(request)
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	entry, ok := dc.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if dc.now().After(entry.expires) {
+		delete(dc.entries, key)
+		return nil, false
+	}
+
+	return entry.response.DeepCopy(), true
+}
+
+// set caches the response for the given request. Responses that do not allow
+// admission are ignored.
+func (dc *DecisionCache) set(request *admission.AdmissionRequest, response *admission.AdmissionResponse) {
+	if !response.Allowed {
+		return
+	}
+
+	key := decisionKey(request)
+	now := dc.now()
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	// Remove expired entries at most once per TTL, so that the cache does not
+	// grow without bound.
+	if now.Sub(dc.lastSweep) > dc.ttl {
+		for k, entry := range dc.entries {
+			if now.After(entry.expires) {
+				delete(dc.entries, k)
+			}
+		}
+
+		dc.lastSweep = now
+	}
+
+	dc.entries[key] = cachedDecision{
+		response: response.DeepCopy(),
+		expires:  now.Add(dc.ttl),
+	}
+}
+
+// decisionKey returns a hash of the parts of an AdmissionRequest that an
+// AdmitFunc's decision depends on. The UID is unique per request, and is
+// deliberately excluded.
+//
+// The name is included as DELETE requests carry no object, and the existing
+// object as UPDATE-dependent AdmitFuncs compare it to the submitted one.
+func decisionKey(request *admission.AdmissionRequest) string {
+	groups := make([]string, len(request.UserInfo.Groups))
+	copy(groups, request.UserInfo.Groups)
+	sort.Strings(groups)
+
+	h := sha256.New()
+	for _, part := range append([]string{
+		request.Kind.String(),
+		request.Namespace,
+		request.Name,
+		request.SubResource,
+		string(request.Operation),
+		request.UserInfo.Username,
+	}, groups...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	// Each object is length-prefixed, so that an object cannot be confused with
+	// the existing object.
+	for _, raw := range [][]byte{request.Object.Raw, request.OldObject.Raw} {
+		fmt.Fprintf(h, "%d:", len(raw))
+		h.Write(raw)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
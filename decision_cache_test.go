@@ -0,0 +1,180 @@
+package admissioncontrol
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admission "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDecisionCache(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	var allow bool
+	admitFunc := func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		calls++
+		resp := newDefaultDenyResponse()
+		resp.Allowed = allow
+		return resp, nil
+	}
+
+	now := time.Now()
+	cache := NewDecisionCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	handler := &AdmissionHandler{
+		AdmitFunc:     admitFunc,
+		Logger:        &noopLogger{},
+		DecisionCache: cache,
+	}
+
+	review := func(uid types.UID, object string) *admission.AdmissionResponse {
+		t.Helper()
+
+		incomingReview := &admission.AdmissionReview{
+			Request: &admission.AdmissionRequest{
+				UID:    uid,
+				Object: runtime.RawExtension{Raw: []byte(object)},
+			},
+		}
+
+		buf := &bytes.Buffer{}
+		if err := json.NewEncoder(buf).Encode(incomingReview); err != nil {
+			t.Fatalf("error marshalling incomingReview: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", buf))
+
+		outgoingReview := &admission.AdmissionReview{}
+		if err := json.Unmarshal(rr.Body.Bytes(), outgoingReview); err != nil {
+			t.Fatalf("couldn't unmarshal the review response: %v", err)
+		}
+
+		return outgoingReview.Response
+	}
+
+	// Denials are never cached.
+	review("1", `{"kind":"Pod"}`)
+	review("2", `{"kind":"Pod"}`)
+	if calls != 2 {
+		t.Fatalf("denials should not be cached: AdmitFunc called %d times (wanted 2)", calls)
+	}
+
+	// An identical object within the TTL is served from the cache, with the UID
+	// of the new request.
+	allow = true
+	review("3", `{"kind":"Pod"}`)
+	resp := review("4", `{"kind":"Pod"}`)
+	if calls != 3 {
+		t.Fatalf("identical request was not cached: AdmitFunc called %d times (wanted 3)", calls)
+	}
+
+	if !resp.Allowed || resp.UID != "4" {
+		t.Fatalf("cached response is incorrect: allowed: %t, UID: %q", resp.Allowed, resp.UID)
+	}
+
+	// A different object is not a cache hit.
+	review("5", `{"kind":"Pod","metadata":{"name":"other"}}`)
+	if calls != 4 {
+		t.Fatalf("different object should not hit the cache: AdmitFunc called %d times (wanted 4)", calls)
+	}
+
+	// Decisions expire after the TTL.
+	now = now.Add(time.Minute * 2)
+	review("6", `{"kind":"Pod"}`)
+	if calls != 5 {
+		t.Fatalf("expired decision was served: AdmitFunc called %d times (wanted 5)", calls)
+	}
+}
+
+func TestDecisionKey(t *testing.T) {
+	t.Parallel()
+
+	var newRequest = func(operation admission.Operation, name string, object, oldObject string) *admission.AdmissionRequest {
+		return &admission.AdmissionRequest{
+			Kind:      meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Namespace: "default",
+			Name:      name,
+			Operation: operation,
+			UserInfo:  authenticationv1.UserInfo{Username: "jane", Groups: []string{"system:authenticated", "dev"}},
+			Object:    runtime.RawExtension{Raw: []byte(object)},
+			OldObject: runtime.RawExtension{Raw: []byte(oldObject)},
+		}
+	}
+
+	var keyTests = []struct {
+		testName string
+		a        *admission.AdmissionRequest
+		b        *admission.AdmissionRequest
+		sameKey  bool
+	}{
+		{
+			testName: "DELETEs of objects with different names",
+			a:        newRequest(admission.Delete, "hello-app", "", ""),
+			b:        newRequest(admission.Delete, "other-app", "", ""),
+			sameKey:  false,
+		},
+		{
+			testName: "UPDATEs that differ only in the existing object",
+			a:        newRequest(admission.Update, "hello-app", `{"spec":{"replicas":3}}`, `{"spec":{"replicas":1}}`),
+			b:        newRequest(admission.Update, "hello-app", `{"spec":{"replicas":3}}`, `{"spec":{"replicas":3}}`),
+			sameKey:  false,
+		},
+		{
+			testName: "An object that is moved into the existing object",
+			a:        newRequest(admission.Update, "hello-app", `{"spec":{}}`, ""),
+			b:        newRequest(admission.Update, "hello-app", "", `{"spec":{}}`),
+			sameKey:  false,
+		},
+		{
+			testName: "Requests on different subresources",
+			a:        newRequest(admission.Update, "hello-app", `{"spec":{}}`, `{"spec":{}}`),
+			b: func() *admission.AdmissionRequest {
+				request := newRequest(admission.Update, "hello-app", `{"spec":{}}`, `{"spec":{}}`)
+				request.SubResource = "scale"
+				return request
+			}(),
+			sameKey: false,
+		},
+		{
+			testName: "Users with the same name, but different groups",
+			a:        newRequest(admission.Create, "hello-app", `{"spec":{}}`, ""),
+			b: func() *admission.AdmissionRequest {
+				request := newRequest(admission.Create, "hello-app", `{"spec":{}}`, "")
+				request.UserInfo.Groups = []string{"system:authenticated", "system:masters"}
+				return request
+			}(),
+			sameKey: false,
+		},
+		{
+			testName: "Identical requests, with groups in a different order",
+			a:        newRequest(admission.Update, "hello-app", `{"spec":{}}`, `{"spec":{}}`),
+			b: func() *admission.AdmissionRequest {
+				request := newRequest(admission.Update, "hello-app", `{"spec":{}}`, `{"spec":{}}`)
+				request.UID = "another-request"
+				request.UserInfo.Groups = []string{"dev", "system:authenticated"}
+				return request
+			}(),
+			sameKey: true,
+		},
+	}
+
+	for _, tt := range keyTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			if same := decisionKey(tt.a) == decisionKey(tt.b); same != tt.sameKey {
+				t.Fatalf("cache keys do not match expectations: got same key: %t (want %t)", same, tt.sameKey)
+			}
+		})
+	}
+}
@@ -2,37 +2,22 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"flag"
 	"fmt"
 	"github.com/gorilla/mux"
 	stdlog "log"
 	"net/http"
 	"os"
-	"time"
 
 	admissioncontrol "github.com/elithrar/admission-control"
 	log "github.com/go-kit/kit/log"
 )
 
-type conf struct {
-	TLSCertPath string
-	TLSKeyPath  string
-	HTTPOnly    bool
-	Port        string
-	Host        string
-}
-
 func main() {
 	ctx := context.Background()
 
 	// Get config
-	conf := &conf{}
-	flag.StringVar(&conf.TLSCertPath, "cert-path", "./cert.crt", "The path to the PEM-encoded TLS certificate")
-	flag.StringVar(&conf.TLSKeyPath, "key-path", "./key.key", "The path to the unencrypted TLS key")
-	flag.BoolVar(&conf.HTTPOnly, "http-only", false, "Only listen on unencrypted HTTP (e.g. for proxied environments)")
-	flag.StringVar(&conf.Port, "port", "8443", "The port to listen on (HTTPS).")
-	flag.StringVar(&conf.Host, "host", "admissiond.questionable.services", "The hostname for the service")
+	conf := admissioncontrol.LoadConfigFromFlags(flag.CommandLine)
 	flag.Parse()
 
 	// Set up logging
@@ -42,17 +27,10 @@ func main() {
 	logger = log.With(logger, "ts", log.DefaultTimestampUTC, "loc", log.DefaultCaller)
 
 	// TLS configuration
-	// Only load the TLS keypair if the -http-only flag is not set.
-	var tlsConf *tls.Config
-	if !conf.HTTPOnly {
-		keyPair, err := tls.LoadX509KeyPair(conf.TLSCertPath, conf.TLSKeyPath)
-		if err != nil {
-			fatal(logger, err)
-		}
-		tlsConf = &tls.Config{
-			Certificates: []tls.Certificate{keyPair},
-			ServerName:   conf.Host,
-		}
+	// Only loads the TLS keypair if the -http-only flag is not set.
+	tlsConf, err := conf.TLSConfig()
+	if err != nil {
+		fatal(logger, err)
 	}
 
 	// Set up the routes & logging middleware.
@@ -65,6 +43,9 @@ func main() {
 
 	// Example admission handler endpoints
 	admissions := r.PathPrefix("/admission-control").Subrouter()
+	// Respond with a valid AdmissionReview - as per our failure policy - if any
+	// of our handlers panic.
+	admissions.Use(admissioncontrol.RecoveryMiddleware(logger, conf.FailurePolicy))
 	admissions.Handle("/deny-ingresses", &admissioncontrol.AdmissionHandler{
 		AdmitFunc: admissioncontrol.DenyIngresses(nil),
 		Logger:    logger,
@@ -91,17 +72,13 @@ func main() {
 		Logger: logger,
 	}).Methods(http.MethodPost)
 
-	// HTTP server
-	timeout := time.Second * 15
-	srv := &http.Server{
-		Handler:           admissioncontrol.LoggingMiddleware(logger)(r),
-		TLSConfig:         tlsConf,
-		Addr:              ":" + conf.Port,
-		IdleTimeout:       timeout,
-		ReadTimeout:       timeout,
-		ReadHeaderTimeout: timeout,
-		WriteTimeout:      timeout,
-	}
+	// HTTP server, with the default timeouts.
+	srv := admissioncontrol.NewHTTPServer(
+		":"+conf.Port,
+		admissioncontrol.LoggingMiddleware(logger)(r),
+		admissioncontrol.ServerTimeouts{},
+		tlsConf,
+	)
 
 	admissionServer, err := admissioncontrol.NewServer(
 		srv,
@@ -111,6 +88,7 @@ func main() {
 		fatal(logger, err)
 		return
 	}
+	admissionServer.GracePeriod = conf.GracePeriod
 
 	if err := admissionServer.Run(ctx); err != nil {
 		fatal(logger, err)
@@ -3,13 +3,16 @@ module github.com/elithrar/admission-control
 go 1.12
 
 require (
+	github.com/evanphx/json-patch v4.9.0+incompatible
 	github.com/go-kit/kit v0.10.0
 	github.com/google/go-cmp v0.5.5 // indirect
 	github.com/google/gofuzz v1.2.0 // indirect
 	github.com/gorilla/mux v1.8.0
+	github.com/prometheus/client_golang v1.3.0
 	golang.org/x/net v0.0.0-20210410081132-afb366fc7cd1 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1
 	k8s.io/api v0.21.0
 	k8s.io/apimachinery v0.21.0
 	sigs.k8s.io/structured-merge-diff/v4 v4.1.1 // indirect
+	sigs.k8s.io/yaml v1.2.0
 )
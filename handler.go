@@ -1,19 +1,29 @@
 package admissioncontrol
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"golang.org/x/xerrors"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"strings"
 
 	admission "k8s.io/api/admission/v1beta1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	log "github.com/go-kit/kit/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeYAML = "application/yaml"
 )
 
 // AdmitFunc is a type for building Kubernetes admission webhooks. An AdmitFunc
@@ -24,10 +34,45 @@ import (
 // Users wishing to build their own admission handlers should satisfy the
 // AdmitFunc type, and pass it to an AdmissionHandler for serving over HTTP.
 //
+// AdmitFuncs that return a patch (mutating webhooks) must be idempotent: the
+// apiserver may re-invoke a webhook on an object it has already patched (see
+// reinvocationPolicy), and doing so must produce an empty patch.
+//
+// An AdmitFunc that has no opinion on a request may return a nil response and
+// a nil error, which allows admission.
+//
 // Note: this mirrors the type in k8s source:
 // https://github.com/kubernetes/kubernetes/blob/v1.13.0/test/images/webhook/main.go#L43-L44
 type AdmitFunc func(reviewRequest *admission.AdmissionReview) (*admission.AdmissionResponse, error)
 
+// FailurePolicy determines whether admission is allowed or denied when a
+// request cannot be evaluated - e.g. due to a panic, or an unavailable
+// dependency. It mirrors the failurePolicy field of a webhook configuration.
+type FailurePolicy string
+
+const (
+	// FailClosed denies admission when a request cannot be evaluated.
+	FailClosed FailurePolicy = "Fail"
+	// FailOpen allows admission when a request cannot be evaluated.
+	FailOpen FailurePolicy = "Ignore"
+)
+
+// String implements flag.Value.
+func (fp *FailurePolicy) String() string {
+	return string(*fp)
+}
+
+// Set implements flag.Value, and only accepts the "Fail" and "Ignore" values.
+func (fp *FailurePolicy) Set(value string) error {
+	switch policy := FailurePolicy(value); policy {
+	case FailClosed, FailOpen:
+		*fp = policy
+		return nil
+	default:
+		return xerrors.Errorf("invalid failure policy %q: must be one of %q or %q", value, FailClosed, FailOpen)
+	}
+}
+
 // AdmissionHandler represents the configuration & associated endpoint for an
 // k8s ValidatingAdmissionController (or MutatingAdmissionController) webhook.
 //
@@ -40,55 +85,168 @@ type AdmissionHandler struct {
 	Logger log.Logger
 	// LimitBytes limits the size of objects the webhook will handle.
 	LimitBytes int64
+	// Name identifies this handler in debug output. It defaults to the path of
+	// the request.
+	Name string
+	// DecisionCache, if set, memoizes allow decisions for identical requests
+	// so that the AdmitFunc is not invoked again within the cache TTL.
+	DecisionCache *DecisionCache
+	// RejectMalformedRequests responds with HTTP 400 (Bad Request), rather than
+	// HTTP 200, to requests that cannot be decoded as an AdmissionReview at all.
+	// This surfaces malformed requests to proxy-level monitoring. Denials - and
+	// any other errors from evaluating a valid AdmissionReview - always respond
+	// with HTTP 200.
+	RejectMalformedRequests bool
+	// DebugEcho attaches the UID & Kind of the AdmissionRequest, and the Name of
+	// this handler, as auditAnnotations on every response. This allows responses
+	// to be correlated with their requests in the apiserver's audit log.
+	DebugEcho bool
 	// deserializer supports deserializing k8s objects. It can be left null; the
 	// ServeHTTP function will lazily instantiate a decoder instance.
 	deserializer runtime.Decoder
 }
 
+// ServeHTTP decodes the AdmissionReview in the request, and responds with the
+// decision of the AdmitFunc. Responses are JSON, unless the Accept header of the
+// request prefers YAML.
 func (ah *AdmissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ah.LimitBytes <= 0 {
+		ah.LimitBytes = 1024 * 1024 * 1024 // 1MB
+	}
+
+	name := ah.Name
+	if name == "" {
+		name = r.URL.Path
+	}
+
+	contentType := negotiateContentType(r.Header.Get("Accept"))
+	w.Header().Set("Content-Type", contentType)
+
+	var res []byte
+	limitReader := io.LimitReader(r.Body, ah.LimitBytes)
+	body, err := ioutil.ReadAll(limitReader)
+	if err != nil {
+		res, err = ah.encodeError(
+			malformedRequestError{AdmissionError{false, "could not read the request body", err.Error(), ""}},
+			&admission.AdmissionReview{},
+			name,
+			contentType,
+		)
+	} else {
+		res, err = ah.evaluate(r.Context(), body, name, contentType)
+	}
+
+	if res == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		ah.Logger.Log(
+			"err", err.Error(),
+			"msg", "failed to marshal review response",
+		)
+
+		return
+	}
+
+	status := http.StatusOK
+	var malformedErr malformedRequestError
+	if ah.RejectMalformedRequests && xerrors.As(err, &malformedErr) {
+		status = http.StatusBadRequest
+	}
+
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+// Evaluate decodes the (JSON) AdmissionReview in body, invokes the AdmitFunc,
+// and returns the encoded AdmissionReview response. It does not depend on
+// net/http, and allows an AdmissionHandler to be served over other transports:
+// ServeHTTP is a thin wrapper around it.
+//
+// If the request is malformed, or the AdmitFunc returns an error, a response
+// that denies admission is returned along with the error. A nil response is
+// only returned when the response cannot be encoded. LimitBytes is not applied:
+// other transports should limit the size of body themselves.
+func (ah *AdmissionHandler) Evaluate(ctx context.Context, body []byte) ([]byte, error) {
+	return ah.evaluate(ctx, body, ah.Name, contentTypeJSON)
+}
+
+// evaluate implements Evaluate, and encodes the response as the given content
+// type. name identifies this handler in debug output.
+func (ah *AdmissionHandler) evaluate(ctx context.Context, body []byte, name string, contentType string) ([]byte, error) {
 	if ah.deserializer == nil {
 		runtimeScheme := runtime.NewScheme()
 		ah.deserializer = serializer.NewCodecFactory(runtimeScheme).UniversalDeserializer()
 	}
 
-	if ah.LimitBytes <= 0 {
-		ah.LimitBytes = 1024 * 1024 * 1024 // 1MB
+	incomingReview := &admission.AdmissionReview{}
+	reviewResponse, err := ah.admit(ctx, body, incomingReview)
+	if err != nil {
+		return ah.encodeError(err, incomingReview, name, contentType)
+	}
+
+	reviewResponse.UID = incomingReview.Request.UID
+	if ah.DebugEcho {
+		addDebugAnnotations(name, incomingReview, reviewResponse)
 	}
 
+	review := admission.AdmissionReview{
+		TypeMeta: incomingReview.TypeMeta,
+		Response: reviewResponse,
+	}
+
+	res, err := marshalReview(&review, contentType)
+	if err != nil {
+		return ah.encodeError(
+			AdmissionError{false, "marshalling the review response failed", err.Error(), ""},
+			incomingReview,
+			name,
+			contentType,
+		)
+	}
+
+	return res, nil
+}
+
+// encodeError encodes a response to incomingReview that denies admission (or
+// allows it, per AdmissionError.Allowed) with the given error. The error is
+// returned alongside the encoded response.
+func (ah *AdmissionHandler) encodeError(err error, incomingReview *admission.AdmissionReview, name string, contentType string) ([]byte, error) {
 	outgoingReview := &admission.AdmissionReview{
-		Response: &admission.AdmissionResponse{},
+		Response: &admission.AdmissionResponse{
+			Allowed: false,
+			Result: &meta.Status{
+				Message: err.Error(),
+			},
+		},
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := ah.handleAdmissionRequest(w, r); err != nil {
-		outgoingReview.Response.Allowed = false
-		outgoingReview.Response.Result = &meta.Status{
-			Message: err.Error(),
-		}
+	if ah.DebugEcho {
+		addDebugAnnotations(name, incomingReview, outgoingReview.Response)
+	}
 
-		admissionErr, ok := err.(AdmissionError)
-		if ok {
-			ah.Logger.Log(
-				"msg", admissionErr.Message,
-				"debug", admissionErr.Debug,
-			)
-			outgoingReview.Response.Allowed = admissionErr.Allowed
-		}
+	var admissionErr AdmissionError
+	if xerrors.As(err, &admissionErr) {
+		ah.Logger.Log(
+			"msg", admissionErr.Message,
+			"debug", admissionErr.Debug,
+			"reason", admissionErr.Reason,
+		)
+		outgoingReview.Response.Allowed = admissionErr.Allowed
 
-		res, err := json.Marshal(outgoingReview)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			ah.Logger.Log(
-				"err", err.Error(),
-				"msg", "failed to marshal review response",
-			)
+		if admissionErr.Reason != "" {
+			if outgoingReview.Response.AuditAnnotations == nil {
+				outgoingReview.Response.AuditAnnotations = make(map[string]string)
+			}
 
-			return
+			outgoingReview.Response.AuditAnnotations["reason"] = string(admissionErr.Reason)
 		}
+	}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write(res)
+	res, marshalErr := marshalReview(outgoingReview, contentType)
+	if marshalErr != nil {
+		return nil, marshalErr
 	}
+
+	return res, err
 }
 
 // AdmissionError represents an error (rejection, serialization error, etc) from
@@ -97,57 +255,159 @@ type AdmissionError struct {
 	Allowed bool
 	Message string
 	Debug   string
+	// Reason is the ReasonCode of the denial, if the AdmitFunc provided one.
+	Reason ReasonCode
 }
 
 func (e AdmissionError) Error() string {
 	return fmt.Sprintf("admission error: %s (allowed: %t)", e.Message, e.Allowed)
 }
 
-func (ah *AdmissionHandler) handleAdmissionRequest(w http.ResponseWriter, r *http.Request) error {
-	limitReader := io.LimitReader(r.Body, ah.LimitBytes)
-	body, err := ioutil.ReadAll(limitReader)
-	if err != nil {
-		return AdmissionError{false, "could not read the request body", err.Error()}
+// malformedRequestError wraps the errors returned for requests that could not
+// be decoded as an AdmissionReview.
+type malformedRequestError struct {
+	err error
+}
+
+func (e malformedRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e malformedRequestError) Unwrap() error {
+	return e.err
+}
+
+// addDebugAnnotations adds the request UID & Kind, and the handler name, to the
+// auditAnnotations of the given response.
+func addDebugAnnotations(name string, incomingReview *admission.AdmissionReview, response *admission.AdmissionResponse) {
+	if response.AuditAnnotations == nil {
+		response.AuditAnnotations = make(map[string]string)
 	}
 
+	response.AuditAnnotations["handler"] = name
+	if request := incomingReview.Request; request != nil {
+		response.AuditAnnotations["request.uid"] = string(request.UID)
+		response.AuditAnnotations["request.kind"] = request.Kind.String()
+	}
+}
+
+// admit decodes the AdmissionReview in body into incomingReview, and returns
+// the response of the AdmitFunc. Any error returned should be encoded as the
+// response by the caller.
+func (ah *AdmissionHandler) admit(ctx context.Context, body []byte, incomingReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
 	if body == nil || len(body) == 0 {
-		return AdmissionError{
+		return nil, malformedRequestError{AdmissionError{
 			false,
 			"no request body was received",
 			"the request body was nil/len == 0",
-		}
+			"",
+		}}
 	}
 
-	incomingReview := admission.AdmissionReview{}
-	if _, _, err := ah.deserializer.Decode(body, nil, &incomingReview); err != nil {
-		return AdmissionError{false, "decoding the review request failed", err.Error()}
+	if _, _, err := ah.deserializer.Decode(body, nil, incomingReview); err != nil {
+		return nil, malformedRequestError{AdmissionError{false, "decoding the review request failed", err.Error(), ""}}
 	}
 
 	if incomingReview.Request == nil {
-		return xerrors.New("received invalid request: no AdmissionReview was found")
+		return nil, malformedRequestError{xerrors.New("received invalid request: no AdmissionReview was found")}
 	}
 
-	reviewResponse, err := ah.AdmitFunc(&incomingReview)
-	if err != nil {
-		return AdmissionError{false, err.Error(), "the AdmitFunc returned an error"}
+	setAdmissionInfo(ctx, incomingReview.Request)
+
+	// Older clients may omit the TypeMeta of the AdmissionReview entirely: we
+	// assume they are sending the version we support.
+	if incomingReview.APIVersion == "" {
+		ah.Logger.Log(
+			"msg", "the AdmissionReview did not specify an apiVersion",
+			"debug", fmt.Sprintf("defaulting to %s", admission.SchemeGroupVersion),
+		)
+
+		incomingReview.TypeMeta = meta.TypeMeta{
+			APIVersion: admission.SchemeGroupVersion.String(),
+			Kind:       "AdmissionReview",
+		}
 	}
 
-	if reviewResponse == nil {
-		return AdmissionError{false, "the AdmitFunc returned an empty AdmissionReview", ""}
+	if err := ctx.Err(); err != nil {
+		return nil, AdmissionError{false, "the request was cancelled before it was evaluated", err.Error(), ""}
 	}
 
-	reviewResponse.UID = incomingReview.Request.UID
-	review := admission.AdmissionReview{
-		Response: reviewResponse,
+	var reviewResponse *admission.AdmissionResponse
+	if ah.DecisionCache != nil {
+		reviewResponse, _ = ah.DecisionCache.get(incomingReview.Request)
+	}
+
+	if reviewResponse == nil {
+		var err error
+		reviewResponse, err = ah.AdmitFunc(incomingReview)
+		if err != nil {
+			return nil, AdmissionError{false, err.Error(), "the AdmitFunc returned an error", ReasonCodeOf(err)}
+		}
+
+		// An AdmitFunc with no opinion on the request - e.g. a mutating
+		// AdmitFunc with nothing to patch - may return (nil, nil).
+		if reviewResponse == nil {
+			reviewResponse = newDefaultDenyResponse()
+			reviewResponse.Allowed = true
+		}
+
+		if len(reviewResponse.Patch) > 0 {
+			if err := validatePatch(incomingReview.Request.Object.Raw, reviewResponse.Patch); err != nil {
+				return nil, AdmissionError{false, "the AdmitFunc returned a patch that does not apply to the object", err.Error(), ""}
+			}
+		}
+
+		if ah.DecisionCache != nil {
+			ah.DecisionCache.set(incomingReview.Request, reviewResponse)
+		}
 	}
 
-	res, err := json.Marshal(&review)
+	return reviewResponse, nil
+}
+
+// validatePatch applies the given RFC 6902 JSON patch to the object in-memory,
+// and returns an error if it is malformed or does not apply cleanly. The
+// apiserver would otherwise reject the patch without a useful error.
+func validatePatch(object []byte, patch []byte) error {
+	decodedPatch, err := jsonpatch.DecodePatch(patch)
 	if err != nil {
-		return AdmissionError{false, "marshalling the review response failed", err.Error()}
+		return xerrors.Errorf("failed to decode the patch: %w", err)
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	if _, err := decodedPatch.Apply(object); err != nil {
+		return xerrors.Errorf("failed to apply the patch: %w", err)
+	}
 
 	return nil
 }
+
+// negotiateContentType returns the content type of the response for the given
+// Accept header. The apiserver always accepts JSON, which is the default: YAML
+// is only returned when it is preferred by the client, which is useful when
+// debugging the handler by hand.
+func negotiateContentType(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case contentTypeYAML:
+			return contentTypeYAML
+		case contentTypeJSON:
+			return contentTypeJSON
+		}
+	}
+
+	return contentTypeJSON
+}
+
+// marshalReview serializes the AdmissionReview as the given content type.
+func marshalReview(review *admission.AdmissionReview, contentType string) ([]byte, error) {
+	if contentType == contentTypeYAML {
+		return yaml.Marshal(review)
+	}
+
+	return json.Marshal(review)
+}
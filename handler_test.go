@@ -2,15 +2,34 @@ package admissioncontrol
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	admission "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+
+	"sigs.k8s.io/yaml"
 )
 
+// recordingLogger records the keyvals of each call to Log.
+type recordingLogger struct {
+	mu   sync.Mutex
+	logs [][]interface{}
+}
+
+func (rl *recordingLogger) Log(keyvals ...interface{}) error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.logs = append(rl.logs, keyvals)
+	return nil
+}
+
 func newTestAdmitFunc(allowed bool, returnError bool) AdmitFunc {
 	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
 		ar := &admission.AdmissionResponse{
@@ -65,14 +84,14 @@ func TestAdmissionHandler(t *testing.T) {
 			shouldPass: false,
 		},
 		{
-			testName: "Return an error for a malformed outgoing AdmissionReview",
+			testName: "Allow admission when the AdmitFunc returns no response or error",
 			admitFunc: func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
 				return nil, nil
 			},
 			incomingReview: &admission.AdmissionReview{
 				Request: &admission.AdmissionRequest{},
 			},
-			shouldPass: false,
+			shouldPass: true,
 		},
 	}
 
@@ -119,3 +138,471 @@ func TestAdmissionHandler(t *testing.T) {
 	}
 
 }
+
+func TestAdmissionHandlerDebugEcho(t *testing.T) {
+	t.Parallel()
+
+	var debugTests = []struct {
+		testName    string
+		handlerName string
+		admitFunc   AdmitFunc
+		wantHandler string
+	}{
+		{
+			testName:    "Debug annotations are attached to allowed responses",
+			handlerName: "allow-all",
+			admitFunc:   newTestAdmitFunc(true, false),
+			wantHandler: "allow-all",
+		},
+		{
+			testName:    "Debug annotations are attached to rejected responses",
+			admitFunc:   newTestAdmitFunc(false, true),
+			wantHandler: "/deny",
+		},
+	}
+
+	for _, tt := range debugTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			handler := &AdmissionHandler{
+				AdmitFunc: tt.admitFunc,
+				Logger:    &noopLogger{},
+				Name:      tt.handlerName,
+				DebugEcho: true,
+			}
+
+			incomingReview := &admission.AdmissionReview{
+				Request: &admission.AdmissionRequest{
+					UID:  "705ab4f5-6393-11e8-b7cc-42010a800002",
+					Kind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				},
+			}
+
+			buf := &bytes.Buffer{}
+			if err := json.NewEncoder(buf).Encode(incomingReview); err != nil {
+				t.Fatalf("error marshalling incomingReview: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/deny", buf)
+			handler.ServeHTTP(rr, req)
+
+			review := &admission.AdmissionReview{}
+			if err := json.Unmarshal(rr.Body.Bytes(), review); err != nil {
+				t.Fatalf("couldn't marshal the review response: %v", err)
+			}
+
+			expected := map[string]string{
+				"handler":      tt.wantHandler,
+				"request.uid":  "705ab4f5-6393-11e8-b7cc-42010a800002",
+				"request.kind": "apps/v1, Kind=Deployment",
+			}
+
+			for key, val := range expected {
+				if got := review.Response.AuditAnnotations[key]; got != val {
+					t.Fatalf("auditAnnotation %q does not match: got %q - wanted %q", key, got, val)
+				}
+			}
+		})
+	}
+}
+
+func TestAdmissionHandlerPatchValidation(t *testing.T) {
+	t.Parallel()
+
+	var newPatchingAdmitFunc = func(patch string) AdmitFunc {
+		return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			patchType := admission.PatchTypeJSONPatch
+			return &admission.AdmissionResponse{
+				Allowed:   true,
+				Patch:     []byte(patch),
+				PatchType: &patchType,
+			}, nil
+		}
+	}
+
+	var patchTests = []struct {
+		testName   string
+		admitFunc  AdmitFunc
+		shouldPass bool
+	}{
+		{
+			testName:   "Allow a patch that applies to the object",
+			admitFunc:  newPatchingAdmitFunc(`[{"op": "add", "path": "/metadata/annotations", "value": {"autoscaler": "enabled"}}]`),
+			shouldPass: true,
+		},
+		{
+			testName:   "Reject a patch with an unknown operation",
+			admitFunc:  newPatchingAdmitFunc(`[{"op": "upsert", "path": "/metadata/annotations", "value": {}}]`),
+			shouldPass: false,
+		},
+		{
+			testName:   "Reject a patch that replaces a missing path",
+			admitFunc:  newPatchingAdmitFunc(`[{"op": "replace", "path": "/spec/replicas", "value": 3}]`),
+			shouldPass: false,
+		},
+		{
+			testName:   "Reject a patch that is not valid JSON",
+			admitFunc:  newPatchingAdmitFunc(`[{"op": "add"`),
+			shouldPass: false,
+		},
+	}
+
+	for _, tt := range patchTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			handler := &AdmissionHandler{
+				AdmitFunc: tt.admitFunc,
+				Logger:    &noopLogger{},
+			}
+
+			incomingReview := &admission.AdmissionReview{
+				Request: &admission.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "hello-app"}}`),
+					},
+				},
+			}
+
+			buf := &bytes.Buffer{}
+			if err := json.NewEncoder(buf).Encode(incomingReview); err != nil {
+				t.Fatalf("error marshalling incomingReview: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", buf)
+			handler.ServeHTTP(rr, req)
+
+			review := &admission.AdmissionReview{}
+			if err := json.Unmarshal(rr.Body.Bytes(), review); err != nil {
+				t.Fatalf("couldn't marshal the review response: %v", err)
+			}
+
+			if allowed := review.Response.Allowed; allowed != tt.shouldPass {
+				t.Fatalf("invalid review response: got allowed: %t (want %t)", allowed, tt.shouldPass)
+			}
+
+			if !tt.shouldPass && len(review.Response.Patch) > 0 {
+				t.Fatalf("rejected response should not include a patch: %s", review.Response.Patch)
+			}
+		})
+	}
+}
+
+func TestAdmissionHandlerReasonCode(t *testing.T) {
+	t.Parallel()
+
+	handler := &AdmissionHandler{
+		AdmitFunc: DenyIngresses(nil),
+		Logger:    &noopLogger{},
+	}
+
+	incomingReview := &admission.AdmissionReview{
+		Request: &admission.AdmissionRequest{
+			Kind:   metav1.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			Object: runtime.RawExtension{Raw: []byte(`{"metadata": {"name": "hello-ingress"}}`)},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(incomingReview); err != nil {
+		t.Fatalf("error marshalling incomingReview: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", buf))
+
+	review := &admission.AdmissionReview{}
+	if err := json.Unmarshal(rr.Body.Bytes(), review); err != nil {
+		t.Fatalf("couldn't marshal the review response: %v", err)
+	}
+
+	if review.Response.Allowed {
+		t.Fatalf("expected admission to be denied")
+	}
+
+	if reason := review.Response.AuditAnnotations["reason"]; reason != string(ReasonIngressForbidden) {
+		t.Fatalf("reason auditAnnotation does not match: got %q - wanted %q", reason, ReasonIngressForbidden)
+	}
+}
+
+func TestAdmissionHandlerMissingAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	logger := &recordingLogger{}
+	handler := &AdmissionHandler{
+		AdmitFunc: newTestAdmitFunc(true, false),
+		Logger:    logger,
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		bytes.NewBufferString(`{"request": {"uid": "705ab4f5-6393-11e8-b7cc-42010a800002"}}`),
+	)
+	handler.ServeHTTP(rr, req)
+
+	review := &admission.AdmissionReview{}
+	if err := json.Unmarshal(rr.Body.Bytes(), review); err != nil {
+		t.Fatalf("couldn't marshal the review response: %v", err)
+	}
+
+	if review.Response == nil || !review.Response.Allowed {
+		t.Fatalf("the review without an apiVersion was not processed: %s", rr.Body.String())
+	}
+
+	if review.APIVersion != "admission.k8s.io/v1beta1" || review.Kind != "AdmissionReview" {
+		t.Fatalf("unexpected TypeMeta in response: %#v", review.TypeMeta)
+	}
+
+	if len(logger.logs) != 1 {
+		t.Fatalf("expected a warning to be logged: got %d log lines", len(logger.logs))
+	}
+}
+
+func TestAdmissionHandlerContentNegotiation(t *testing.T) {
+	t.Parallel()
+
+	handler := &AdmissionHandler{
+		AdmitFunc: newTestAdmitFunc(true, false),
+		Logger:    &noopLogger{},
+	}
+
+	var negotiationTests = []struct {
+		testName            string
+		accept              string
+		expectedContentType string
+	}{
+		{"Default to JSON without an Accept header", "", "application/json"},
+		{"Return JSON when accepted", "application/json", "application/json"},
+		{"Return YAML when accepted", "application/yaml", "application/yaml"},
+		{"Return the first supported type", "text/html, application/yaml;q=0.9, application/json", "application/yaml"},
+		{"Default to JSON for unsupported types", "text/html", "application/json"},
+	}
+
+	for _, tt := range negotiationTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				bytes.NewBufferString(`{"kind":"AdmissionReview","apiVersion":"admission.k8s.io/v1beta1","request":{"uid":"705ab4f5-6393-11e8-b7cc-42010a800002"}}`),
+			)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			handler.ServeHTTP(rr, req)
+
+			if contentType := rr.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Fatalf("unexpected Content-Type: got %q (wanted %q)", contentType, tt.expectedContentType)
+			}
+
+			body := rr.Body.Bytes()
+			if tt.expectedContentType == "application/yaml" {
+				if strings.HasPrefix(strings.TrimSpace(rr.Body.String()), "{") {
+					t.Fatalf("the response body is not YAML: %s", rr.Body.String())
+				}
+
+				var err error
+				if body, err = yaml.YAMLToJSON(body); err != nil {
+					t.Fatalf("couldn't convert the YAML response: %v", err)
+				}
+			}
+
+			review := &admission.AdmissionReview{}
+			if err := json.Unmarshal(body, review); err != nil {
+				t.Fatalf("couldn't unmarshal the review response: %v", err)
+			}
+
+			if review.Response == nil || !review.Response.Allowed || review.Kind != "AdmissionReview" {
+				t.Fatalf("unexpected review response: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdmissionHandlerRejectMalformedRequests(t *testing.T) {
+	t.Parallel()
+
+	deniedReview := &admission.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+		Request: &admission.AdmissionRequest{
+			Kind:   metav1.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			Object: runtime.RawExtension{Raw: []byte(`{"metadata": {"name": "hello-ingress"}}`)},
+		},
+	}
+
+	denied, err := json.Marshal(deniedReview)
+	if err != nil {
+		t.Fatalf("error marshalling the review: %v", err)
+	}
+
+	var malformedTests = []struct {
+		testName                string
+		rejectMalformedRequests bool
+		body                    string
+		expectedStatus          int
+	}{
+		{"Garbage bodies are rejected with a 400", true, "garbage", http.StatusBadRequest},
+		{"Empty bodies are rejected with a 400", true, "", http.StatusBadRequest},
+		{"Reviews without a request are rejected with a 400", true, `{"kind":"AdmissionReview","apiVersion":"admission.k8s.io/v1beta1"}`, http.StatusBadRequest},
+		{"Valid denials are returned with a 200", true, string(denied), http.StatusOK},
+		{"Garbage bodies are returned with a 200 by default", false, "garbage", http.StatusOK},
+	}
+
+	for _, tt := range malformedTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			handler := &AdmissionHandler{
+				AdmitFunc:               DenyIngresses(nil),
+				Logger:                  &noopLogger{},
+				RejectMalformedRequests: tt.rejectMalformedRequests,
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body)))
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("unexpected status code: got %d (wanted %d)", rr.Code, tt.expectedStatus)
+			}
+
+			review := &admission.AdmissionReview{}
+			if err := json.Unmarshal(rr.Body.Bytes(), review); err != nil {
+				t.Fatalf("couldn't unmarshal the review response: %v", err)
+			}
+
+			if review.Response == nil || review.Response.Allowed {
+				t.Fatalf("expected admission to be denied: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdmissionHandlerEvaluate(t *testing.T) {
+	t.Parallel()
+
+	var evaluateTests = []struct {
+		testName       string
+		admitFunc      AdmitFunc
+		body           []byte
+		shouldAllow    bool
+		shouldErr      bool
+		expectedReason string
+	}{
+		{
+			testName:    "Allow a valid AdmissionReview",
+			admitFunc:   newTestAdmitFunc(true, false),
+			body:        []byte(`{"apiVersion":"admission.k8s.io/v1beta1","kind":"AdmissionReview","request":{"uid":"abc-123"}}`),
+			shouldAllow: true,
+		},
+		{
+			testName: "Allow an AdmissionReview the AdmitFunc has no opinion on",
+			admitFunc: func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+				return nil, nil
+			},
+			body:        []byte(`{"apiVersion":"admission.k8s.io/v1beta1","kind":"AdmissionReview","request":{"uid":"abc-123"}}`),
+			shouldAllow: true,
+		},
+		{
+			testName:       "Deny an AdmissionReview rejected by the AdmitFunc",
+			admitFunc:      DenyIngresses(nil),
+			body:           []byte(`{"apiVersion":"admission.k8s.io/v1beta1","kind":"AdmissionReview","request":{"uid":"abc-123","kind":{"group":"extensions","version":"v1beta1","kind":"Ingress"},"object":{"metadata":{"name":"hello-ingress"}}}}`),
+			shouldAllow:    false,
+			shouldErr:      true,
+			expectedReason: string(ReasonIngressForbidden),
+		},
+		{
+			testName:    "Deny an empty body",
+			admitFunc:   newTestAdmitFunc(true, false),
+			body:        nil,
+			shouldAllow: false,
+			shouldErr:   true,
+		},
+		{
+			testName:    "Deny a malformed body",
+			admitFunc:   newTestAdmitFunc(true, false),
+			body:        []byte(`{"request":`),
+			shouldAllow: false,
+			shouldErr:   true,
+		},
+	}
+
+	for _, tt := range evaluateTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			handler := &AdmissionHandler{
+				AdmitFunc: tt.admitFunc,
+				Logger:    &noopLogger{},
+			}
+
+			res, err := handler.Evaluate(context.Background(), tt.body)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("unexpected error: got %v (wanted an error: %t)", err, tt.shouldErr)
+			}
+
+			if res == nil {
+				t.Fatalf("received an empty response")
+			}
+
+			review := &admission.AdmissionReview{}
+			if err := json.Unmarshal(res, review); err != nil {
+				t.Fatalf("couldn't unmarshal the review response: %v", err)
+			}
+
+			if allowed := review.Response.Allowed; allowed != tt.shouldAllow {
+				t.Fatalf("invalid review response: got allowed: %t (want %t)", allowed, tt.shouldAllow)
+			}
+
+			if reason := review.Response.AuditAnnotations["reason"]; reason != tt.expectedReason {
+				t.Fatalf("reason auditAnnotation does not match: got %q - wanted %q", reason, tt.expectedReason)
+			}
+		})
+	}
+
+	t.Run("Echo the request UID", func(t *testing.T) {
+		handler := &AdmissionHandler{
+			AdmitFunc: newTestAdmitFunc(true, false),
+			Logger:    &noopLogger{},
+		}
+
+		res, err := handler.Evaluate(context.Background(), []byte(`{"request":{"uid":"abc-123"}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		review := &admission.AdmissionReview{}
+		if err := json.Unmarshal(res, review); err != nil {
+			t.Fatalf("couldn't unmarshal the review response: %v", err)
+		}
+
+		if review.Response.UID != "abc-123" {
+			t.Fatalf("the request UID was not echoed: got %q", review.Response.UID)
+		}
+	})
+
+	t.Run("Deny a cancelled request", func(t *testing.T) {
+		handler := &AdmissionHandler{
+			AdmitFunc: newTestAdmitFunc(true, false),
+			Logger:    &noopLogger{},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		res, err := handler.Evaluate(ctx, []byte(`{"request":{"uid":"abc-123"}}`))
+		if err == nil {
+			t.Fatalf("expected an error for a cancelled request")
+		}
+
+		review := &admission.AdmissionReview{}
+		if err := json.Unmarshal(res, review); err != nil {
+			t.Fatalf("couldn't unmarshal the review response: %v", err)
+		}
+
+		if review.Response.Allowed {
+			t.Fatalf("expected a cancelled request to be denied")
+		}
+	})
+}
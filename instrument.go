@@ -0,0 +1,25 @@
+package admissioncontrol
+
+import (
+	"github.com/go-kit/kit/metrics"
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// InstrumentedAdmitFunc wraps an AdmitFunc, and records how long each
+// invocation takes (in seconds) to the provided histogram, labeled by
+// "policy" with the given name.
+//
+// This measures the evaluation of a single AdmitFunc, rather than the HTTP
+// request as a whole: wrap each AdmitFunc in a chain to see which policy is
+// slow. The histogram can be any go-kit metrics backend - e.g. Prometheus - and
+// must declare a "policy" label.
+func InstrumentedAdmitFunc(name string, histogram metrics.Histogram, admitFunc AdmitFunc) AdmitFunc {
+	histogram = histogram.With("policy", name)
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		timer := metrics.NewTimer(histogram)
+		defer timer.ObserveDuration()
+
+		return admitFunc(admissionReview)
+	}
+}
@@ -0,0 +1,67 @@
+package admissioncontrol
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// recordingHistogram is a metrics.Histogram that records its label values and
+// observations.
+type recordingHistogram struct {
+	mu           sync.Mutex
+	labelValues  []string
+	observations []float64
+}
+
+func (h *recordingHistogram) With(labelValues ...string) metrics.Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.labelValues = append(h.labelValues, labelValues...)
+	return h
+}
+
+func (h *recordingHistogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observations = append(h.observations, value)
+}
+
+func TestInstrumentedAdmitFunc(t *testing.T) {
+	t.Parallel()
+
+	histogram := &recordingHistogram{}
+	wrapped := InstrumentedAdmitFunc(
+		"slow-policy",
+		histogram,
+		func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			time.Sleep(time.Millisecond * 10)
+			return &admission.AdmissionResponse{Allowed: true}, nil
+		},
+	)
+
+	resp, err := wrapped(&admission.AdmissionReview{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !resp.Allowed {
+		t.Fatalf("the wrapped AdmitFunc's response was not returned: %#v", resp)
+	}
+
+	if expected := []string{"policy", "slow-policy"}; !reflect.DeepEqual(histogram.labelValues, expected) {
+		t.Fatalf("unexpected label values: got %v (wanted %v)", histogram.labelValues, expected)
+	}
+
+	if len(histogram.observations) != 1 {
+		t.Fatalf("unexpected number of observations: got %d (wanted 1)", len(histogram.observations))
+	}
+
+	if seconds := histogram.observations[0]; seconds < 0.01 {
+		t.Fatalf("the recorded latency was too low: got %fs (wanted >= 0.01s)", seconds)
+	}
+}
@@ -0,0 +1,323 @@
+package admissioncontrol
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io/ioutil"
+	stdlog "log"
+	"math"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	admission "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// e2eServer is an AdmissionServer listening on a loopback address over TLS,
+// along with a client that trusts its (self-signed) certificate.
+type e2eServer struct {
+	srv    *AdmissionServer
+	client *http.Client
+	url    string
+}
+
+// newSelfSignedCert generates a short-lived, self-signed certificate valid for
+// the loopback address.
+func newSelfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a private key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "admission-control-e2e"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse the certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// newE2EServer starts an AdmissionServer with a self-signed certificate, serving
+// the provided handler, and blocks until it is accepting connections.
+func newE2EServer(ctx context.Context, t *testing.T, handler http.Handler) *e2eServer {
+	t.Helper()
+
+	cert, pool := newSelfSignedCert(t)
+
+	// Reserve a free port, and release it for the AdmissionServer to bind to.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	srv := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		// Silence the handshake errors caused by our readiness check below.
+		ErrorLog: stdlog.New(ioutil.Discard, "", 0),
+	}
+
+	admissionServer, err := NewServer(srv, &noopLogger{})
+	if err != nil {
+		t.Fatalf("admission server creation failed: %s", err)
+	}
+	admissionServer.GracePeriod = time.Millisecond * 100
+
+	go func() {
+		if err := admissionServer.Run(ctx); err != nil {
+			t.Logf("server stopped: %s", err)
+		}
+	}()
+
+	var (
+		backoffFactor = 1.25
+		waitTime      = time.Millisecond * 50
+		maxAttempts   = 5
+		dialTimeout   = time.Second * 1
+	)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+		if err != nil {
+			time.Sleep(waitTime)
+			newWait := float64(waitTime) * math.Pow(backoffFactor, float64(attempt))
+			waitTime = time.Duration(newWait)
+			continue
+		}
+
+		if err := conn.Close(); err != nil {
+			t.Fatalf("failed to close the test connection: %v", err)
+		}
+
+		break
+	}
+
+	client := &http.Client{
+		Timeout: time.Second * 5,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+
+	return &e2eServer{srv: admissionServer, client: client, url: "https://" + addr}
+}
+
+// review posts an AdmissionReview - as the apiserver would - to the given path,
+// and returns the decoded AdmissionReview response.
+func (es *e2eServer) review(t *testing.T, path string, request *admission.AdmissionRequest) *admission.AdmissionReview {
+	t.Helper()
+
+	incomingReview := &admission.AdmissionReview{
+		TypeMeta: meta.TypeMeta{
+			APIVersion: "admission.k8s.io/v1beta1",
+			Kind:       "AdmissionReview",
+		},
+		Request: request,
+	}
+
+	body, err := json.Marshal(incomingReview)
+	if err != nil {
+		t.Fatalf("failed to marshal the AdmissionReview: %v", err)
+	}
+
+	resp, err := es.client.Post(es.url+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: got %d (wanted %d)", resp.StatusCode, http.StatusOK)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("unexpected Content-Type: got %q (wanted %q)", contentType, "application/json")
+	}
+
+	outgoingReview := &admission.AdmissionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(outgoingReview); err != nil {
+		t.Fatalf("failed to decode the AdmissionReview response: %v", err)
+	}
+
+	if outgoingReview.Response == nil {
+		t.Fatalf("the AdmissionReview did not contain a response")
+	}
+
+	return outgoingReview
+}
+
+// mustRaw serializes a k8s API object for use as an AdmissionRequest.Object.
+func mustRaw(t *testing.T, obj interface{}) runtime.RawExtension {
+	t.Helper()
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("could not marshal k8s API object: %v", err)
+	}
+
+	return runtime.RawExtension{Raw: raw}
+}
+
+// TestEndToEnd serves each built-in AdmitFunc from an AdmissionServer over TLS,
+// and checks the AdmissionReview responses it returns.
+func TestEndToEnd(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := http.NewServeMux()
+	routes := map[string]AdmitFunc{
+		"/deny-ingresses":             DenyIngresses(nil),
+		"/deny-public-load-balancers": DenyPublicLoadBalancers(nil, GCP),
+		"/enforce-pod-annotations": EnforcePodAnnotations(nil, map[string]func(string) bool{
+			"buildVersion": func(s string) bool { return s != "" },
+		}),
+		"/deny-cluster-admin-bindings":    DenyClusterAdminBindings(""),
+		"/require-liveness-initial-delay": RequireLivenessInitialDelay(nil, 10),
+	}
+	for path, admitFunc := range routes {
+		mux.Handle(path, &AdmissionHandler{AdmitFunc: admitFunc, Logger: &noopLogger{}})
+	}
+
+	es := newE2EServer(ctx, t, mux)
+
+	var e2eTests = []struct {
+		testName    string
+		path        string
+		kind        meta.GroupVersionKind
+		object      interface{}
+		shouldAllow bool
+	}{
+		{
+			testName: "DenyIngresses rejects an Ingress",
+			path:     "/deny-ingresses",
+			kind:     meta.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			object: &extensionsv1beta1.Ingress{
+				TypeMeta:   meta.TypeMeta{Kind: "Ingress", APIVersion: "extensions/v1beta1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-ingress", Namespace: "default"},
+			},
+			shouldAllow: false,
+		},
+		{
+			testName: "DenyPublicLoadBalancers rejects a public LoadBalancer",
+			path:     "/deny-public-load-balancers",
+			kind:     meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Service"},
+			object: &corev1.Service{
+				TypeMeta:   meta.TypeMeta{Kind: "Service", APIVersion: "v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			shouldAllow: false,
+		},
+		{
+			testName: "EnforcePodAnnotations allows an annotated Deployment",
+			path:     "/enforce-pod-annotations",
+			kind:     meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			object: &appsv1.Deployment{
+				TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+					ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{"buildVersion": "v1.0.0"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}}},
+				}},
+			},
+			shouldAllow: true,
+		},
+		{
+			testName: "DenyClusterAdminBindings rejects a cluster-admin binding",
+			path:     "/deny-cluster-admin-bindings",
+			kind:     meta.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+			object: &rbacv1.ClusterRoleBinding{
+				TypeMeta:   meta.TypeMeta{Kind: "ClusterRoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "ci-admin"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+			shouldAllow: false,
+		},
+		{
+			testName: "RequireLivenessInitialDelay allows a Pod with a sufficient delay",
+			path:     "/require-liveness-initial-delay",
+			kind:     meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"},
+			object: &corev1.Pod{
+				TypeMeta:   meta.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:          "nginx",
+					Image:         "nginx:latest",
+					LivenessProbe: &corev1.Probe{InitialDelaySeconds: 30},
+				}}},
+			},
+			shouldAllow: true,
+		},
+	}
+
+	for _, tt := range e2eTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			uid := types.UID("e2e-" + tt.path)
+			request := &admission.AdmissionRequest{
+				UID:       uid,
+				Kind:      tt.kind,
+				Operation: admission.Create,
+				Object:    mustRaw(t, tt.object),
+			}
+
+			review := es.review(t, tt.path, request)
+			if review.Response.Allowed != tt.shouldAllow {
+				t.Fatalf(testErrAdmissionMismatch, tt.kind, review.Response.Allowed, tt.shouldAllow)
+			}
+
+			// Rejections are surfaced via the error path of the AdmissionHandler,
+			// which does not (yet) echo the request UID; only check it when allowed.
+			if tt.shouldAllow && review.Response.UID != uid {
+				t.Fatalf("response UID does not match: got %q (wanted %q)", review.Response.UID, uid)
+			}
+
+			if !tt.shouldAllow && (review.Response.Result == nil || review.Response.Result.Message == "") {
+				t.Fatalf("denied response did not include a reason: %#v", review.Response.Result)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package admissioncontrol
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler returns an http.Handler that serves the metrics collected by
+// the given Prometheus registry in the Prometheus exposition format.
+func MetricsHandler(registry prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// NewHealthServer returns a plaintext *http.Server that serves a liveness check
+// at /healthz and, if registry is non-nil, the registry's metrics at /metrics.
+//
+// Admission webhooks must be served over TLS, and the apiserver is often the
+// only client with a trusted certificate: serving health checks and metrics on
+// a separate listener allows the kubelet and Prometheus to reach them without
+// one. The returned server should be started alongside the AdmissionServer.
+func NewHealthServer(addr string, registry prometheus.Gatherer, timeouts ServerTimeouts) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	if registry != nil {
+		mux.Handle("/metrics", MetricsHandler(registry))
+	}
+
+	return NewHTTPServer(addr, mux, timeouts, nil)
+}
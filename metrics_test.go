@@ -0,0 +1,89 @@
+package admissioncontrol
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewHealthServer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	denials := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "admission_denials_total",
+		Help: "The number of denied admission requests.",
+	})
+	registry.MustRegister(denials)
+	denials.Add(3)
+
+	srv := NewHealthServer(":8080", registry, ServerTimeouts{})
+	if srv.TLSConfig != nil {
+		t.Fatalf("the health server should serve plaintext HTTP: got a non-nil TLSConfig")
+	}
+
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	var tests = []struct {
+		testName       string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:       "Health checks are served",
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "OK",
+		},
+		{
+			testName:       "Registered metrics are served",
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "admission_denials_total 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			resp, err := ts.Client().Get(ts.URL + tt.path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("status code mismatch: got %d (wanted %d)", resp.StatusCode, tt.expectedStatus)
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read the response body: %v", err)
+			}
+
+			if !strings.Contains(string(body), tt.expectedBody) {
+				t.Fatalf("response body did not contain %q: got %q", tt.expectedBody, body)
+			}
+		})
+	}
+
+	t.Run("Metrics are not served without a registry", func(t *testing.T) {
+		ts := httptest.NewServer(NewHealthServer(":8080", nil, ServerTimeouts{}).Handler)
+		defer ts.Close()
+
+		resp, err := ts.Client().Get(ts.URL + "/metrics")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status code mismatch: got %d (wanted %d)", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+}
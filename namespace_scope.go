@@ -0,0 +1,102 @@
+package admissioncontrol
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/xerrors"
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// NamespaceScope determines which namespaces a policy applies to.
+//
+// Ignored namespaces are exempt from the policy, and it is enforced everywhere
+// else. If Only is set, the policy is enforced in those namespaces only. Ignored
+// and Only are mutually exclusive: use Validate to check a NamespaceScope
+// before use. The zero value enforces a policy in all namespaces.
+type NamespaceScope struct {
+	// Ignored lists the namespaces the policy is not enforced in.
+	Ignored []string
+	// Only lists the namespaces the policy is exclusively enforced in.
+	Only []string
+}
+
+// Validate returns an error if both Ignored and Only are set.
+func (ns NamespaceScope) Validate() error {
+	if len(ns.Ignored) > 0 && len(ns.Only) > 0 {
+		return xerrors.New("a NamespaceScope cannot set both Ignored and Only namespaces")
+	}
+
+	return nil
+}
+
+// ShouldSkip returns true if a policy with this scope should not be enforced in
+// the given namespace. Matching is case-sensitive.
+func (ns NamespaceScope) ShouldSkip(namespace string) bool {
+	if len(ns.Only) > 0 {
+		return !containsString(ns.Only, namespace)
+	}
+
+	return containsString(ns.Ignored, namespace)
+}
+
+// allow returns an allowed AdmissionResponse (and true) if a policy with this
+// scope should not be enforced in the given namespace.
+func (ns NamespaceScope) allow(namespace string) (*admission.AdmissionResponse, bool) {
+	if !ns.ShouldSkip(namespace) {
+		return nil, false
+	}
+
+	resp := newDefaultDenyResponse()
+	resp.Allowed = true
+	if len(ns.Only) > 0 {
+		resp.Result.Message = fmt.Sprintf("allowing admission: %s namespace is not enforced", namespace)
+	} else {
+		resp.Result.Message = fmt.Sprintf("allowing admission: %s namespace is whitelisted", namespace)
+	}
+
+	return resp, true
+}
+
+// WithNamespaceScope wraps an AdmitFunc so that it is only invoked for objects
+// in namespaces within the given scope; objects in other namespaces are
+// allowed. This applies to any AdmitFunc, including your own: the built-in
+// AdmitFuncs also provide Scoped variants that accept a NamespaceScope.
+//
+// The namespace of the object is used, falling back to the namespace of the
+// request. An error is returned if the scope is invalid.
+func WithNamespaceScope(scope NamespaceScope, admitFunc AdmitFunc) (AdmitFunc, error) {
+	if err := scope.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		var object struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+
+		// Objects are absent on DELETE, and we rely on the request namespace.
+		if raw := admissionReview.Request.Object.Raw; len(raw) > 0 {
+			if err := json.Unmarshal(raw, &object); err != nil {
+				return nil, err
+			}
+		}
+
+		namespace := requestNamespace(admissionReview, object.Metadata.Namespace)
+		if resp, ok := scope.allow(namespace); ok {
+			return resp, nil
+		}
+
+		return admitFunc(admissionReview)
+	}, nil
+}
+
+// invalidNamespaceScope returns an AdmitFunc that denies every request, for a
+// built-in AdmitFunc constructed with an invalid NamespaceScope.
+func invalidNamespaceScope(err error) AdmitFunc {
+	return func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		return newDefaultDenyResponse(), denyf(ReasonInvalidConfiguration, "%v", err)
+	}
+}
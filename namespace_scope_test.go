@@ -0,0 +1,158 @@
+package admissioncontrol
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceScopeShouldSkip(t *testing.T) {
+	t.Parallel()
+
+	var scopeTests = []struct {
+		testName   string
+		scope      NamespaceScope
+		namespace  string
+		shouldSkip bool
+	}{
+		{"Zero value enforces everywhere", NamespaceScope{}, "default", false},
+		{"Ignored namespace is skipped", NamespaceScope{Ignored: []string{"kube-system"}}, "kube-system", true},
+		{"Other namespaces are enforced when ignoring", NamespaceScope{Ignored: []string{"kube-system"}}, "default", false},
+		{"Only namespace is enforced", NamespaceScope{Only: []string{"prod"}}, "prod", false},
+		{"Other namespaces are skipped when using only", NamespaceScope{Only: []string{"prod"}}, "staging", true},
+		{"Matching is case-sensitive", NamespaceScope{Only: []string{"prod"}}, "Prod", true},
+	}
+
+	for _, tt := range scopeTests {
+		if skip := tt.scope.ShouldSkip(tt.namespace); skip != tt.shouldSkip {
+			t.Errorf("%s: got %t (wanted %t)", tt.testName, skip, tt.shouldSkip)
+		}
+	}
+}
+
+func TestNamespaceScopeValidate(t *testing.T) {
+	t.Parallel()
+
+	scope := NamespaceScope{Ignored: []string{"kube-system"}, Only: []string{"prod"}}
+	if err := scope.Validate(); err == nil {
+		t.Fatal("expected an error when both Ignored and Only are set")
+	}
+
+	if _, err := WithNamespaceScope(scope, DenyIngresses(nil)); err == nil {
+		t.Fatal("expected WithNamespaceScope to reject an invalid scope")
+	}
+
+	if err := (NamespaceScope{Only: []string{"prod"}}).Validate(); err != nil {
+		t.Fatalf("unexpected error for a valid scope: %v", err)
+	}
+}
+
+func TestWithNamespaceScope(t *testing.T) {
+	t.Parallel()
+
+	var mustScope = func(scope NamespaceScope, admitFunc AdmitFunc) AdmitFunc {
+		scoped, err := WithNamespaceScope(scope, admitFunc)
+		if err != nil {
+			t.Fatalf("failed to scope AdmitFunc: %v", err)
+		}
+
+		return scoped
+	}
+
+	var (
+		podKind   = meta.GroupVersionKind{Group: "", Kind: "Pod", Version: "v1"}
+		unpinned  = corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}}}
+		onlyProd  = mustScope(NamespaceScope{Only: []string{"prod"}}, EnforceImageDigest(nil, nil))
+		ignoreDev = mustScope(NamespaceScope{Ignored: []string{"dev"}}, EnforceImageDigest(nil, nil))
+	)
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Only: reject in an enforced namespace",
+			admitFunc:       onlyProd,
+			kind:            podKind,
+			object:          newTestPod("prod", unpinned),
+			expectedMessage: "the submitted Pods have images that are not pinned to a digest: [nginx:latest]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Only: allow in other namespaces",
+			admitFunc:   onlyProd,
+			kind:        podKind,
+			object:      newTestPod("staging", unpinned),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Only: fall back to the request namespace",
+			admitFunc:   onlyProd,
+			kind:        podKind,
+			namespace:   "staging",
+			object:      newTestPod("", unpinned),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Ignored: allow in an ignored namespace",
+			admitFunc:   ignoreDev,
+			kind:        podKind,
+			object:      newTestPod("dev", unpinned),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Ignored: reject in other namespaces",
+			admitFunc:       ignoreDev,
+			kind:            podKind,
+			object:          newTestPod("staging", unpinned),
+			expectedMessage: "the submitted Pods have images that are not pinned to a digest: [nginx:latest]",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
+
+func TestScopedAdmitFuncs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		podKind  = meta.GroupVersionKind{Group: "", Kind: "Pod", Version: "v1"}
+		unpinned = corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:latest"}}}
+		onlyProd = EnforceImageDigestScoped(NamespaceScope{Only: []string{"prod"}})
+		invalid  = EnforceImageDigestScoped(NamespaceScope{Ignored: []string{"dev"}, Only: []string{"prod"}})
+	)
+
+	var scopedTests = []objectTest{
+		{
+			testName:        "Only: reject in an enforced namespace",
+			admitFunc:       onlyProd,
+			kind:            podKind,
+			object:          newTestPod("prod", unpinned),
+			expectedMessage: "the submitted Pods have images that are not pinned to a digest: [nginx:latest]",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Only: allow in other namespaces",
+			admitFunc:   onlyProd,
+			kind:        podKind,
+			object:      newTestPod("staging", unpinned),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Reject every request when the scope is invalid",
+			admitFunc:       invalid,
+			kind:            podKind,
+			object:          newTestPod("prod", unpinned),
+			expectedMessage: "a NamespaceScope cannot set both Ignored and Only namespaces",
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Ignored namespaces take precedence over enforced namespaces",
+			admitFunc:   EnforceImageDigest([]string{"prod"}, []string{"prod"}),
+			kind:        podKind,
+			object:      newTestPod("prod", unpinned),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, scopedTests)
+}
@@ -0,0 +1,201 @@
+package admissioncontrol
+
+import (
+	"golang.org/x/xerrors"
+)
+
+// ReasonCode is a stable, machine-readable code describing why a built-in
+// AdmitFunc denied admission. Unlike rejection messages, reason codes do not
+// change between releases, and are suitable for aggregating denials.
+//
+// The AdmissionHandler attaches the ReasonCode of a denial to the response as
+// the "reason" auditAnnotation.
+type ReasonCode string
+
+// The reason codes returned by the built-in AdmitFuncs.
+const (
+	// ReasonInvalidConfiguration is returned when an AdmitFunc was constructed
+	// with invalid arguments, such as a nil pattern or matchFunc.
+	ReasonInvalidConfiguration ReasonCode = "INVALID_CONFIGURATION"
+	// ReasonUnsupportedKind is returned when an AdmitFunc cannot handle the Kind
+	// of the submitted object, and is wrapped with WithUnsupportedKindPolicy to
+	// reject unsupported Kinds.
+	ReasonUnsupportedKind ReasonCode = "UNSUPPORTED_KIND"
+	// ReasonIngressForbidden is returned by DenyIngresses.
+	ReasonIngressForbidden ReasonCode = "INGRESS_FORBIDDEN"
+	// ReasonPublicLoadBalancer is returned by DenyPublicLoadBalancers.
+	ReasonPublicLoadBalancer ReasonCode = "PUBLIC_LOAD_BALANCER"
+	// ReasonInvalidTrafficPolicy is returned by ValidateLocalTrafficPolicy.
+	ReasonInvalidTrafficPolicy ReasonCode = "INVALID_TRAFFIC_POLICY"
+	// ReasonSelectorOverlap is returned by DenyCrossNamespaceSelectorOverlap.
+	ReasonSelectorOverlap ReasonCode = "SELECTOR_OVERLAP"
+	// ReasonNonFQDNExternalName is returned by DenyNonFQDNExternalNames.
+	ReasonNonFQDNExternalName ReasonCode = "NON_FQDN_EXTERNAL_NAME"
+	// ReasonMissingAnnotation is returned by EnforcePodAnnotations,
+	// EnforcePodAnnotationsAnyOf, EnforceServiceAccountAnnotations and
+	// RequireChartVersionAnnotation.
+	ReasonMissingAnnotation ReasonCode = "MISSING_ANNOTATION"
+	// ReasonClusterAdminBinding is returned by DenyClusterAdminBindings.
+	ReasonClusterAdminBinding ReasonCode = "CLUSTER_ADMIN_BINDING"
+	// ReasonLivenessInitialDelay is returned by RequireLivenessInitialDelay.
+	ReasonLivenessInitialDelay ReasonCode = "LIVENESS_INITIAL_DELAY"
+	// ReasonPrivilegedContainerPort is returned by DenyPrivilegedContainerPorts.
+	ReasonPrivilegedContainerPort ReasonCode = "PRIVILEGED_CONTAINER_PORT"
+	// ReasonDuplicateMountPath is returned by DenyDuplicateMountPaths.
+	ReasonDuplicateMountPath ReasonCode = "DUPLICATE_MOUNT_PATH"
+	// ReasonInvalidContainerName is returned by EnforceContainerNames.
+	ReasonInvalidContainerName ReasonCode = "INVALID_CONTAINER_NAME"
+	// ReasonForbiddenSecretReference is returned by RestrictSecretReferences.
+	ReasonForbiddenSecretReference ReasonCode = "FORBIDDEN_SECRET_REFERENCE"
+	// ReasonAllowAllNetworkPolicy is returned by DenyAllowAllNetworkPolicies.
+	ReasonAllowAllNetworkPolicy ReasonCode = "ALLOW_ALL_NETWORK_POLICY"
+	// ReasonRestrictedProfile is returned by EnforceRestrictedProfile.
+	ReasonRestrictedProfile ReasonCode = "RESTRICTED_PROFILE_VIOLATION"
+	// ReasonBroadPodDisruptionBudget is returned by DenyBroadPDB.
+	ReasonBroadPodDisruptionBudget ReasonCode = "BROAD_POD_DISRUPTION_BUDGET"
+	// ReasonDefaultServiceAccountToken is returned by
+	// DenyDefaultServiceAccountToken.
+	ReasonDefaultServiceAccountToken ReasonCode = "DEFAULT_SERVICE_ACCOUNT_TOKEN"
+	// ReasonTerminationGracePeriod is returned by
+	// RequireTerminationGracePeriodBounds.
+	ReasonTerminationGracePeriod ReasonCode = "TERMINATION_GRACE_PERIOD_OUT_OF_BOUNDS"
+	// ReasonSidecarInjectionOptOut is returned by DenySidecarInjectionOptOut.
+	ReasonSidecarInjectionOptOut ReasonCode = "SIDECAR_INJECTION_OPT_OUT"
+	// ReasonMissingFsGroup is returned by RequireFsGroup.
+	ReasonMissingFsGroup ReasonCode = "MISSING_FS_GROUP"
+	// ReasonMissingResourceClaim is returned by RequireResourceClaims.
+	ReasonMissingResourceClaim ReasonCode = "MISSING_RESOURCE_CLAIM"
+	// ReasonImageNotPinned is returned by EnforceImageDigest.
+	ReasonImageNotPinned ReasonCode = "IMAGE_NOT_PINNED"
+	// ReasonRunAsUserOutOfRange is returned by RequireRunAsUserInRange.
+	ReasonRunAsUserOutOfRange ReasonCode = "RUN_AS_USER_OUT_OF_RANGE"
+	// ReasonClusterScopedCreation is returned by DenyClusterScopedCreation.
+	ReasonClusterScopedCreation ReasonCode = "CLUSTER_SCOPED_CREATION"
+	// ReasonConfigMapTooLarge is returned by LimitConfigMapSize.
+	ReasonConfigMapTooLarge ReasonCode = "CONFIG_MAP_TOO_LARGE"
+	// ReasonSecretTypeMismatch is returned by EnforceSecretTypeConventions.
+	ReasonSecretTypeMismatch ReasonCode = "SECRET_TYPE_MISMATCH"
+	// ReasonWindowsHostProcess is returned by DenyWindowsHostProcess.
+	ReasonWindowsHostProcess ReasonCode = "WINDOWS_HOST_PROCESS"
+	// ReasonUnboundedMemoryVolume is returned by
+	// RequireMemoryEmptyDirSizeLimit.
+	ReasonUnboundedMemoryVolume ReasonCode = "UNBOUNDED_MEMORY_VOLUME"
+	// ReasonNeverPullPolicy is returned by DenyNeverPullPolicy.
+	ReasonNeverPullPolicy ReasonCode = "NEVER_PULL_POLICY"
+	// ReasonMissingReferencedResource is returned by
+	// RequireReferencedResourcesExist.
+	ReasonMissingReferencedResource ReasonCode = "MISSING_REFERENCED_RESOURCE"
+	// ReasonDirectNodeAssignment is returned by DenyDirectNodeAssignment.
+	ReasonDirectNodeAssignment ReasonCode = "DIRECT_NODE_ASSIGNMENT"
+	// ReasonReadinessGateWithoutProbe is returned by
+	// ValidateReadinessGateConsistency.
+	ReasonReadinessGateWithoutProbe ReasonCode = "READINESS_GATE_WITHOUT_PROBE"
+	// ReasonInvalidField is returned by EnforceCRDField.
+	ReasonInvalidField ReasonCode = "INVALID_FIELD"
+	// ReasonInvalidDefaultContainer is returned by
+	// ValidateDefaultContainerAnnotation.
+	ReasonInvalidDefaultContainer ReasonCode = "INVALID_DEFAULT_CONTAINER"
+	// ReasonDeprecatedAnnotation is returned by DenyDeprecatedAnnotations.
+	ReasonDeprecatedAnnotation ReasonCode = "DEPRECATED_ANNOTATION"
+	// ReasonDisallowedRuntimeClass is returned by RequireRuntimeClass.
+	ReasonDisallowedRuntimeClass ReasonCode = "DISALLOWED_RUNTIME_CLASS"
+	// ReasonUniversalToleration is returned by DenyUniversalTolerations.
+	ReasonUniversalToleration ReasonCode = "UNIVERSAL_TOLERATION"
+	// ReasonConflictingIngressPath is returned by DenyConflictingIngressPaths.
+	ReasonConflictingIngressPath ReasonCode = "CONFLICTING_INGRESS_PATH"
+	// ReasonMissingVolume is returned by RequireVolumes.
+	ReasonMissingVolume ReasonCode = "MISSING_VOLUME"
+	// ReasonAggregatedClusterRoleEdit is returned by
+	// DenyAggregatedClusterRoleEdits.
+	ReasonAggregatedClusterRoleEdit ReasonCode = "AGGREGATED_CLUSTER_ROLE_EDIT"
+	// ReasonInvalidInitContainerRestartPolicy is returned by
+	// ValidateInitContainerRestartPolicy.
+	ReasonInvalidInitContainerRestartPolicy ReasonCode = "INVALID_INIT_CONTAINER_RESTART_POLICY"
+	// ReasonPublishNotReadyAddresses is returned by DenyPublishNotReadyAddresses.
+	ReasonPublishNotReadyAddresses ReasonCode = "PUBLISH_NOT_READY_ADDRESSES"
+	// ReasonDisallowedAccessMode is returned by RestrictPVCAccessModes.
+	ReasonDisallowedAccessMode ReasonCode = "DISALLOWED_ACCESS_MODE"
+	// ReasonQuotaExceeded is returned by PreflightQuotaCheck.
+	ReasonQuotaExceeded ReasonCode = "QUOTA_EXCEEDED"
+	// ReasonImplicitSessionAffinityTimeout is returned by
+	// RequireSessionAffinityTimeout.
+	ReasonImplicitSessionAffinityTimeout ReasonCode = "IMPLICIT_SESSION_AFFINITY_TIMEOUT"
+	// ReasonInvalidHugePages is returned by ValidateHugePages.
+	ReasonInvalidHugePages ReasonCode = "INVALID_HUGE_PAGES"
+	// ReasonUnsignedImage is returned by RequireSignedImages.
+	ReasonUnsignedImage ReasonCode = "UNSIGNED_IMAGE"
+	// ReasonDebugCapability is returned by DenyDebugCapabilities.
+	ReasonDebugCapability ReasonCode = "DEBUG_CAPABILITY"
+	// ReasonMissingOwnerLabel is returned by RequireServiceOwnerLabel.
+	ReasonMissingOwnerLabel ReasonCode = "MISSING_OWNER_LABEL"
+	// ReasonMissingGPUScheduling is returned by RequireGPUScheduling.
+	ReasonMissingGPUScheduling ReasonCode = "MISSING_GPU_SCHEDULING"
+	// ReasonInvalidProgressDeadline is returned by RequireProgressDeadline.
+	ReasonInvalidProgressDeadline ReasonCode = "INVALID_PROGRESS_DEADLINE"
+	// ReasonUnmaskedProcMount is returned by DenyUnmaskedProcMount.
+	ReasonUnmaskedProcMount ReasonCode = "UNMASKED_PROC_MOUNT"
+	// ReasonExternalEndpoint is returned by DenyExternalEndpoints.
+	ReasonExternalEndpoint ReasonCode = "EXTERNAL_ENDPOINT"
+	// ReasonServiceLinksEnabled is returned by DenyServiceLinks.
+	ReasonServiceLinksEnabled ReasonCode = "SERVICE_LINKS_ENABLED"
+	// ReasonTooManyAffinityTerms is returned by LimitAffinityTerms.
+	ReasonTooManyAffinityTerms ReasonCode = "TOO_MANY_AFFINITY_TERMS"
+	// ReasonFIPSImagePolicy is returned by EnforceFIPSImagePolicy.
+	ReasonFIPSImagePolicy ReasonCode = "FIPS_IMAGE_POLICY"
+	// ReasonDisallowedAppArmorProfile is returned by RequireAppArmorProfile.
+	ReasonDisallowedAppArmorProfile ReasonCode = "DISALLOWED_APPARMOR_PROFILE"
+	// ReasonAllNamespacesAffinity is returned by
+	// RestrictAffinityNamespaceSelectors.
+	ReasonAllNamespacesAffinity ReasonCode = "ALL_NAMESPACES_AFFINITY"
+	// ReasonPullPolicyChanged is returned by DenyPullPolicyChanges.
+	ReasonPullPolicyChanged ReasonCode = "PULL_POLICY_CHANGED"
+	// ReasonQoSClassMismatch is returned by ValidateQoSConsistency.
+	ReasonQoSClassMismatch ReasonCode = "QOS_CLASS_MISMATCH"
+	// ReasonMissingZoneSpread is returned by RequireZoneSpread.
+	ReasonMissingZoneSpread ReasonCode = "MISSING_ZONE_SPREAD"
+	// ReasonInvalidWorkingDir is returned by ValidateWorkingDir.
+	ReasonInvalidWorkingDir ReasonCode = "INVALID_WORKING_DIR"
+	// ReasonInvalidExclusiveCPU is returned by ValidateExclusiveCPU.
+	ReasonInvalidExclusiveCPU ReasonCode = "INVALID_EXCLUSIVE_CPU"
+	// ReasonInvalidRevisionHistoryLimit is returned by
+	// RequireRevisionHistoryLimit.
+	ReasonInvalidRevisionHistoryLimit ReasonCode = "INVALID_REVISION_HISTORY_LIMIT"
+	// ReasonObjectTooLarge is returned by an AdmitFunc wrapped with
+	// WithObjectSizeLimit when the object exceeds the limit for its Kind.
+	ReasonObjectTooLarge ReasonCode = "OBJECT_TOO_LARGE"
+	// ReasonDependencyUnavailable is returned by an AdmitFunc wrapped with
+	// WithCircuitBreaker while the breaker is open, and failing closed.
+	ReasonDependencyUnavailable ReasonCode = "DEPENDENCY_UNAVAILABLE"
+)
+
+// reasonError is a denial annotated with a ReasonCode. Its message is that of
+// the underlying error.
+type reasonError struct {
+	code ReasonCode
+	err  error
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+// denyf formats a denial as per xerrors.Errorf, and annotates it with the given
+// ReasonCode.
+func denyf(code ReasonCode, format string, args ...interface{}) error {
+	return &reasonError{code: code, err: xerrors.Errorf(format, args...)}
+}
+
+// ReasonCodeOf returns the ReasonCode of an error returned by an AdmitFunc, or
+// an empty ReasonCode if it does not have one.
+func ReasonCodeOf(err error) ReasonCode {
+	var re *reasonError
+	if xerrors.As(err, &re) {
+		return re.code
+	}
+
+	return ""
+}
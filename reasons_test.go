@@ -0,0 +1,733 @@
+package admissioncontrol
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	admission "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestReasonCodes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		podKind                 = meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}
+		serviceKind             = meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Service"}
+		container               = corev1.Container{Name: "app", Image: "app:v1"}
+		unmaskedProcMount       = corev1.UnmaskedProcMount
+		threeReplicas     int32 = 3
+	)
+
+	var exhaustedQuota = &fakeQuotaLister{quotas: map[string][]corev1.ResourceQuota{
+		"default": {{Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("0")},
+		}}},
+	}}
+
+	var newService = func(spec corev1.ServiceSpec) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+			Spec:       spec,
+		}
+	}
+
+	var reasonTests = []struct {
+		testName  string
+		admitFunc AdmitFunc
+		kind      meta.GroupVersionKind
+		operation admission.Operation
+		object    interface{}
+		oldObject interface{}
+		reason    ReasonCode
+	}{
+		{
+			testName:  "EnforceContainerNames with a nil pattern",
+			admitFunc: EnforceContainerNames(nil, nil),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonInvalidConfiguration,
+		},
+		{
+			testName:  "EnforcePodAnnotations with an unsupported Kind",
+			admitFunc: WithUnsupportedKindPolicy(RejectUnsupportedKinds, EnforcePodAnnotations(nil, nil)),
+			kind:      meta.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"},
+			object:    &corev1.ConfigMap{},
+			reason:    ReasonUnsupportedKind,
+		},
+		{
+			testName:  "DenyIngresses",
+			admitFunc: DenyIngresses(nil),
+			kind:      meta.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			object:    &extensionsv1beta1.Ingress{ObjectMeta: meta.ObjectMeta{Name: "hello-ingress", Namespace: "default"}},
+			reason:    ReasonIngressForbidden,
+		},
+		{
+			testName:  "DenyPublicLoadBalancers",
+			admitFunc: DenyPublicLoadBalancers(nil, GCP),
+			kind:      serviceKind,
+			object:    newService(corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}),
+			reason:    ReasonPublicLoadBalancer,
+		},
+		{
+			testName:  "ValidateLocalTrafficPolicy",
+			admitFunc: ValidateLocalTrafficPolicy(nil),
+			kind:      serviceKind,
+			object:    newService(corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, HealthCheckNodePort: 30000}),
+			reason:    ReasonInvalidTrafficPolicy,
+		},
+		{
+			testName:  "DenyCrossNamespaceSelectorOverlap",
+			admitFunc: DenyCrossNamespaceSelectorOverlap(map[string]string{"k8s-app": "kube-dns"}),
+			kind:      serviceKind,
+			object:    newService(corev1.ServiceSpec{Selector: map[string]string{"k8s-app": "kube-dns"}}),
+			reason:    ReasonSelectorOverlap,
+		},
+		{
+			testName:  "DenyNonFQDNExternalNames",
+			admitFunc: DenyNonFQDNExternalNames(nil),
+			kind:      serviceKind,
+			object:    newService(corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db"}),
+			reason:    ReasonNonFQDNExternalName,
+		},
+		{
+			testName: "EnforcePodAnnotations",
+			admitFunc: EnforcePodAnnotations(nil, map[string]func(string) bool{
+				"buildVersion": func(s string) bool { return s != "" },
+			}),
+			kind:   podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason: ReasonMissingAnnotation,
+		},
+		{
+			testName:  "DenyClusterAdminBindings",
+			admitFunc: DenyClusterAdminBindings(""),
+			kind:      meta.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+			object: &rbacv1.ClusterRoleBinding{
+				ObjectMeta: meta.ObjectMeta{Name: "ci-admin"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+			reason: ReasonClusterAdminBinding,
+		},
+		{
+			testName:  "RequireLivenessInitialDelay",
+			admitFunc: RequireLivenessInitialDelay(nil, 10),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", LivenessProbe: &corev1.Probe{}},
+			}}),
+			reason: ReasonLivenessInitialDelay,
+		},
+		{
+			testName:  "DenyPrivilegedContainerPorts",
+			admitFunc: DenyPrivilegedContainerPorts(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", Ports: []corev1.ContainerPort{{ContainerPort: 80}}},
+			}}),
+			reason: ReasonPrivilegedContainerPort,
+		},
+		{
+			testName:  "DenyDuplicateMountPaths",
+			admitFunc: DenyDuplicateMountPaths(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", VolumeMounts: []corev1.VolumeMount{
+					{Name: "a", MountPath: "/data"},
+					{Name: "b", MountPath: "/data"},
+				}},
+			}}),
+			reason: ReasonDuplicateMountPath,
+		},
+		{
+			testName:  "EnforceContainerNames",
+			admitFunc: EnforceContainerNames(nil, regexp.MustCompile(`^web$`)),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonInvalidContainerName,
+		},
+		{
+			testName:  "RestrictSecretReferences",
+			admitFunc: RestrictSecretReferences(nil, regexp.MustCompile(`^hello-app-`)),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				Volumes: []corev1.Volume{{
+					Name:         "tls",
+					VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "wildcard-tls"}},
+				}},
+			}),
+			reason: ReasonForbiddenSecretReference,
+		},
+		{
+			testName:  "DenyAllowAllNetworkPolicies",
+			admitFunc: DenyAllowAllNetworkPolicies(nil),
+			kind:      meta.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+			object: &networkingv1.NetworkPolicy{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-policy", Namespace: "default"},
+				Spec:       networkingv1.NetworkPolicySpec{Ingress: []networkingv1.NetworkPolicyIngressRule{{}}},
+			},
+			reason: ReasonAllowAllNetworkPolicy,
+		},
+		{
+			testName:  "EnforceRestrictedProfile",
+			admitFunc: EnforceRestrictedProfile(nil),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{HostPID: true, Containers: []corev1.Container{container}}),
+			reason:    ReasonRestrictedProfile,
+		},
+		{
+			testName:  "DenyBroadPDB",
+			admitFunc: DenyBroadPDB(nil),
+			kind:      meta.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+			object:    &policyv1.PodDisruptionBudget{ObjectMeta: meta.ObjectMeta{Name: "hello-pdb", Namespace: "default"}},
+			reason:    ReasonBroadPodDisruptionBudget,
+		},
+		{
+			testName:  "DenyDefaultServiceAccountToken",
+			admitFunc: DenyDefaultServiceAccountToken(nil),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonDefaultServiceAccountToken,
+		},
+		{
+			testName:  "RequireTerminationGracePeriodBounds",
+			admitFunc: RequireTerminationGracePeriodBounds(nil, 60, 300),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonTerminationGracePeriod,
+		},
+		{
+			testName:  "DenySidecarInjectionOptOut",
+			admitFunc: DenySidecarInjectionOptOut(nil, map[string]string{"sidecar.istio.io/inject": "false"}),
+			kind:      podKind,
+			object: &corev1.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "hello-app",
+					Namespace:   "default",
+					Annotations: map[string]string{"sidecar.istio.io/inject": "false"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+			reason: ReasonSidecarInjectionOptOut,
+		},
+		{
+			testName:  "RequireFsGroup",
+			admitFunc: RequireFsGroup(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				Volumes: []corev1.Volume{{
+					Name:         "data",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}},
+				}},
+			}),
+			reason: ReasonMissingFsGroup,
+		},
+		{
+			testName:  "RequireResourceClaims",
+			admitFunc: RequireResourceClaims(nil, []string{"nvidia.com/gpu"}),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "trainer",
+				Image:     "trainer:v1",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}},
+			}}}),
+			reason: ReasonMissingResourceClaim,
+		},
+		{
+			testName:  "EnforceImageDigest",
+			admitFunc: EnforceImageDigest(nil, nil),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonImageNotPinned,
+		},
+		{
+			testName:  "RequireRunAsUserInRange",
+			admitFunc: RequireRunAsUserInRange(map[string][2]int64{"default": {10000, 19999}}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonRunAsUserOutOfRange,
+		},
+		{
+			testName:  "DenyClusterScopedCreation",
+			admitFunc: DenyClusterScopedCreation([]string{"system:masters"}),
+			kind:      meta.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+			operation: admission.Create,
+			object:    &rbacv1.ClusterRole{ObjectMeta: meta.ObjectMeta{Name: "widget-reader"}},
+			reason:    ReasonClusterScopedCreation,
+		},
+		{
+			testName:  "LimitConfigMapSize",
+			admitFunc: LimitConfigMapSize(nil, 1),
+			kind:      meta.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"},
+			object:    &corev1.ConfigMap{Data: map[string]string{"key": "value"}},
+			reason:    ReasonConfigMapTooLarge,
+		},
+		{
+			testName:  "EnforceSecretTypeConventions",
+			admitFunc: EnforceSecretTypeConventions(map[string]corev1.SecretType{"*-tls": corev1.SecretTypeTLS}),
+			kind:      meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Secret"},
+			object:    &corev1.Secret{ObjectMeta: meta.ObjectMeta{Name: "hello-tls"}},
+			reason:    ReasonSecretTypeMismatch,
+		},
+		{
+			testName:  "DenyWindowsHostProcess",
+			admitFunc: DenyWindowsHostProcess(nil),
+			kind:      podKind,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "hello-app", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"securityContext": map[string]interface{}{"windowsOptions": map[string]interface{}{"hostProcess": true}},
+					"containers":      []interface{}{map[string]interface{}{"name": "agent", "image": "agent:v1"}},
+				},
+			},
+			reason: ReasonWindowsHostProcess,
+		},
+		{
+			testName:  "RequireMemoryEmptyDirSizeLimit",
+			admitFunc: RequireMemoryEmptyDirSizeLimit(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				Volumes: []corev1.Volume{{
+					Name:         "cache",
+					VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}},
+				}},
+			}),
+			reason: ReasonUnboundedMemoryVolume,
+		},
+		{
+			testName:  "EnforceServiceAccountAnnotations",
+			admitFunc: EnforceServiceAccountAnnotations(nil, map[string]func(string) bool{"iam.gke.io/gcp-service-account": func(string) bool { return true }}),
+			kind:      meta.GroupVersionKind{Group: "", Kind: "ServiceAccount", Version: "v1"},
+			object:    &corev1.ServiceAccount{ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"}},
+			reason:    ReasonMissingAnnotation,
+		},
+		{
+			testName:  "EnforcePodAnnotationsAnyOf",
+			admitFunc: EnforcePodAnnotationsAnyOf(nil, []map[string]func(string) bool{{"buildVersion": func(string) bool { return true }}}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonMissingAnnotation,
+		},
+		{
+			testName:  "DenyNeverPullPolicy",
+			admitFunc: DenyNeverPullPolicy(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullNever}},
+			}),
+			reason: ReasonNeverPullPolicy,
+		},
+		{
+			testName:  "RequireReferencedResourcesExist",
+			admitFunc: RequireReferencedResourcesExist(nil, &fakeResourceLister{}),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				Volumes: []corev1.Volume{{
+					Name:         "config",
+					VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}}},
+				}},
+			}),
+			reason: ReasonMissingReferencedResource,
+		},
+		{
+			testName:  "DenyDirectNodeAssignment",
+			admitFunc: DenyDirectNodeAssignment(nil),
+			kind:      podKind,
+			operation: admission.Create,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				NodeName:   "node-1",
+			}),
+			reason: ReasonDirectNodeAssignment,
+		},
+		{
+			testName:  "ValidateReadinessGateConsistency",
+			admitFunc: ValidateReadinessGateConsistency(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers:     []corev1.Container{container},
+				ReadinessGates: []corev1.PodReadinessGate{{ConditionType: "example.com/load-balancer-ready"}},
+			}),
+			reason: ReasonReadinessGateWithoutProbe,
+		},
+		{
+			testName:  "EnforceCRDField",
+			admitFunc: EnforceCRDField(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"}, "spec.tier", func(string) bool { return false }),
+			kind:      meta.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"},
+			object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Database",
+				"metadata":   map[string]interface{}{"name": "orders"},
+				"spec":       map[string]interface{}{"tier": "gold"},
+			},
+			reason: ReasonInvalidField,
+		},
+		{
+			testName:  "ValidateDefaultContainerAnnotation",
+			admitFunc: ValidateDefaultContainerAnnotation(nil),
+			kind:      podKind,
+			object: &corev1.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "hello-app",
+					Namespace:   "default",
+					Annotations: map[string]string{"kubectl.kubernetes.io/default-container": "ap"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+			reason: ReasonInvalidDefaultContainer,
+		},
+		{
+			testName:  "DenyDeprecatedAnnotations",
+			admitFunc: DenyDeprecatedAnnotations(map[string]string{"scheduler.alpha.kubernetes.io/critical-pod": ""}),
+			kind:      podKind,
+			object: &corev1.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "hello-app",
+					Namespace:   "default",
+					Annotations: map[string]string{"scheduler.alpha.kubernetes.io/critical-pod": ""},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+			reason: ReasonDeprecatedAnnotation,
+		},
+		{
+			testName:  "RequireRuntimeClass",
+			admitFunc: RequireRuntimeClass(nil, []string{"gvisor"}),
+			kind:      podKind,
+			object:    newTestPod("tenant-a", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonDisallowedRuntimeClass,
+		},
+		{
+			testName:  "DenyUniversalTolerations",
+			admitFunc: DenyUniversalTolerations(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers:  []corev1.Container{container},
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			}),
+			reason: ReasonUniversalToleration,
+		},
+		{
+			testName:  "DenyConflictingIngressPaths",
+			admitFunc: DenyConflictingIngressPaths(fakeIngressLister{newTestIngress("team-a", "existing", "example.com", "/")}),
+			kind:      meta.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+			object:    newTestIngress("team-b", "hello-ingress", "example.com", "/"),
+			reason:    ReasonConflictingIngressPath,
+		},
+		{
+			testName:  "RequireVolumes",
+			admitFunc: RequireVolumes(nil, []string{"logging"}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonMissingVolume,
+		},
+		{
+			testName:  "DenyAggregatedClusterRoleEdits",
+			admitFunc: DenyAggregatedClusterRoleEdits(""),
+			kind:      meta.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+			operation: admission.Update,
+			object: &rbacv1.ClusterRole{
+				ObjectMeta:      meta.ObjectMeta{Name: "monitoring"},
+				AggregationRule: &rbacv1.AggregationRule{},
+			},
+			reason: ReasonAggregatedClusterRoleEdit,
+		},
+		{
+			testName:  "ValidateInitContainerRestartPolicy",
+			admitFunc: ValidateInitContainerRestartPolicy(nil, false),
+			kind:      podKind,
+			object: json.RawMessage(`{
+				"metadata": {"name": "hello-app", "namespace": "default"},
+				"spec": {
+					"initContainers": [{"name": "proxy", "image": "proxy:v1", "restartPolicy": "Always"}],
+					"containers": [{"name": "app", "image": "app:v1"}]
+				}
+			}`),
+			reason: ReasonInvalidInitContainerRestartPolicy,
+		},
+		{
+			testName:  "DenyPublishNotReadyAddresses",
+			admitFunc: DenyPublishNotReadyAddresses(nil),
+			kind:      serviceKind,
+			object: &corev1.Service{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+				Spec:       corev1.ServiceSpec{PublishNotReadyAddresses: true},
+			},
+			reason: ReasonPublishNotReadyAddresses,
+		},
+		{
+			testName:  "WithObjectSizeLimit",
+			admitFunc: WithObjectSizeLimit(DenyIngresses(nil), map[string]int{"Pod": 16}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonObjectTooLarge,
+		},
+		{
+			testName:  "RestrictPVCAccessModes",
+			admitFunc: RestrictPVCAccessModes(nil, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}),
+			kind:      meta.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"},
+			object: &corev1.PersistentVolumeClaim{
+				ObjectMeta: meta.ObjectMeta{Name: "data", Namespace: "default"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				},
+			},
+			reason: ReasonDisallowedAccessMode,
+		},
+		{
+			testName:  "PreflightQuotaCheck",
+			admitFunc: PreflightQuotaCheck(exhaustedQuota),
+			kind:      meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			operation: admission.Create,
+			object:    &appsv1.Deployment{ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"}},
+			reason:    ReasonQuotaExceeded,
+		},
+		{
+			testName:  "RequireSessionAffinityTimeout",
+			admitFunc: RequireSessionAffinityTimeout(nil),
+			kind:      serviceKind,
+			object:    newService(corev1.ServiceSpec{SessionAffinity: corev1.ServiceAffinityClientIP}),
+			reason:    ReasonImplicitSessionAffinityTimeout,
+		},
+		{
+			testName:  "ValidateHugePages",
+			admitFunc: ValidateHugePages(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:v1",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"hugepages-2Mi": resource.MustParse("100Mi")},
+				},
+			}}}),
+			reason: ReasonInvalidHugePages,
+		},
+		{
+			testName:  "RequireChartVersionAnnotation",
+			admitFunc: RequireChartVersionAnnotation(nil, "helm.sh/chart-version"),
+			kind:      meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			object:    &appsv1.Deployment{ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"}},
+			reason:    ReasonMissingAnnotation,
+		},
+		{
+			testName:  "RequireSignedImages",
+			admitFunc: RequireSignedImages(nil, fakeImageVerifier{}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonUnsignedImage,
+		},
+		{
+			testName:  "DenyDebugCapabilities",
+			admitFunc: DenyDebugCapabilities(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:v1",
+				SecurityContext: &corev1.SecurityContext{
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+				},
+			}}}),
+			reason: ReasonDebugCapability,
+		},
+		{
+			testName:  "RequireServiceOwnerLabel",
+			admitFunc: RequireServiceOwnerLabel(nil, "owner"),
+			kind:      serviceKind,
+			object:    newService(corev1.ServiceSpec{}),
+			reason:    ReasonMissingOwnerLabel,
+		},
+		{
+			testName:  "RequireGPUScheduling",
+			admitFunc: RequireGPUScheduling(nil, "nvidia.com/gpu", nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:v1",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}}}),
+			reason: ReasonMissingGPUScheduling,
+		},
+		{
+			testName:  "RequireProgressDeadline",
+			admitFunc: RequireProgressDeadline(nil, 600),
+			kind:      meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			object:    &appsv1.Deployment{ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"}},
+			reason:    ReasonInvalidProgressDeadline,
+		},
+		{
+			testName:  "DenyUnmaskedProcMount",
+			admitFunc: DenyUnmaskedProcMount(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{
+				Name:            "app",
+				Image:           "app:v1",
+				SecurityContext: &corev1.SecurityContext{ProcMount: &unmaskedProcMount},
+			}}}),
+			reason: ReasonUnmaskedProcMount,
+		},
+		{
+			testName:  "DenyExternalEndpoints",
+			admitFunc: DenyExternalEndpoints(nil, []string{"10.0.0.0/8"}),
+			kind:      meta.GroupVersionKind{Group: "", Version: "v1", Kind: "Endpoints"},
+			object: &corev1.Endpoints{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-service", Namespace: "default"},
+				Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "203.0.113.10"}}}},
+			},
+			reason: ReasonExternalEndpoint,
+		},
+		{
+			testName:  "DenyServiceLinks",
+			admitFunc: DenyServiceLinks(nil),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonServiceLinksEnabled,
+		},
+		{
+			testName:  "LimitAffinityTerms",
+			admitFunc: LimitAffinityTerms(nil, 1),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: make([]corev1.PreferredSchedulingTerm, 2),
+				}},
+			}),
+			reason: ReasonTooManyAffinityTerms,
+		},
+		{
+			testName:  "EnforceFIPSImagePolicy",
+			admitFunc: EnforceFIPSImagePolicy(nil, []string{"registry.example.com"}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonFIPSImagePolicy,
+		},
+		{
+			testName:  "RequireAppArmorProfile",
+			admitFunc: RequireAppArmorProfile(nil, []string{"runtime/default"}),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonDisallowedAppArmorProfile,
+		},
+		{
+			testName:  "RestrictAffinityNamespaceSelectors",
+			admitFunc: RestrictAffinityNamespaceSelectors(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				Affinity: &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+						NamespaceSelector: &meta.LabelSelector{},
+						TopologyKey:       "kubernetes.io/hostname",
+					}},
+				}},
+			}),
+			reason: ReasonAllNamespacesAffinity,
+		},
+		{
+			testName:  "DenyPullPolicyChanges",
+			admitFunc: DenyPullPolicyChanges(),
+			kind:      podKind,
+			operation: admission.Update,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullAlways},
+			}}),
+			oldObject: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullIfNotPresent},
+			}}),
+			reason: ReasonPullPolicyChanged,
+		},
+		{
+			testName:  "ValidateQoSConsistency",
+			admitFunc: ValidateQoSConsistency(nil, corev1.PodQOSGuaranteed),
+			kind:      podKind,
+			object:    newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{container}}),
+			reason:    ReasonQoSClassMismatch,
+		},
+		{
+			testName:  "RequireZoneSpread",
+			admitFunc: RequireZoneSpread(nil),
+			kind:      meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			object: &appsv1.Deployment{
+				ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &threeReplicas},
+			},
+			reason: ReasonMissingZoneSpread,
+		},
+		{
+			testName:  "ValidateWorkingDir",
+			admitFunc: ValidateWorkingDir(nil),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", WorkingDir: "/app/../etc"},
+			}}),
+			reason: ReasonInvalidWorkingDir,
+		},
+		{
+			testName:  "ValidateExclusiveCPU",
+			admitFunc: ValidateExclusiveCPU(nil),
+			kind:      podKind,
+			object: &corev1.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "hello-app",
+					Namespace:   "default",
+					Annotations: map[string]string{ExclusiveCPUAnnotation: "true"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+			reason: ReasonInvalidExclusiveCPU,
+		},
+		{
+			testName:  "RequireRevisionHistoryLimit",
+			admitFunc: RequireRevisionHistoryLimit(nil, 10),
+			kind:      meta.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			object:    &appsv1.Deployment{ObjectMeta: meta.ObjectMeta{Name: "hello-app", Namespace: "default"}},
+			reason:    ReasonInvalidRevisionHistoryLimit,
+		},
+	}
+
+	for _, tt := range reasonTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			raw, err := json.Marshal(tt.object)
+			if err != nil {
+				t.Fatalf("could not marshal k8s API object: %v", err)
+			}
+
+			review := &admission.AdmissionReview{
+				Request: &admission.AdmissionRequest{Kind: tt.kind, Operation: tt.operation},
+			}
+			review.Request.Object.Raw = raw
+
+			if tt.oldObject != nil {
+				review.Request.OldObject.Raw, err = json.Marshal(tt.oldObject)
+				if err != nil {
+					t.Fatalf("could not marshal k8s API object: %v", err)
+				}
+			}
+
+			_, err = tt.admitFunc(review)
+			if err == nil {
+				t.Fatalf("expected admission to be denied")
+			}
+
+			if reason := ReasonCodeOf(err); reason != tt.reason {
+				t.Fatalf("reason codes do not match: got %q - expected %q", reason, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package admissioncontrol
+
+import (
+	"sync"
+
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// RecordedCall is a single invocation of a RecordingAdmitFunc: the review it
+// was called with, and the decision returned by the wrapped AdmitFunc.
+type RecordedCall struct {
+	Review   *admission.AdmissionReview
+	Response *admission.AdmissionResponse
+	Err      error
+}
+
+// RecordingAdmitFunc wraps an AdmitFunc and records each call to it. It is
+// intended for use in tests, to assert which AdmitFuncs ran (and in what order)
+// when composing them into your own chains or handlers.
+//
+// Pass the Admit method wherever an AdmitFunc is expected. A
+// RecordingAdmitFunc is safe for concurrent use.
+type RecordingAdmitFunc struct {
+	admitFunc AdmitFunc
+
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// NewRecordingAdmitFunc returns a RecordingAdmitFunc that wraps the given
+// AdmitFunc.
+func NewRecordingAdmitFunc(admitFunc AdmitFunc) *RecordingAdmitFunc {
+	return &RecordingAdmitFunc{admitFunc: admitFunc}
+}
+
+// Admit invokes the wrapped AdmitFunc and records the call. It satisfies the
+// AdmitFunc type.
+func (rf *RecordingAdmitFunc) Admit(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+	resp, err := rf.admitFunc(admissionReview)
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	rf.calls = append(rf.calls, RecordedCall{
+		Review:   admissionReview,
+		Response: resp,
+		Err:      err,
+	})
+
+	return resp, err
+}
+
+// Calls returns the calls recorded so far, in the order they were made.
+func (rf *RecordingAdmitFunc) Calls() []RecordedCall {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	calls := make([]RecordedCall, len(rf.calls))
+	copy(calls, rf.calls)
+	return calls
+}
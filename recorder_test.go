@@ -0,0 +1,69 @@
+package admissioncontrol
+
+import (
+	"errors"
+	"testing"
+
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+func TestRecordingAdmitFunc(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	var newNamedAdmitFunc = func(name string, allowed bool) *RecordingAdmitFunc {
+		return NewRecordingAdmitFunc(func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			order = append(order, name)
+			resp := newDefaultDenyResponse()
+			if !allowed {
+				return resp, errors.New(name + " denied admission")
+			}
+
+			resp.Allowed = true
+			return resp, nil
+		})
+	}
+
+	first := newNamedAdmitFunc("first", true)
+	second := newNamedAdmitFunc("second", false)
+	third := newNamedAdmitFunc("third", true)
+
+	// A minimal chain that stops at the first rejection, as a user might write.
+	chain := func(review *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		var resp *admission.AdmissionResponse
+		for _, admitFunc := range []AdmitFunc{first.Admit, second.Admit, third.Admit} {
+			var err error
+			if resp, err = admitFunc(review); err != nil {
+				return resp, err
+			}
+		}
+
+		return resp, nil
+	}
+
+	review := &admission.AdmissionReview{Request: &admission.AdmissionRequest{UID: "1234"}}
+	if _, err := chain(review); err == nil {
+		t.Fatal("expected the chain to deny admission")
+	}
+
+	if got := len(first.Calls()); got != 1 {
+		t.Fatalf("first AdmitFunc called %d times (wanted 1)", got)
+	}
+
+	calls := second.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("second AdmitFunc called %d times (wanted 1)", len(calls))
+	}
+
+	if calls[0].Review != review || calls[0].Err == nil || calls[0].Response.Allowed {
+		t.Fatalf("second AdmitFunc call was not recorded correctly: %#v", calls[0])
+	}
+
+	if got := len(third.Calls()); got != 0 {
+		t.Fatalf("third AdmitFunc should have been short-circuited: called %d times", got)
+	}
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("AdmitFuncs ran out of order: %v", order)
+	}
+}
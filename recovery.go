@@ -0,0 +1,75 @@
+package admissioncontrol
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	log "github.com/go-kit/kit/log"
+	admission "k8s.io/api/admission/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecoveryMiddleware recovers from panics in the wrapped handler, and responds
+// with a valid AdmissionReview that allows or denies admission as per the
+// provided FailurePolicy. This prevents the apiserver from receiving a
+// malformed response (or a closed connection) from the webhook.
+//
+// It is independent of LoggingMiddleware, and should wrap your AdmissionHandlers
+// directly so that the failure policy applies to them.
+func RecoveryMiddleware(logger log.Logger, failurePolicy FailurePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			wrapped := wrapResponseWriter(w)
+			defer func() {
+				if err := recover(); err != nil {
+					logger.Log(
+						"err", err,
+						"msg", "recovered from a panic",
+						"failurePolicy", failurePolicy,
+						"trace", debug.Stack(),
+					)
+
+					// We can't replace a response that is already in-flight.
+					if wrapped.wroteHeader {
+						return
+					}
+
+					writeFailurePolicyResponse(wrapped, logger, failurePolicy)
+				}
+			}()
+
+			next.ServeHTTP(wrapped, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// writeFailurePolicyResponse writes an AdmissionReview that allows or denies
+// admission as per the given FailurePolicy.
+func writeFailurePolicyResponse(w http.ResponseWriter, logger log.Logger, failurePolicy FailurePolicy) {
+	outgoingReview := &admission.AdmissionReview{
+		Response: &admission.AdmissionResponse{
+			Allowed: failurePolicy == FailOpen,
+			Result: &meta.Status{
+				Message: "the admission webhook failed to evaluate the request",
+			},
+		},
+	}
+
+	res, err := json.Marshal(outgoingReview)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Log(
+			"err", err.Error(),
+			"msg", "failed to marshal review response",
+		)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
@@ -0,0 +1,98 @@
+package admissioncontrol
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	panicHandler := &AdmissionHandler{
+		AdmitFunc: func(_ *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+			panic("something went very wrong")
+		},
+		Logger: &noopLogger{},
+	}
+
+	var recoveryTests = []struct {
+		testName      string
+		failurePolicy FailurePolicy
+		shouldAllow   bool
+	}{
+		{
+			testName:      "Panics are denied when failing closed",
+			failurePolicy: FailClosed,
+			shouldAllow:   false,
+		},
+		{
+			testName:      "Panics are allowed when failing open",
+			failurePolicy: FailOpen,
+			shouldAllow:   true,
+		},
+	}
+
+	for _, tt := range recoveryTests {
+		tt := tt
+		t.Run(tt.testName, func(t *testing.T) {
+			handler := RecoveryMiddleware(&noopLogger{}, tt.failurePolicy)(panicHandler)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(`{"kind":"AdmissionReview","apiVersion":"admission.k8s.io/v1beta1","request":{"uid":"1234"}}`),
+			)
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("unexpected status code: got %d (wanted %d)", rr.Code, http.StatusOK)
+			}
+
+			if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Fatalf("unexpected Content-Type: got %q", contentType)
+			}
+
+			review := &admission.AdmissionReview{}
+			if err := json.Unmarshal(rr.Body.Bytes(), review); err != nil {
+				t.Fatalf("couldn't unmarshal the review response: %v", err)
+			}
+
+			if review.Response == nil {
+				t.Fatalf("the AdmissionReview did not contain a response")
+			}
+
+			if review.Response.Allowed != tt.shouldAllow {
+				t.Fatalf("invalid review response: got allowed: %t (want %t)", review.Response.Allowed, tt.shouldAllow)
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddlewareAfterWrite(t *testing.T) {
+	t.Parallel()
+
+	// Write implicitly sends a 200 OK: the partial response cannot be replaced,
+	// and a second AdmissionReview must not be appended to it.
+	partial := `{"kind":"AdmissionReview"`
+	panicHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(partial))
+		panic("something went very wrong")
+	})
+
+	handler := RecoveryMiddleware(&noopLogger{}, FailClosed)(panicHandler)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	handler.ServeHTTP(rr, req)
+
+	if body := rr.Body.String(); body != partial {
+		t.Fatalf("unexpected response body: got %q (wanted %q)", body, partial)
+	}
+}
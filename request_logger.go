@@ -36,6 +36,16 @@ func (rw *responseWriter) WriteHeader(code int) {
 	return
 }
 
+// Write writes the data to the connection, implicitly writing a 200 OK status
+// (as http.ResponseWriter does) if WriteHeader has not been called.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	return rw.ResponseWriter.Write(b)
+}
+
 // LoggingMiddleware logs the incoming HTTP request & its duration.
 func LoggingMiddleware(logger log.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
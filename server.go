@@ -2,6 +2,7 @@ package admissioncontrol
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"golang.org/x/xerrors"
 	"net/http"
@@ -15,8 +16,64 @@ import (
 
 var (
 	defaultGracePeriod = time.Second * 15
+
+	defaultReadTimeout       = time.Second * 15
+	defaultReadHeaderTimeout = time.Second * 5
+	defaultWriteTimeout      = time.Second * 15
+	defaultIdleTimeout       = time.Second * 60
 )
 
+// ServerTimeouts configures the timeouts of the *http.Server returned by
+// NewHTTPServer. Zero values are replaced with sensible defaults.
+type ServerTimeouts struct {
+	// Read is the maximum duration for reading an entire request, including the
+	// body. Defaults to 15 seconds.
+	Read time.Duration
+	// ReadHeader is the maximum duration for reading the request headers, and
+	// guards against Slowloris attacks. Defaults to 5 seconds.
+	ReadHeader time.Duration
+	// Write is the maximum duration before timing out writes of the response.
+	// Defaults to 15 seconds.
+	Write time.Duration
+	// Idle is the maximum duration to wait for the next request on a keep-alive
+	// connection. Defaults to 60 seconds.
+	Idle time.Duration
+}
+
+// NewHTTPServer returns an *http.Server for serving the given handler on addr,
+// with the provided timeouts (or their defaults) applied. It is intended to be
+// passed to NewServer.
+//
+// tlsConf may be nil when serving plaintext HTTP from behind a TLS-terminating
+// proxy.
+func NewHTTPServer(addr string, handler http.Handler, timeouts ServerTimeouts, tlsConf *tls.Config) *http.Server {
+	if timeouts.Read <= 0 {
+		timeouts.Read = defaultReadTimeout
+	}
+
+	if timeouts.ReadHeader <= 0 {
+		timeouts.ReadHeader = defaultReadHeaderTimeout
+	}
+
+	if timeouts.Write <= 0 {
+		timeouts.Write = defaultWriteTimeout
+	}
+
+	if timeouts.Idle <= 0 {
+		timeouts.Idle = defaultIdleTimeout
+	}
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConf,
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+}
+
 // AdmissionServer represents a HTTP server configuration for serving an
 // Admission Controller.
 //
@@ -27,9 +84,22 @@ type AdmissionServer struct {
 	// GracePeriod is defines how long the server allows for in-flight connections
 	// to complete before exiting.
 	GracePeriod time.Duration
+	// PreShutdownHook, if set, is called when shutdown begins, before the server
+	// stops accepting connections. Callers can use it to fail readiness checks,
+	// and wait for the Pod to be removed from its Service endpoints, so that
+	// in-flight admission requests are not dropped. The GracePeriod begins once
+	// the hook returns.
+	PreShutdownHook func()
 }
 
 func (as *AdmissionServer) shutdown(ctx context.Context, gracePeriod time.Duration) error {
+	if as.PreShutdownHook != nil {
+		as.logger.Log(
+			"msg", "running the pre-shutdown hook",
+		)
+		as.PreShutdownHook()
+	}
+
 	timeoutCtx, cancel := context.WithTimeout(ctx, gracePeriod)
 	defer cancel()
 	as.logger.Log(
@@ -141,6 +141,42 @@ func TestAdmissionServer(t *testing.T) {
 		}
 	})
 
+	t.Run("AdmissionServer runs the PreShutdownHook before shutting down", func(t *testing.T) {
+		t.Parallel()
+		testSrv := newTestServer(context.TODO(), t)
+		testSrv.srv.GracePeriod = time.Microsecond * 1
+
+		var hookErr error
+		hookCalled := false
+		testSrv.srv.PreShutdownHook = func() {
+			hookCalled = true
+			// The server must still be serving requests while the hook runs.
+			resp, err := testSrv.client.Get(testSrv.url)
+			if err != nil {
+				hookErr = err
+				return
+			}
+			resp.Body.Close()
+		}
+
+		testSrv.srv.Stop()
+		if !hookCalled {
+			t.Fatal("the PreShutdownHook was not called")
+		}
+
+		if hookErr != nil {
+			t.Fatalf("the server stopped serving before the PreShutdownHook returned: %v", hookErr)
+		}
+
+		if err := testSrv.srv.srv.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+			t.Fatalf(
+				"server did not shutdown after the PreShutdownHook returned: got %v (want %v)",
+				err,
+				http.ErrServerClosed,
+			)
+		}
+	})
+
 	t.Run("AdmissionServer handles a cancellation context and shuts down.", func(t *testing.T) {
 		t.Parallel()
 		ctx, cancel := context.WithCancel(context.Background())
@@ -160,3 +196,42 @@ func TestAdmissionServer(t *testing.T) {
 	})
 
 }
+
+func TestNewHTTPServer(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NotFoundHandler()
+
+	t.Run("Defaults are applied to zero values", func(t *testing.T) {
+		srv := NewHTTPServer(":8443", handler, ServerTimeouts{}, nil)
+
+		if srv.Addr != ":8443" || srv.Handler == nil {
+			t.Fatalf("the address & handler were not set: got %q, %v", srv.Addr, srv.Handler)
+		}
+
+		if srv.ReadTimeout != defaultReadTimeout ||
+			srv.ReadHeaderTimeout != defaultReadHeaderTimeout ||
+			srv.WriteTimeout != defaultWriteTimeout ||
+			srv.IdleTimeout != defaultIdleTimeout {
+			t.Fatalf(
+				"unexpected timeouts: got read=%s readHeader=%s write=%s idle=%s",
+				srv.ReadTimeout, srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout,
+			)
+		}
+	})
+
+	t.Run("Provided timeouts are preserved", func(t *testing.T) {
+		srv := NewHTTPServer(":8443", handler, ServerTimeouts{
+			Read:       time.Second * 30,
+			ReadHeader: time.Second * 2,
+		}, nil)
+
+		if srv.ReadTimeout != time.Second*30 || srv.ReadHeaderTimeout != time.Second*2 {
+			t.Fatalf("the provided timeouts were overridden: got read=%s readHeader=%s", srv.ReadTimeout, srv.ReadHeaderTimeout)
+		}
+
+		if srv.WriteTimeout != defaultWriteTimeout || srv.IdleTimeout != defaultIdleTimeout {
+			t.Fatalf("defaults were not applied to the unset timeouts: got write=%s idle=%s", srv.WriteTimeout, srv.IdleTimeout)
+		}
+	})
+}
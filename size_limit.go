@@ -0,0 +1,35 @@
+package admissioncontrol
+
+import (
+	admission "k8s.io/api/admission/v1beta1"
+)
+
+// WithObjectSizeLimit wraps an AdmitFunc so that objects larger than the limit
+// for their Kind are rejected before it is invoked. limitsByKind maps a Kind
+// (e.g. "ConfigMap") to the maximum size of its serialized object, in bytes:
+// objects of Kinds without a limit are passed through to the AdmitFunc.
+//
+// This allows tighter limits to be applied to specific Kinds than the
+// LimitBytes of the AdmissionHandler, which applies to every request.
+func WithObjectSizeLimit(admitFunc AdmitFunc, limitsByKind map[string]int) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		kind := admissionReview.Request.Kind.Kind
+		limit, ok := limitsByKind[kind]
+		if !ok {
+			return admitFunc(admissionReview)
+		}
+
+		if size := len(admissionReview.Request.Object.Raw); size > limit {
+			resp := newDefaultDenyResponse()
+			return resp, denyf(
+				ReasonObjectTooLarge,
+				"the submitted %s is %d bytes, which exceeds the limit of %d bytes",
+				kind,
+				size,
+				limit,
+			)
+		}
+
+		return admitFunc(admissionReview)
+	}
+}
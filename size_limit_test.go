@@ -0,0 +1,75 @@
+package admissioncontrol
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/xerrors"
+	admission "k8s.io/api/admission/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithObjectSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		configMapKind = meta.GroupVersionKind{Group: "", Kind: "ConfigMap", Version: "v1"}
+		podKind       = meta.GroupVersionKind{Group: "", Kind: "Pod", Version: "v1"}
+		limitsByKind  = map[string]int{"ConfigMap": 1024}
+	)
+
+	var allowAll AdmitFunc = func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp := newDefaultDenyResponse()
+		resp.Allowed = true
+		return resp, nil
+	}
+
+	var denyAll AdmitFunc = func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		return newDefaultDenyResponse(), xerrors.New("denied by the wrapped AdmitFunc")
+	}
+
+	var newConfigMap = func(size int) []byte {
+		return []byte(fmt.Sprintf(
+			`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"hello-config","namespace":"default"},"data":{"config":%q}}`,
+			strings.Repeat("a", size),
+		))
+	}
+
+	oversized := newConfigMap(2048)
+
+	var denyTests = []objectTest{
+		{
+			testName:        "Reject an oversized ConfigMap",
+			admitFunc:       WithObjectSizeLimit(allowAll, limitsByKind),
+			kind:            configMapKind,
+			rawObject:       oversized,
+			expectedMessage: fmt.Sprintf("the submitted ConfigMap is %d bytes, which exceeds the limit of 1024 bytes", len(oversized)),
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a ConfigMap under the limit",
+			admitFunc:   WithObjectSizeLimit(allowAll, limitsByKind),
+			kind:        configMapKind,
+			rawObject:   newConfigMap(512),
+			shouldAllow: true,
+		},
+		{
+			testName:    "Allow a large object of a Kind without a limit",
+			admitFunc:   WithObjectSizeLimit(allowAll, limitsByKind),
+			kind:        podKind,
+			rawObject:   []byte(fmt.Sprintf(`{"metadata":{"name":"hello-app","annotations":{"note":%q}}}`, strings.Repeat("a", 2048))),
+			shouldAllow: true,
+		},
+		{
+			testName:        "Invoke the AdmitFunc for objects under the limit",
+			admitFunc:       WithObjectSizeLimit(denyAll, limitsByKind),
+			kind:            configMapKind,
+			rawObject:       newConfigMap(512),
+			expectedMessage: "denied by the wrapped AdmitFunc",
+			shouldAllow:     false,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
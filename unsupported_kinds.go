@@ -0,0 +1,80 @@
+package admissioncontrol
+
+import (
+	"fmt"
+
+	admission "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UnsupportedKindPolicy determines how an AdmitFunc responds to objects of a
+// Kind it does not inspect - e.g. a Service submitted to EnforcePodAnnotations.
+type UnsupportedKindPolicy string
+
+const (
+	// PassThroughUnsupportedKinds allows objects of Kinds the AdmitFunc does not
+	// inspect. This is the behavior of every built-in AdmitFunc, and allows
+	// webhook configurations to match more resources than a single AdmitFunc
+	// handles.
+	PassThroughUnsupportedKinds UnsupportedKindPolicy = "PassThrough"
+	// RejectUnsupportedKinds denies objects of Kinds the AdmitFunc does not
+	// inspect, with ReasonUnsupportedKind. This surfaces webhook configurations
+	// that match more resources than intended.
+	RejectUnsupportedKinds UnsupportedKindPolicy = "Reject"
+)
+
+// WithUnsupportedKindPolicy wraps a built-in AdmitFunc so that objects of Kinds
+// it does not inspect are handled according to the given policy. The built-in
+// AdmitFuncs pass through unsupported Kinds by default, and so this is only
+// needed to reject them.
+//
+// Your own AdmitFuncs can honor this policy by returning
+// PassThroughUnsupportedKind(admissionReview) for Kinds they do not inspect.
+func WithUnsupportedKindPolicy(policy UnsupportedKindPolicy, admitFunc AdmitFunc) AdmitFunc {
+	return func(admissionReview *admission.AdmissionReview) (*admission.AdmissionResponse, error) {
+		resp, err := admitFunc(admissionReview)
+		if err != nil || policy != RejectUnsupportedKinds || !isUnsupportedKind(resp) {
+			return resp, err
+		}
+
+		return newDefaultDenyResponse(), denyf(
+			ReasonUnsupportedKind,
+			"%s %s",
+			unsupportedKindError,
+			admissionReview.Request.Kind.Kind,
+		)
+	}
+}
+
+// PassThroughUnsupportedKind returns an allowed AdmissionResponse for an object
+// of a Kind that an AdmitFunc does not inspect. The response is marked so that
+// WithUnsupportedKindPolicy can reject it instead.
+func PassThroughUnsupportedKind(admissionReview *admission.AdmissionReview) *admission.AdmissionResponse {
+	resp := newDefaultDenyResponse()
+	resp.Allowed = true
+	resp.Result.Reason = metav1.StatusReason(ReasonUnsupportedKind)
+	resp.Result.Message = fmt.Sprintf(
+		"allowing admission: %s is not inspected by this admission handler",
+		admissionReview.Request.Kind.Kind,
+	)
+
+	return resp
+}
+
+// passThroughUnsupportedKindError returns the response from
+// PassThroughUnsupportedKind if err denies an unsupported Kind - as returned by
+// extractPodTemplate - and returns err otherwise.
+func passThroughUnsupportedKindError(admissionReview *admission.AdmissionReview, err error) (*admission.AdmissionResponse, error) {
+	if ReasonCodeOf(err) == ReasonUnsupportedKind {
+		return PassThroughUnsupportedKind(admissionReview), nil
+	}
+
+	return nil, err
+}
+
+// isUnsupportedKind returns true if resp was returned by
+// PassThroughUnsupportedKind.
+func isUnsupportedKind(resp *admission.AdmissionResponse) bool {
+	return resp != nil && resp.Allowed && resp.Result != nil &&
+		resp.Result.Reason == metav1.StatusReason(ReasonUnsupportedKind)
+}
@@ -0,0 +1,180 @@
+package admissioncontrol
+
+import (
+	"regexp"
+	"testing"
+
+	admission "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestUnsupportedKindPolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pattern    = regexp.MustCompile(`^[a-z]+$`)
+		annotation = map[string]func(string) bool{"team": func(string) bool { return true }}
+	)
+
+	// DenyClusterScopedCreation and DenyDeprecatedAnnotations inspect objects of
+	// every Kind, and are not listed.
+	var builtins = map[string]AdmitFunc{
+		"DenyIngresses":                        DenyIngresses(nil),
+		"DenyPublicLoadBalancers":              DenyPublicLoadBalancers(nil, GCP),
+		"ValidateLocalTrafficPolicy":           ValidateLocalTrafficPolicy(nil),
+		"DenyCrossNamespaceSelectorOverlap":    DenyCrossNamespaceSelectorOverlap(map[string]string{"app": "api"}),
+		"DenyNonFQDNExternalNames":             DenyNonFQDNExternalNames(nil),
+		"EnforcePodAnnotations":                EnforcePodAnnotations(nil, annotation),
+		"EnforcePodAnnotationsAnyOf":           EnforcePodAnnotationsAnyOf(nil, []map[string]func(string) bool{annotation}),
+		"EnforceServiceAccountAnnotations":     EnforceServiceAccountAnnotations(nil, annotation),
+		"DenyClusterAdminBindings":             DenyClusterAdminBindings(""),
+		"DenyAggregatedClusterRoleEdits":       DenyAggregatedClusterRoleEdits(""),
+		"RequireLivenessInitialDelay":          RequireLivenessInitialDelay(nil, 5),
+		"DenyPrivilegedContainerPorts":         DenyPrivilegedContainerPorts(nil),
+		"DenyDuplicateMountPaths":              DenyDuplicateMountPaths(nil),
+		"EnforceContainerNames":                EnforceContainerNames(nil, pattern),
+		"RestrictSecretReferences":             RestrictSecretReferences(nil, pattern),
+		"DenyAllowAllNetworkPolicies":          DenyAllowAllNetworkPolicies(nil),
+		"WarnInteractiveContainers":            WarnInteractiveContainers(nil),
+		"WarnLongPreStopHooks":                 WarnLongPreStopHooks(nil, 30),
+		"WarnZeroReplicas":                     WarnZeroReplicas(nil),
+		"WarnTopologyHintsMisuse":              WarnTopologyHintsMisuse(nil),
+		"WarnDeploymentsLackingRequestsForHPA": WarnDeploymentsLackingRequestsForHPA(nil),
+		"WarnSensitivePortsExposed":            WarnSensitivePortsExposed(nil, []int32{9090}),
+		"DenyPublishNotReadyAddresses":         DenyPublishNotReadyAddresses(nil),
+		"RequireSessionAffinityTimeout":        RequireSessionAffinityTimeout(nil),
+		"WarnInlineSecretEnv":                  WarnInlineSecretEnv(nil, []*regexp.Regexp{pattern}),
+		"EnforceRestrictedProfile":             EnforceRestrictedProfile(nil),
+		"DenyBroadPDB":                         DenyBroadPDB(nil),
+		"DenyDefaultServiceAccountToken":       DenyDefaultServiceAccountToken(nil),
+		"RequireTerminationGracePeriodBounds":  RequireTerminationGracePeriodBounds(nil, 10, 60),
+		"DenySidecarInjectionOptOut":           DenySidecarInjectionOptOut(nil, map[string]string{"sidecar.istio.io/inject": "false"}),
+		"RequireFsGroup":                       RequireFsGroup(nil),
+		"RequireResourceClaims":                RequireResourceClaims(nil, []string{"nvidia.com/gpu"}),
+		"EnforceImageDigest":                   EnforceImageDigest(nil, nil),
+		"RequireRunAsUserInRange":              RequireRunAsUserInRange(map[string][2]int64{"default": {1000, 2000}}),
+		"LimitConfigMapSize":                   LimitConfigMapSize(nil, 1024),
+		"RestrictPVCAccessModes":               RestrictPVCAccessModes(nil, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}),
+		"EnforceSecretTypeConventions":         EnforceSecretTypeConventions(map[string]corev1.SecretType{"tls-": corev1.SecretTypeTLS}),
+		"DenyWindowsHostProcess":               DenyWindowsHostProcess(nil),
+		"RequireMemoryEmptyDirSizeLimit":       RequireMemoryEmptyDirSizeLimit(nil),
+		"DenyNeverPullPolicy":                  DenyNeverPullPolicy(nil),
+		"DenyDirectNodeAssignment":             DenyDirectNodeAssignment(nil),
+		"ValidateReadinessGateConsistency":     ValidateReadinessGateConsistency(nil),
+		"EnforceCRDField":                      EnforceCRDField(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}, "spec.size", func(string) bool { return true }),
+		"ValidateDefaultContainerAnnotation":   ValidateDefaultContainerAnnotation(nil),
+		"InjectDefaultSecurityContext":         InjectDefaultSecurityContext(nil, corev1.SecurityContext{}),
+		"RequireRuntimeClass":                  RequireRuntimeClass(nil, []string{"gvisor"}),
+		"DenyUniversalTolerations":             DenyUniversalTolerations(nil),
+		"RequireVolumes":                       RequireVolumes(nil, []string{"logging"}),
+		"ValidateInitContainerRestartPolicy":   ValidateInitContainerRestartPolicy(nil, false),
+		"ValidateHugePages":                    ValidateHugePages(nil),
+		"RequireChartVersionAnnotation":        RequireChartVersionAnnotation(nil, "helm.sh/chart-version"),
+		"RequireSignedImages":                  RequireSignedImages(nil, fakeImageVerifier{}),
+		"DenyDebugCapabilities":                DenyDebugCapabilities(nil),
+		"RequireServiceOwnerLabel":             RequireServiceOwnerLabel(nil, "owner"),
+		"WarnImageArchitecture":                WarnImageArchitecture("arm64", &fakeRegistryClient{}),
+		"RequireReferencedResourcesExist":      RequireReferencedResourcesExist(nil, &fakeResourceLister{}),
+		"DenyConflictingIngressPaths":          DenyConflictingIngressPaths(&fakeIngressLister{}),
+		"PreflightQuotaCheck":                  PreflightQuotaCheck(&fakeQuotaLister{}),
+		"RequireGPUScheduling":                 RequireGPUScheduling(nil, "nvidia.com/gpu", nil),
+		"RequireProgressDeadline":              RequireProgressDeadline(nil, 600),
+		"DenyUnmaskedProcMount":                DenyUnmaskedProcMount(nil),
+		"DenyExternalEndpoints":                DenyExternalEndpoints(nil, []string{"10.0.0.0/8"}),
+		"DenyServiceLinks":                     DenyServiceLinks(nil),
+		"LimitAffinityTerms":                   LimitAffinityTerms(nil, 10),
+		"EnforceFIPSImagePolicy":               EnforceFIPSImagePolicy(nil, []string{"registry.example.com"}),
+		"RequireAppArmorProfile":               RequireAppArmorProfile(nil, []string{"runtime/default"}),
+		"RestrictAffinityNamespaceSelectors":   RestrictAffinityNamespaceSelectors(nil),
+		"WarnClusterTrafficPolicyOnLB":         WarnClusterTrafficPolicyOnLB(nil),
+		"DenyPullPolicyChanges":                DenyPullPolicyChanges(),
+		"ValidateQoSConsistency":               ValidateQoSConsistency(nil, corev1.PodQOSGuaranteed),
+		"RequireZoneSpread":                    RequireZoneSpread(nil),
+		"ValidateWorkingDir":                   ValidateWorkingDir(nil),
+		"ValidateExclusiveCPU":                 ValidateExclusiveCPU(nil),
+		"RequireRevisionHistoryLimit":          RequireRevisionHistoryLimit(nil, 10),
+	}
+
+	var newWidgetReview = func() *admission.AdmissionReview {
+		review := &admission.AdmissionReview{
+			Request: &admission.AdmissionRequest{
+				Kind:      meta.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+				Namespace: "default",
+				Operation: admission.Update,
+			},
+		}
+		review.Request.Object.Raw = []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"hello-widget","namespace":"default"}}`)
+		review.Request.OldObject.Raw = review.Request.Object.Raw
+
+		return review
+	}
+
+	for name, admitFunc := range builtins {
+		name, admitFunc := name, admitFunc
+		t.Run(name, func(t *testing.T) {
+			resp, err := admitFunc(newWidgetReview())
+			if err != nil {
+				t.Fatalf("unrelated Kinds should be allowed by default: got %v", err)
+			}
+
+			if !resp.Allowed {
+				t.Fatalf("unrelated Kinds should be allowed by default: got allowed=%t", resp.Allowed)
+			}
+
+			_, err = WithUnsupportedKindPolicy(PassThroughUnsupportedKinds, admitFunc)(newWidgetReview())
+			if err != nil {
+				t.Fatalf("unrelated Kinds should be allowed when passed through: got %v", err)
+			}
+
+			_, err = WithUnsupportedKindPolicy(RejectUnsupportedKinds, admitFunc)(newWidgetReview())
+			if err == nil {
+				t.Fatalf("unrelated Kinds should be rejected when configured to")
+			}
+
+			if reason := ReasonCodeOf(err); reason != ReasonUnsupportedKind {
+				t.Fatalf("reason codes do not match: got %q - expected %q", reason, ReasonUnsupportedKind)
+			}
+		})
+	}
+}
+
+func TestWithUnsupportedKindPolicySupportedKinds(t *testing.T) {
+	t.Parallel()
+
+	var podKind = meta.GroupVersionKind{
+		Group:   "",
+		Kind:    "Pod",
+		Version: "v1",
+	}
+
+	var denyTests = []objectTest{
+		{
+			testName:    "Allow a supported Kind that passes the AdmitFunc",
+			admitFunc:   WithUnsupportedKindPolicy(RejectUnsupportedKinds, DenyNeverPullPolicy(nil)),
+			kind:        podKind,
+			object:      newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}}),
+			shouldAllow: true,
+		},
+		{
+			testName:  "Reject a supported Kind that fails the AdmitFunc",
+			admitFunc: WithUnsupportedKindPolicy(RejectUnsupportedKinds, DenyNeverPullPolicy(nil)),
+			kind:      podKind,
+			object: newTestPod("default", corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullNever},
+			}}),
+			expectedMessage: `the submitted Pods have containers with imagePullPolicy: Never: [app]`,
+			shouldAllow:     false,
+		},
+		{
+			testName:    "Allow a whitelisted namespace",
+			admitFunc:   WithUnsupportedKindPolicy(RejectUnsupportedKinds, DenyNeverPullPolicy([]string{"kube-system"})),
+			kind:        podKind,
+			object:      newTestPod("kube-system", corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1", ImagePullPolicy: corev1.PullNever}}}),
+			shouldAllow: true,
+		},
+	}
+
+	runObjectTests(t, denyTests)
+}
@@ -0,0 +1,85 @@
+package admissioncontrol
+
+import (
+	"net/url"
+	"regexp"
+	"sync"
+
+	"golang.org/x/xerrors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// semverRegexp matches a Semantic Versioning 2.0.0 version, with an optional
+// leading "v". See https://semver.org/
+var semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]func(string) bool{
+		"dns":      isDNSName,
+		"semver":   semverRegexp.MatchString,
+		"url":      isURL,
+		"nonEmpty": func(s string) bool { return s != "" },
+	}
+)
+
+// RegisterValidator makes an annotation value validator available by name, so
+// that it can be referenced from configuration and resolved with
+// ResolveValidators.
+//
+// The "dns", "semver", "url" & "nonEmpty" validators are registered by default.
+// RegisterValidator panics if fn is nil, or if a validator is already
+// registered with the same name.
+func RegisterValidator(name string, fn func(string) bool) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	if fn == nil {
+		panic("admissioncontrol: RegisterValidator validator is nil")
+	}
+
+	if _, dup := validators[name]; dup {
+		panic("admissioncontrol: RegisterValidator called twice for validator " + name)
+	}
+
+	validators[name] = fn
+}
+
+// ResolveValidators resolves a map of annotation keys to validator names (e.g.
+// as read from a config file) to the validator funcs registered under those
+// names. The result can be passed to EnforcePodAnnotations.
+//
+// An error is returned if any of the named validators are not registered.
+func ResolveValidators(annotations map[string]string) (map[string]func(string) bool, error) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	resolved := make(map[string]func(string) bool, len(annotations))
+	for key, name := range annotations {
+		fn, ok := validators[name]
+		if !ok {
+			return nil, xerrors.Errorf("no validator named %q is registered (for annotation %q)", name, key)
+		}
+
+		resolved[key] = fn
+	}
+
+	return resolved, nil
+}
+
+// isDNSName returns true if s is a valid DNS (RFC 1123) subdomain.
+func isDNSName(s string) bool {
+	return len(validation.IsDNS1123Subdomain(s)) == 0
+}
+
+// isURL returns true if s is an absolute URL with a scheme and host.
+func isURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme != "" && u.Host != ""
+}
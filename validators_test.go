@@ -0,0 +1,75 @@
+package admissioncontrol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveValidators(t *testing.T) {
+	t.Parallel()
+
+	var validatorTests = []struct {
+		validator string
+		value     string
+		valid     bool
+	}{
+		{"dns", "api.example.com", true},
+		{"dns", "Not_A_Hostname", false},
+		{"semver", "v1.2.3", true},
+		{"semver", "1.2.3-rc.1+build.5", true},
+		{"semver", "1.2", false},
+		{"url", "https://example.com/builds/1", true},
+		{"url", "example.com", false},
+		{"nonEmpty", "anything", true},
+		{"nonEmpty", "", false},
+	}
+
+	for _, tt := range validatorTests {
+		resolved, err := ResolveValidators(map[string]string{"key": tt.validator})
+		if err != nil {
+			t.Fatalf("failed to resolve %q: %v", tt.validator, err)
+		}
+
+		if valid := resolved["key"](tt.value); valid != tt.valid {
+			t.Errorf("%s(%q): got %t (wanted %t)", tt.validator, tt.value, valid, tt.valid)
+		}
+	}
+}
+
+func TestResolveValidatorsUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := ResolveValidators(map[string]string{"buildVersion": "doesNotExist"})
+	if err == nil {
+		t.Fatal("expected an error for an unregistered validator")
+	}
+
+	if !strings.Contains(err.Error(), "doesNotExist") {
+		t.Fatalf("error does not name the validator: %v", err)
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	t.Parallel()
+
+	RegisterValidator("team", func(s string) bool { return strings.HasPrefix(s, "team-") })
+
+	resolved, err := ResolveValidators(map[string]string{"owner": "team", "buildVersion": "semver"})
+	if err != nil {
+		t.Fatalf("failed to resolve validators: %v", err)
+	}
+
+	if !resolved["owner"]("team-payments") || resolved["owner"]("payments") {
+		t.Fatal("the custom validator was not resolved")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("registering a duplicate validator should panic")
+			}
+		}()
+
+		RegisterValidator("semver", func(string) bool { return true })
+	}()
+}